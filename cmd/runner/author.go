@@ -0,0 +1,234 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/author"
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/internal/ui"
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+)
+
+// errAuthoringAborted is returned when the author quits or input ends before a koan is complete
+var errAuthoringAborted = errors.New("aborted, nothing was written")
+
+// NewLesson interactively creates the next numbered lesson file in lessonsDir.
+// Numbers and koan IDs are chosen so they do not clash with anything in lessons.
+func NewLesson(lessons fs.FS, lessonsDir string, console ui.UI) error {
+	if lessonsDir == "" {
+		return errors.New("no lessons directory to write to; give one with --lessons")
+	}
+	console.DisplayInfo("Creating a new lesson in " + lessonsDir)
+
+	title, err := promptRequired(console, "Lesson title (e.g. Time Zones - Running Jobs in UTC)")
+	if err != nil {
+		return err
+	}
+	description, err := promptRequired(console, "Lesson description")
+	if err != nil {
+		return err
+	}
+
+	filename, err := author.NextLessonFile(lessons, lessonsDir, title)
+	if err != nil {
+		return err
+	}
+	filename = console.PromptString("File", filename)
+	if _, err := os.Stat(filename); err == nil {
+		return fmt.Errorf("%s already exists; use cronkoans new koan to add to it", filename)
+	}
+
+	used := author.ExistingIDs(lessons)
+	prefix := strings.TrimSuffix(filepath.Base(filename), ".yaml")
+	if _, rest, ok := strings.Cut(prefix, "_"); ok {
+		prefix = rest
+	}
+
+	pack, err := koan.LoadPackFS(lessons)
+	if err != nil {
+		return err
+	}
+
+	lesson := &koan.Lesson{Title: title, Description: description}
+	for {
+		k, err := promptKoan(console, prefix, used, pack.Dialect)
+		if err != nil {
+			return err
+		}
+		used[k.ID] = true
+		lesson.Koans = append(lesson.Koans, *k)
+
+		if !console.PromptYesNo("Add another koan?") {
+			break
+		}
+	}
+
+	if err := author.WriteNode(filename, author.NewLessonNode(lesson)); err != nil {
+		return err
+	}
+	return checkWritten(filename, console)
+}
+
+// NewKoan interactively adds a koan to a lesson file, by default the highest
+// numbered one in lessonsDir, with an ID that is unused in lessons
+func NewKoan(lessons fs.FS, lessonsDir, filename string, console ui.UI) error {
+	if filename == "" {
+		if lessonsDir == "" {
+			return errors.New("no lesson file to add to; name one, or give its directory with --lessons")
+		}
+		latest, err := author.LatestLessonFile(lessonsDir)
+		if err != nil {
+			return err
+		}
+		filename = latest
+	}
+
+	lesson, err := koan.LoadLesson(filename)
+	if err != nil {
+		return err
+	}
+	console.DisplayInfo(fmt.Sprintf("Adding a koan to %s (%s)", lesson.Title, filename))
+
+	dialect := koan.DialectCron
+	if len(lesson.Koans) > 0 {
+		dialect = lesson.Koans[0].Dialect
+	}
+	k, err := promptKoan(console, lesson.IDPrefix(), author.ExistingIDs(lessons), dialect)
+	if err != nil {
+		return err
+	}
+	if err := author.AppendKoan(filename, k); err != nil {
+		return err
+	}
+	return checkWritten(filename, console)
+}
+
+// promptKoan asks for every field of a koan, suggesting an unused ID with the
+// given prefix and checking the answer in dialect as it is typed
+func promptKoan(console ui.UI, prefix string, used map[string]bool, dialect koan.Dialect) (*koan.Koan, error) {
+	k := &koan.Koan{Dialect: dialect}
+	var err error
+	for {
+		k.ID = console.PromptString("Koan ID", author.SuggestID(prefix, used))
+		if !used[k.ID] {
+			break
+		}
+		console.DisplayWarning(fmt.Sprintf("Koan ID %q is already used by another koan", k.ID))
+	}
+	if k.Description, err = promptRequired(console, "What does this koan teach?"); err != nil {
+		return nil, err
+	}
+	if k.Question, err = promptRequired(console, "Question (the schedule in plain English)"); err != nil {
+		return nil, err
+	}
+
+	for {
+		if k.Incomplete, err = promptRequired(console, "Incomplete expression, with __ for the blank"); err != nil {
+			return nil, err
+		}
+		if strings.Contains(k.Incomplete, "__") {
+			break
+		}
+		console.DisplayWarning("The expression needs a __ placeholder for the learner to fill in")
+	}
+
+	for empty := 0; ; {
+		console.DisplayInfo("Type the answer that fills the blank")
+		k.Answer = console.PromptForAnswerWithPreview(k)
+		if k.Answer == "quit" {
+			return nil, errAuthoringAborted
+		}
+		// Give up on empty answers the way promptRequired does
+		if k.Answer == "" {
+			if empty++; empty == 3 {
+				return nil, errAuthoringAborted
+			}
+			console.DisplayWarning("This field is required")
+			continue
+		}
+		if err := dialect.Validate(k.CompleteCronExpression()); err != nil {
+			console.DisplayWarning(fmt.Sprintf("%s is not a valid %s: %v", k.CompleteCronExpression(), dialect.Noun(), err))
+			continue
+		}
+		if dialect == koan.DialectSystemd {
+			console.DisplaySuccess(k.CompleteCronExpression())
+			break
+		}
+		warning, never := reachWarning(k.CompleteCronExpression())
+		if warning != "" {
+			console.DisplayWarning(warning)
+		}
+		if never {
+			continue
+		}
+		console.DisplaySuccess(k.CompleteCronExpression() + " - " + cronexpr.Describe(k.CompleteCronExpression()))
+		break
+	}
+
+	levels := []string{"general direction", "more specific", "almost gives it away"}
+	for i, level := range levels {
+		hint, err := promptRequired(console, fmt.Sprintf("Hint %d (%s)", i+1, level))
+		if err != nil {
+			return nil, err
+		}
+		k.Hints = append(k.Hints, hint)
+	}
+
+	proposed := ""
+	if dialect != koan.DialectSystemd {
+		proposed = author.ProposeExplanation(k.Answer, k.CompleteCronExpression())
+	}
+	k.Explanation = console.PromptString("Explanation", proposed)
+	return k, nil
+}
+
+// reachWarning describes a valid expression that a learner could wait years
+// for: one that never fires, which never makes a good answer, or one that
+// fires less than once a year. It is empty for other expressions.
+func reachWarning(expr string) (warning string, never bool) {
+	err := cronexpr.CheckReach(expr, 1, time.Now())
+	switch {
+	case err == nil:
+		return "", false
+	case errors.Is(err, cronexpr.ErrNeverFires):
+		return "This expression never fires: " + expr, true
+	}
+	return fmt.Sprintf("%s %v", expr, err), false
+}
+
+// promptRequired asks again until the answer is not empty, giving up after a
+// few empty answers so that scripted input which runs out cannot loop forever
+func promptRequired(console ui.UI, question string) (string, error) {
+	for i := 0; i < 3; i++ {
+		if answer := console.PromptString(question, ""); answer != "" {
+			return answer, nil
+		}
+		console.DisplayWarning("This field is required")
+	}
+	return "", errAuthoringAborted
+}
+
+// checkWritten loads the file that was just written and reports whether it is a valid lesson
+func checkWritten(filename string, console ui.UI) error {
+	fc := koan.CheckLessonFile(filename)
+	if !fc.OK() {
+		for _, p := range fc.Problems {
+			console.DisplayWarning(p.String())
+		}
+		for _, kc := range fc.Koans {
+			for _, p := range kc.Problems {
+				console.DisplayWarning(p.String())
+			}
+		}
+		return fmt.Errorf("wrote %s, but it has problems; fix them and run cronkoans validate", filename)
+	}
+
+	console.DisplaySuccess("Wrote " + filename)
+	return nil
+}
@@ -0,0 +1,488 @@
+package runner
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/author"
+	"github.com/dwildt/cronkoans/internal/config"
+	"github.com/dwildt/cronkoans/internal/ui"
+	"github.com/dwildt/cronkoans/pkg/crontab"
+)
+
+// Main runs cronkoans with the command-line arguments that follow the
+// program name, and is all that the cronkoans binary does. Global flags come
+// before the command, and the command's own flags after it.
+func Main(args []string, console *ui.Console) error {
+	flags := flag.NewFlagSet("cronkoans", flag.ContinueOnError)
+	helpFlag := flags.Bool("help", false, "Show help message")
+	versionFlag := flags.Bool("version", false, "Show version")
+	var lessonDirs stringList
+	flags.Var(&lessonDirs, "lessons", "Lessons directory layered over the bundled lessons (repeatable)")
+	noBuiltinFlag := flags.Bool("no-builtin", false, "Use only the --lessons directories, not the bundled lessons")
+	packFlag := flags.String("pack", BuiltinPack, "Lesson pack to learn from (see cronkoans pack list)")
+	tuiFlag := flags.Bool("tui", false, "Use the full-screen terminal interface")
+	configPath := flags.String("config", config.DefaultPath(), "Path to the config file")
+	colorFlag := flags.String("color", "auto", "Use colors: auto, always or never")
+	noColorFlag := flags.Bool("no-color", false, "Disable colors (same as --color=never)")
+	calendarFlag := flags.Bool("calendar", false, "Show this month's runs after each solved koan")
+	asciiFlag := flags.Bool("ascii", false, "Use plain ASCII instead of symbols and emoji")
+	outputFlag := flags.String("output", "text", "Output format for status, list, validate, normalize, diff, analyze, lint and convert: text, json, yaml or junit (validate only)")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	if err := configureTheme(console, cfg, *colorFlag, isFlagSet(flags, "color"), *noColorFlag, *asciiFlag); err != nil {
+		return err
+	}
+
+	console.SetShowCalendar(*calendarFlag || cfg.Calendar)
+
+	// Handle version flag
+	if *versionFlag {
+		console.DisplayVersion(Version)
+		return nil
+	}
+
+	// Handle help flag
+	if *helpFlag {
+		console.DisplayHelp()
+		return nil
+	}
+
+	// Get the command (if any)
+	args = flags.Args()
+	command := "interactive"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	outputFormat, err := ui.ParseOutputFormatFor(command, *outputFlag)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "practice":
+		return runPractice(console, args[1:])
+	case "pack":
+		return runPack(console, args[1:])
+	case "normalize":
+		return Normalize(console, args[1:], outputFormat)
+	case "diff":
+		return runDiff(console, args[1:], outputFormat)
+	case "calendar":
+		return runCalendar(console, args[1:])
+	case "analyze":
+		return runAnalyze(console, args[1:], outputFormat)
+	case "lint":
+		return runLint(console, args[1:], outputFormat)
+	case "convert":
+		return runConvert(console, args[1:], outputFormat)
+	}
+
+	// dev takes --lessons after the command too, so parse it before loading lessons
+	var devOpts DevOptions
+	if command == "dev" {
+		if devOpts, err = parseDev(&lessonDirs, args[1:]); err != nil {
+			return err
+		}
+	}
+
+	packName := *packFlag
+	if *noBuiltinFlag {
+		if isFlagSet(flags, "pack") {
+			return fmt.Errorf("--no-builtin and --pack cannot be used together")
+		}
+		packName = ""
+	}
+	dirs := append(cfg.Lessons, lessonDirs...)
+	lessons, err := LoadLessonsFS(packName, dirs)
+	if err != nil {
+		return err
+	}
+
+	// These commands work on lessons that may not load yet, or load them on their own
+	switch command {
+	case "validate":
+		return runValidate(lessons, console, args[1:], outputFormat)
+	case "new":
+		return runNew(lessons, AuthoringDir(dirs), console, args[1:])
+	case "generate":
+		return runGenerate(lessons, AuthoringDir(dirs), console, args[1:])
+	case "dev":
+		return Dev(lessons, console, devOpts)
+	case "serve":
+		return runServe(lessons, console, args[1:])
+	}
+
+	// Create runner
+	r, err := NewRunner(lessons, console)
+	if err != nil {
+		return err
+	}
+	r.SetOutputFormat(outputFormat)
+
+	// Execute command
+	switch command {
+	case "interactive", "start", "":
+		if command == "start" {
+			if err := parseStart(r, args[1:]); err != nil {
+				return err
+			}
+		}
+		if *tuiFlag {
+			return r.RunTUI()
+		}
+		return r.RunInteractive()
+
+	case "status":
+		return r.ShowStatus()
+
+	case "list":
+		return r.ListLessons()
+
+	case "reset":
+		return r.Reset()
+
+	case "help":
+		console.DisplayHelp()
+		return nil
+
+	default:
+		return fmt.Errorf("unknown command: %s\nRun 'cronkoans help' for usage information", command)
+	}
+}
+
+// runNew dispatches the authoring subcommands: new lesson, and new koan [file]
+func runNew(lessons fs.FS, lessonsDir string, console *ui.Console, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: cronkoans new lesson | cronkoans new koan [lesson-file]")
+	}
+
+	switch args[0] {
+	case "lesson":
+		return NewLesson(lessons, lessonsDir, console)
+	case "koan":
+		file := ""
+		if len(args) > 1 {
+			file = args[1]
+		}
+		return NewKoan(lessons, lessonsDir, file, console)
+	}
+	return fmt.Errorf("unknown thing to create: %s (want lesson or koan)", args[0])
+}
+
+// parseStart applies the start flags, which narrow the session to one topic
+func parseStart(r *Runner, args []string) error {
+	flags := flag.NewFlagSet("start", flag.ContinueOnError)
+	tag := flags.String("tag", "", "Only koans with this tag, such as ranges")
+	koanID := flags.String("koan", "", "Only the koan with this ID, such as steps_1")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *koanID != "" {
+		return r.FilterKoan(*koanID)
+	}
+	if *tag == "" {
+		return nil
+	}
+	return r.FilterTag(*tag)
+}
+
+// runPack dispatches the pack subcommands: install, list, remove and info
+func runPack(console *ui.Console, args []string) error {
+	usage := fmt.Errorf("usage: cronkoans pack install <dir|file.zip|file.tar.gz> | list | remove <name> | info <name>")
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		return PackList(console)
+	case args[0] == "install" && len(args) == 2:
+		return PackInstall(console, args[1])
+	case args[0] == "remove" && len(args) == 2:
+		return PackRemove(console, args[1])
+	case args[0] == "info" && len(args) == 2:
+		return PackInfo(console, args[1])
+	}
+	return usage
+}
+
+// runGenerate parses the generate flags. Expressions come from the arguments or --from;
+// without any, --count random koans are generated for --concept.
+func runGenerate(lessons fs.FS, lessonsDir string, console *ui.Console, args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	concept := flags.String("concept", "", "Concept to practise: ranges, steps, lists or names")
+	count := flags.Int("count", 5, "Number of random koans to generate")
+	seed := flags.Int64("seed", time.Now().UnixNano(), "Random seed, to generate the same lesson again")
+	from := flags.String("from", "", "File with one cron expression per line")
+	title := flags.String("title", "", "Lesson title")
+	out := flags.String("out", "", "Lesson file to write (default: next numbered file in the lessons directory)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	opts := GenerateOptions{
+		Expressions: flags.Args(),
+		Count:       *count,
+		Seed:        *seed,
+		Title:       *title,
+		Out:         *out,
+	}
+	if *concept != "" {
+		c, err := author.ParseConcept(*concept)
+		if err != nil {
+			return err
+		}
+		opts.Concept = c
+	}
+	if *from != "" {
+		expressions, err := ReadExpressions(*from)
+		if err != nil {
+			return err
+		}
+		opts.Expressions = append(opts.Expressions, expressions...)
+	}
+
+	return Generate(lessons, lessonsDir, console, opts)
+}
+
+// runPractice parses the practice flags and starts an endless practice session
+func runPractice(console *ui.Console, args []string) error {
+	flags := flag.NewFlagSet("practice", flag.ContinueOnError)
+	concept := flags.String("concept", ConceptMixed, "Concept to practise: ranges, steps, lists, names or mixed")
+	difficulty := flags.Int("difficulty", 0, "Starting difficulty from 1 to 5 (default: continue where you left off)")
+	count := flags.Int("count", 0, "Number of koans (default: until you quit)")
+	seed := flags.Int64("seed", 0, "Random seed, to practise the same koans again")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return Practice(console, PracticeOptions{
+		Concept:    *concept,
+		Difficulty: *difficulty,
+		Count:      *count,
+		Seed:       *seed,
+	})
+}
+
+// runValidate parses the validate flags and checks the lesson files
+func runValidate(lessons fs.FS, console *ui.Console, args []string, format ui.OutputFormat) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	reach := flags.Bool("reach", false, "Also report answers whose expression never fires")
+	minRuns := flags.Int("min-runs", 0, "Also report answers whose expression fires fewer times a year (implies --reach)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("usage: cronkoans validate [--reach] [--min-runs <n>]")
+	}
+	return Validate(lessons, console, ValidateOptions{Reach: *reach, MinRuns: *minRuns}, format)
+}
+
+// runDiff parses the diff flags and compares two expressions
+func runDiff(console *ui.Console, args []string, format ui.OutputFormat) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	from := flags.String("from", "", "Start of the window, such as 2026-11-01 or 2026-11-01T09:30 (default: now)")
+	days := flags.Int("days", DefaultWindowDays, "Number of days to compare")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("usage: cronkoans diff [--from <date>] [--days <n>] \"<old expression>\" \"<new expression>\"")
+	}
+
+	opts := DiffOptions{Before: flags.Arg(0), After: flags.Arg(1), Days: *days}
+	if *from != "" {
+		start, err := ParseWindowStart(*from, time.Local)
+		if err != nil {
+			return err
+		}
+		opts.From = start
+	}
+	return Diff(console, opts, format)
+}
+
+// runAnalyze parses the analyze flags and analyses the load of a crontab file
+func runAnalyze(console *ui.Console, args []string, format ui.OutputFormat) error {
+	flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	from := flags.String("from", "", "Start of the window, such as 2026-11-01 or 2026-11-01T09:30 (default: now)")
+	days := flags.Int("days", DefaultWindowDays, "Number of days to analyse")
+	hotspot := flags.Int("hotspot", crontab.DefaultHotspot, "Number of jobs starting in the same minute that make a hotspot")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: cronkoans analyze [--from <date>] [--days <n>] [--hotspot <n>] <crontab-file>")
+	}
+
+	opts := AnalyzeOptions{File: flags.Arg(0), Days: *days, Hotspot: *hotspot}
+	if *from != "" {
+		start, err := ParseWindowStart(*from, time.Local)
+		if err != nil {
+			return err
+		}
+		opts.From = start
+	}
+	return Analyze(console, opts, format)
+}
+
+// runLint parses the lint flags and lints crontab files or expressions
+func runLint(console *ui.Console, args []string, format ui.OutputFormat) error {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	disable := flags.String("disable", "", "Comma-separated names of rules to skip")
+	rules := flags.Bool("rules", false, "List the lint rules")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *rules {
+		return LintRules(console, format)
+	}
+
+	opts := LintOptions{Targets: flags.Args()}
+	if *disable != "" {
+		opts.Disabled = strings.Split(*disable, ",")
+	}
+	return Lint(console, opts, format)
+}
+
+// runConvert parses the convert flags and translates between cron and systemd timers
+func runConvert(console *ui.Console, args []string, format ui.OutputFormat) error {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	to := flags.String("to", "", "Convert cron expressions to this syntax: systemd")
+	from := flags.String("from", "", "Convert expressions in this syntax to cron: systemd")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	return Convert(console, ConvertOptions{Targets: flags.Args(), From: *from, To: *to}, format)
+}
+
+// runCalendar parses the calendar flags and shows when an expression fires
+func runCalendar(console *ui.Console, args []string) error {
+	flags := flag.NewFlagSet("calendar", flag.ContinueOnError)
+	month := flags.String("month", "", "Month to show, such as 2026-11 (default: this month)")
+	day := flags.String("day", "", "Day to show minute by minute, such as 2026-11-03")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: cronkoans calendar [--month <yyyy-mm> | --day <yyyy-mm-dd>] \"<expression>\"")
+	}
+	if *month != "" && *day != "" {
+		return fmt.Errorf("--month and --day cannot be used together")
+	}
+
+	opts := CalendarOptions{Expression: flags.Arg(0)}
+	var err error
+	if *month != "" {
+		if opts.Month, err = ParseCalendarMonth(*month, time.Local); err != nil {
+			return err
+		}
+	}
+	if *day != "" {
+		if opts.Day, err = ParseCalendarDay(*day, time.Local); err != nil {
+			return err
+		}
+	}
+	return Calendar(console, opts)
+}
+
+// parseDev parses the dev flags, adding any --lessons directories to dirs
+func parseDev(dirs *stringList, args []string) (DevOptions, error) {
+	flags := flag.NewFlagSet("dev", flag.ContinueOnError)
+	flags.Var(dirs, "lessons", "Lessons directory to watch (repeatable)")
+	koanID := flags.String("koan", "", "ID of the koan to start at")
+	interval := flags.Duration("interval", DefaultDevInterval, "How often to look for changed files")
+	if err := flags.Parse(args); err != nil {
+		return DevOptions{}, err
+	}
+	if flags.NArg() > 0 {
+		return DevOptions{}, fmt.Errorf("unexpected argument %q; use dev --lessons <dir>", flags.Arg(0))
+	}
+	return DevOptions{Interval: *interval, KoanID: *koanID}, nil
+}
+
+// runServe parses the serve flags and serves the koan API
+func runServe(lessons fs.FS, console *ui.Console, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "Address to listen on")
+	data := flags.String("data", "", "Directory for per-user progress (default ~/.cronkoans_server)")
+	tokens := flags.String("tokens", "", "YAML file mapping user names to bearer tokens")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return Serve(lessons, console, ServeOptions{
+		Addr:       *addr,
+		DataDir:    *data,
+		TokensFile: *tokens,
+	})
+}
+
+// configureTheme applies the color and glyph settings from flags, environment and config
+func configureTheme(console *ui.Console, cfg *config.Config, colorFlag string, colorSet, noColor, ascii bool) error {
+	mode, err := ui.ResolveColorMode(colorFlag, colorSet, noColor, cfg.Color)
+	if err != nil {
+		return err
+	}
+
+	opts := ui.ThemeOptions{
+		Mode:   mode,
+		ASCII:  ascii || cfg.ASCII || os.Getenv("TERM") == "dumb",
+		Colors: cfg.Theme,
+	}
+
+	outTerminal, errTerminal := console.Terminals()
+	opts.IsTerminal = outTerminal
+	theme, err := ui.NewTheme(opts)
+	if err != nil {
+		return err
+	}
+
+	opts.IsTerminal = errTerminal
+	errTheme, err := ui.NewTheme(opts)
+	if err != nil {
+		return err
+	}
+
+	console.SetTheme(theme, errTheme)
+	return nil
+}
+
+// isFlagSet reports whether a flag was given explicitly on the command line
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// stringList is a flag that can be given more than once
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,206 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/report"
+	"github.com/dwildt/cronkoans/internal/ui"
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+	"github.com/dwildt/cronkoans/pkg/oncalendar"
+)
+
+// Normalize shows each expression in canonical minimal form, so that
+// expressions written differently can be compared
+func Normalize(console ui.UI, exprs []string, format ui.OutputFormat) error {
+	if len(exprs) == 0 {
+		return fmt.Errorf("no expression given; use normalize \"<expression>\"")
+	}
+
+	doc := report.NormalizeDocument{SchemaVersion: report.SchemaVersion, Kind: report.KindNormalize}
+	for _, expr := range exprs {
+		expr = strings.Join(strings.Fields(expr), " ")
+		normalized, err := cronexpr.Normalize(expr)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid cron expression: %w", expr, err)
+		}
+		doc.Expressions = append(doc.Expressions, report.NormalizedExpression{
+			Expression: expr,
+			Normalized: normalized,
+			Changed:    normalized != expr,
+		})
+	}
+
+	if format != ui.OutputText {
+		return console.DisplayDocument(format, doc)
+	}
+	for _, e := range doc.Expressions {
+		if e.Changed {
+			console.DisplayInfo(fmt.Sprintf("%s  ->  %s", e.Expression, e.Normalized))
+		} else {
+			console.DisplaySuccess(fmt.Sprintf("%s is already in canonical form", e.Expression))
+		}
+	}
+	return nil
+}
+
+// DefaultWindowDays is how many days diff and analyze look at when no window
+// is given
+const DefaultWindowDays = 7
+
+// DiffOptions control a schedule diff
+type DiffOptions struct {
+	Before string    // expression as it was
+	After  string    // expression as it is now
+	From   time.Time // start of the window; zero compares from now
+	Days   int       // length of the window; 0 compares DefaultWindowDays
+}
+
+// Diff compares the fire times of two expressions over a window, so that a
+// change to a crontab line can be reviewed by what it does rather than how
+// it is written
+func Diff(console ui.UI, opts DiffOptions, format ui.OutputFormat) error {
+	if opts.Days < 0 {
+		return fmt.Errorf("--days must be positive, got %d", opts.Days)
+	}
+	if opts.Days == 0 {
+		opts.Days = DefaultWindowDays
+	}
+	if opts.From.IsZero() {
+		opts.From = time.Now()
+	}
+
+	before := strings.Join(strings.Fields(opts.Before), " ")
+	after := strings.Join(strings.Fields(opts.After), " ")
+	schedules := make([]*cronexpr.Schedule, 2)
+	for i, expr := range []string{before, after} {
+		s, err := cronexpr.Parse(expr)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid cron expression: %w", expr, err)
+		}
+		schedules[i] = s
+	}
+	diff := schedules[0].Diff(schedules[1], opts.From, opts.From.AddDate(0, 0, opts.Days))
+
+	if format != ui.OutputText {
+		return console.DisplayDocument(format, report.NewDiffDocument(before, after, diff))
+	}
+	console.DisplayScheduleDiff(before, after, diff)
+	return nil
+}
+
+// ParseWindowStart parses the value of --from for diff and analyze, a date
+// such as 2026-11-01 or a time such as 2026-11-01T09:30, in loc
+func ParseWindowStart(value string, loc *time.Location) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --from %q (want a date such as 2026-11-01 or a time such as 2026-11-01T09:30)", value)
+}
+
+// CalendarOptions choose what calendar shows
+type CalendarOptions struct {
+	Expression string
+	Month      time.Time // the 1st of the month to show; zero shows this month
+	Day        time.Time // when set, the day to show minute by minute instead
+}
+
+// Calendar shows when an expression fires: the number of runs on each day of
+// a month, or for a single day, the minutes of each hour
+func Calendar(console ui.UI, opts CalendarOptions) error {
+	expr := strings.Join(strings.Fields(opts.Expression), " ")
+	s, err := cronexpr.Parse(expr)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid cron expression: %w", expr, err)
+	}
+	if s.Reboot {
+		return fmt.Errorf("'%s' runs at startup and has no calendar", expr)
+	}
+
+	if !opts.Day.IsZero() {
+		console.DisplayDayHeatmap(expr, opts.Day, s.FiresOn(opts.Day))
+		return nil
+	}
+	first := opts.Month
+	if first.IsZero() {
+		now := time.Now()
+		first = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+	console.DisplayMonthCalendar(expr, first, s.MonthRuns(first.Year(), first.Month(), first.Location()))
+	return nil
+}
+
+// ParseCalendarMonth parses the value of calendar --month, such as 2026-11, in loc
+func ParseCalendarMonth(value string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --month %q (want a month such as 2026-11)", value)
+	}
+	return t, nil
+}
+
+// ParseCalendarDay parses the value of calendar --day, such as 2026-11-03, in loc
+func ParseCalendarDay(value string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --day %q (want a date such as 2026-11-03)", value)
+	}
+	return t, nil
+}
+
+// ConvertOptions choose which way convert translates
+type ConvertOptions struct {
+	Targets []string // expressions to convert
+	From    string   // "systemd" to convert OnCalendar expressions to cron
+	To      string   // "systemd" to convert cron expressions to OnCalendar
+}
+
+// Convert translates cron expressions into the settings of a systemd timer,
+// or OnCalendar expressions into cron, warning wherever the two behave
+// differently
+func Convert(console ui.UI, opts ConvertOptions, format ui.OutputFormat) error {
+	doc := report.ConvertDocument{SchemaVersion: report.SchemaVersion, Kind: report.KindConvert}
+	convert := oncalendar.FromCron
+	noun := "an OnCalendar expression"
+	switch {
+	case opts.To == "systemd" && opts.From == "":
+		doc.From, doc.To = "cron", "systemd"
+	case opts.From == "systemd" && opts.To == "":
+		doc.From, doc.To = "systemd", "cron"
+		convert, noun = oncalendar.ToCron, "a cron expression"
+	default:
+		return fmt.Errorf("convert needs either --to systemd or --from systemd")
+	}
+	if len(opts.Targets) == 0 {
+		return fmt.Errorf("no expression given; use convert --to systemd \"<expression>\"")
+	}
+
+	for _, source := range opts.Targets {
+		source = strings.Join(strings.Fields(source), " ")
+		c, err := convert(source)
+		if err != nil {
+			return fmt.Errorf("'%s' cannot be written as %s: %w", source, noun, err)
+		}
+		doc.Conversions = append(doc.Conversions, report.Conversion{
+			Source:   source,
+			Result:   c.Lines,
+			Warnings: append([]string{}, c.Warnings...),
+		})
+	}
+
+	if format != ui.OutputText {
+		return console.DisplayDocument(format, doc)
+	}
+	for _, c := range doc.Conversions {
+		for _, line := range c.Result {
+			console.DisplayInfo(fmt.Sprintf("%s  ->  %s", c.Source, line))
+		}
+		for _, warning := range c.Warnings {
+			console.DisplayWarning(warning)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/report"
+	"github.com/dwildt/cronkoans/internal/ui"
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+	"github.com/dwildt/cronkoans/pkg/crontab"
+)
+
+// AnalyzeOptions control a crontab load analysis
+type AnalyzeOptions struct {
+	File    string    // crontab file to read
+	From    time.Time // start of the window; zero analyses from now
+	Days    int       // length of the window; 0 analyses DefaultWindowDays
+	Hotspot int       // jobs starting together that make a hotspot; 0 uses crontab.DefaultHotspot
+}
+
+// Analyze shows how the jobs of a crontab file load the host: how many start
+// together, which can still be running when they start again, and how to
+// spread them out
+func Analyze(console ui.UI, opts AnalyzeOptions, format ui.OutputFormat) error {
+	if opts.Days < 0 {
+		return fmt.Errorf("--days must be positive, got %d", opts.Days)
+	}
+	if opts.Days == 0 {
+		opts.Days = DefaultWindowDays
+	}
+	if opts.Hotspot == 0 {
+		opts.Hotspot = crontab.DefaultHotspot
+	}
+	if opts.Hotspot < 2 {
+		return fmt.Errorf("--hotspot must be at least 2, got %d", opts.Hotspot)
+	}
+	if opts.From.IsZero() {
+		opts.From = time.Now()
+	}
+
+	tab, err := crontab.ReadFile(opts.File)
+	if err != nil {
+		return err
+	}
+	load := tab.Analyze(opts.From, opts.From.AddDate(0, 0, opts.Days), opts.Hotspot)
+
+	if format != ui.OutputText {
+		return console.DisplayDocument(format, report.NewLoadDocument(opts.File, tab, load))
+	}
+	console.DisplayCrontabLoad(opts.File, tab, load, opts.Hotspot)
+	return nil
+}
+
+// LintOptions control a lint run
+type LintOptions struct {
+	Targets  []string  // crontab files, or cron expressions
+	Disabled []string  // names of rules to skip
+	From     time.Time // when to look for runs from; zero looks from now
+}
+
+// Lint checks crontab files and cron expressions for classic mistakes that
+// are valid syntax, such as "* 9 * * *" running 60 times. An argument that
+// is not a file but is a valid expression is checked as an expression. It
+// fails when any error or warning is found.
+func Lint(console ui.UI, opts LintOptions, format ui.OutputFormat) error {
+	if len(opts.Targets) == 0 {
+		return fmt.Errorf("nothing to lint; use lint <crontab-file> or lint \"<expression>\"")
+	}
+	for _, name := range opts.Disabled {
+		if crontab.FindRule(name) == nil {
+			return fmt.Errorf("unknown lint rule %q; see cronkoans lint --rules", name)
+		}
+	}
+
+	if opts.From.IsZero() {
+		opts.From = time.Now()
+	}
+
+	var results []ui.LintResult
+	for _, target := range opts.Targets {
+		result, err := lintTarget(target, opts.Disabled, opts.From)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+
+	if format != ui.OutputText {
+		if err := console.DisplayDocument(format, report.NewLintDocument(results)); err != nil {
+			return err
+		}
+	} else {
+		console.DisplayLintResults(results)
+	}
+
+	failed := 0
+	for _, r := range results {
+		for _, f := range r.Findings {
+			if f.Rule.Severity != crontab.SeverityInfo {
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("lint failed: %d errors and warnings", failed)
+	}
+	return nil
+}
+
+// lintTarget lints one crontab file, or an expression when there is no such file
+func lintTarget(target string, disabled []string, from time.Time) (ui.LintResult, error) {
+	if _, err := os.Stat(target); err != nil {
+		expr := strings.Join(strings.Fields(target), " ")
+		if cronexpr.Validate(expr) != nil {
+			return ui.LintResult{}, fmt.Errorf("%s is neither a crontab file nor a valid cron expression", target)
+		}
+		findings, err := crontab.LintExpression(expr, disabled, from)
+		return ui.LintResult{Source: expr, Expression: true, Findings: findings}, err
+	}
+
+	tab, err := crontab.ReadFile(target)
+	if err != nil {
+		return ui.LintResult{}, err
+	}
+	return ui.LintResult{Source: target, Findings: tab.Lint(disabled, from)}, nil
+}
+
+// LintRules lists the lint rules
+func LintRules(console ui.UI, format ui.OutputFormat) error {
+	if format != ui.OutputText {
+		return console.DisplayDocument(format, report.NewLintRulesDocument(crontab.Rules))
+	}
+	console.DisplayLintRules(crontab.Rules)
+	return nil
+}
@@ -0,0 +1,297 @@
+package runner
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/internal/ui"
+)
+
+// DefaultDevInterval is how often dev mode looks for changed lesson files
+const DefaultDevInterval = 500 * time.Millisecond
+
+// DevOptions control a dev session
+type DevOptions struct {
+	Interval time.Duration // how often to look for changes; 0 uses DefaultDevInterval
+	KoanID   string        // koan to start at; empty starts at the first
+}
+
+// Dev runs the lessons for their author. Lesson files are polled for changes,
+// problems are reported as soon as a file is saved, and the open koan is
+// presented again with its new content. Files with problems leave the last
+// good version in place. Progress is not saved.
+func Dev(lessonsFS fs.FS, console ui.UI, opts DevOptions) error {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultDevInterval
+	}
+	d := &devSession{lessonsFS: lessonsFS, console: console}
+	d.files = d.snapshot()
+
+	d.console.DisplayInfo(fmt.Sprintf("Dev mode: watching %v for changes. Progress is not saved.", lessonsFS))
+	d.console.DisplayInfo("Type 'hint' for a hint, 'skip' for the next koan, 'reload' to reload now, or 'quit' to stop.")
+	d.reload(nil)
+	if d.lessons != nil && opts.KoanID != "" {
+		if !d.find(opts.KoanID) {
+			return fmt.Errorf("koan %q not found", opts.KoanID)
+		}
+	}
+
+	// Answers are read in the background so that changes show up while the
+	// author is thinking. The reader only reads, one line per request; all
+	// output, the prompt included, comes from this loop so it never interleaves.
+	reads := make(chan struct{})
+	lines := make(chan devLine)
+	go func() {
+		for range reads {
+			text, ok := d.console.ReadAnswer()
+			lines <- devLine{text, ok}
+		}
+	}()
+	defer close(reads)
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	d.present()
+	d.console.DisplayAnswerPrompt()
+	reads <- struct{}{}
+	for {
+		select {
+		case <-ticker.C:
+			if changed := d.changed(); len(changed) > 0 {
+				d.reload(changed)
+				d.console.DisplayAnswerPrompt()
+			}
+		case line := <-lines:
+			if !line.ok {
+				// Like PromptForAnswer, the end of the input quits
+				d.console.DisplayInfo("\nEnd of input; leaving dev mode.")
+				return nil
+			}
+			if quit := d.answer(strings.TrimSpace(line.text)); quit {
+				return nil
+			}
+			d.console.DisplayAnswerPrompt()
+			reads <- struct{}{}
+		}
+	}
+}
+
+// devLine is a line the author typed, or the end of the input when ok is false
+type devLine struct {
+	text string
+	ok   bool
+}
+
+// devSession is the state of a dev session that survives reloads
+type devSession struct {
+	lessonsFS fs.FS
+	console   ui.UI
+	files     map[string]string // contents of the lesson files at the last reload
+
+	lessons   []*koan.Lesson // nil while the lessons have never loaded
+	lesson    int            // position of the open koan
+	index     int
+	koanID    string
+	hintLevel int
+}
+
+// current returns the open koan, or nil when there are no lessons
+func (d *devSession) current() *koan.Koan {
+	if d.lessons == nil {
+		return nil
+	}
+	return &d.lessons[d.lesson].Koans[d.index]
+}
+
+// present shows the open koan
+func (d *devSession) present() {
+	k := d.current()
+	if k == nil {
+		d.console.DisplayWarning("No lessons to show yet. Save a fix to try again.")
+		return
+	}
+	number, total := 0, 0
+	for i, lesson := range d.lessons {
+		if i < d.lesson {
+			number += len(lesson.Koans)
+		}
+		total += len(lesson.Koans)
+	}
+	d.console.DisplayKoan(k, number+d.index+1, total)
+}
+
+// answer handles one line typed by the author and reports whether to stop
+func (d *devSession) answer(answer string) bool {
+	switch strings.ToLower(answer) {
+	case "quit", "exit":
+		return true
+	case "reload":
+		if changed := d.changed(); len(changed) > 0 {
+			d.reload(changed)
+		} else {
+			d.console.DisplayInfo("No lesson files have changed.")
+		}
+		return false
+	case "":
+		return false
+	}
+
+	k := d.current()
+	if k == nil {
+		d.console.DisplayWarning("No lessons to show yet. Save a fix to try again.")
+		return false
+	}
+
+	switch strings.ToLower(answer) {
+	case "skip":
+		d.next()
+	case "hint", "h":
+		if hint := k.GetHint(d.hintLevel); hint != "" {
+			d.console.DisplayHint(hint, d.hintLevel)
+			d.hintLevel++
+		} else {
+			d.console.DisplayInfo("No more hints available for this koan.")
+		}
+	default:
+		if k.CheckAnswer(answer) {
+			d.console.DisplayCorrect(k)
+			d.next()
+		} else {
+			d.console.DisplayIncorrect(k.AnalyzeAnswer(answer))
+		}
+	}
+	return false
+}
+
+// next opens the koan after the current one, starting over after the last
+func (d *devSession) next() {
+	d.index++
+	if d.index >= len(d.lessons[d.lesson].Koans) {
+		d.index = 0
+		d.lesson++
+		if d.lesson >= len(d.lessons) {
+			d.lesson = 0
+			d.console.DisplayInfo("That was the last koan; starting over.")
+		}
+	}
+	d.open()
+	d.present()
+}
+
+// open remembers the koan at the current position, without hints shown
+func (d *devSession) open() {
+	d.koanID = d.current().ID
+	d.hintLevel = 0
+}
+
+// find opens the koan with the given ID, reporting whether it exists
+func (d *devSession) find(id string) bool {
+	for i, lesson := range d.lessons {
+		for j := range lesson.Koans {
+			if lesson.Koans[j].ID == id {
+				d.lesson, d.index = i, j
+				d.koanID = id
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reload checks the lesson files and switches to them when they load. The
+// open koan is kept by ID, or else by position when its ID was changed.
+func (d *devSession) reload(changed []string) {
+	if len(changed) > 0 {
+		d.console.DisplayInfo("\nChanged: " + strings.Join(changed, ", "))
+	}
+
+	checks, err := koan.CheckLessonFilesFS(d.lessonsFS)
+	if err != nil {
+		d.console.DisplayError(err)
+		return
+	}
+	for _, fc := range checks {
+		for _, p := range fc.Problems {
+			d.console.DisplayWarning(p.String())
+		}
+		for _, kc := range fc.Koans {
+			for _, p := range kc.Problems {
+				d.console.DisplayWarning(p.String())
+			}
+		}
+	}
+
+	lessons, err := koan.LoadAllLessonsFS(d.lessonsFS)
+	if err == nil && len(lessons) == 0 {
+		err = fmt.Errorf("no lessons found in %v", d.lessonsFS)
+	}
+	if err != nil {
+		if d.lessons != nil {
+			d.console.DisplayWarning("Keeping the last version that loaded until this is fixed: " + err.Error())
+		} else {
+			d.console.DisplayError(err)
+		}
+		return
+	}
+
+	first := d.lessons == nil
+	old := d.current()
+	d.lessons = lessons
+	if first {
+		d.lesson, d.index = 0, 0
+		d.open()
+		if len(changed) > 0 {
+			d.present()
+		}
+		return
+	}
+
+	if !d.find(d.koanID) {
+		d.lesson = min(d.lesson, len(d.lessons)-1)
+		d.index = min(d.index, len(d.lessons[d.lesson].Koans)-1)
+		d.open()
+		d.console.DisplayInfo(fmt.Sprintf("Koan %s is gone; showing %s.", old.ID, d.koanID))
+	} else if len(changed) > 0 {
+		d.console.DisplaySuccess("Reloaded " + d.koanID + ".")
+	}
+	if len(changed) > 0 {
+		d.present()
+	}
+}
+
+// snapshot reads every YAML file at the root of the lessons
+func (d *devSession) snapshot() map[string]string {
+	files := make(map[string]string)
+	names, _ := fs.Glob(d.lessonsFS, "*.yaml")
+	for _, name := range names {
+		if data, err := fs.ReadFile(d.lessonsFS, name); err == nil {
+			files[name] = string(data)
+		}
+	}
+	return files
+}
+
+// changed lists the lesson files that were added, edited or removed since the
+// last call, sorted by name
+func (d *devSession) changed() []string {
+	files := d.snapshot()
+	var changed []string
+	for name, data := range files {
+		if old, ok := d.files[name]; !ok || old != data {
+			changed = append(changed, name)
+		}
+	}
+	for name := range d.files {
+		if _, ok := files[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	d.files = files
+	sort.Strings(changed)
+	return changed
+}
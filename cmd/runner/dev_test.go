@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/internal/ui"
+)
+
+// syncBuffer is a bytes.Buffer that dev mode can write to while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return ansiPattern.ReplaceAllString(b.buf.String(), "")
+}
+
+const devLesson = `title: "Dev"
+description: "A lesson edited during a dev session"
+koans:
+  - id: "dev_1"
+    description: "Every minute"
+    question: %q
+    incomplete: "__ * * * *"
+    answer: %q
+    hints:
+      - "First hint"
+      - "Second hint"
+      - "Answer"
+    explanation: "Explained."
+`
+
+// TestDevReload edits a lesson while its koan is open and checks that dev
+// mode reports the broken version, then shows and grades the fixed one
+func TestDevReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "01_dev.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(fmt.Sprintf(devLesson, "Every minute of every day", "*"))
+
+	in, typed := io.Pipe()
+	var out syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- Dev(koan.LessonDirs(dir), ui.NewConsole(in, &out, &out), DevOptions{Interval: 5 * time.Millisecond})
+	}()
+
+	// waitFor waits until the output has want after the previous expectation
+	seen := 0
+	waitFor := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if idx := strings.Index(out.String()[seen:], want); idx >= 0 {
+				seen += idx + len(want)
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("expected output %q not found\n--- output ---\n%s", want, out.String())
+	}
+	typeLine := func(line string) {
+		t.Helper()
+		if _, err := io.WriteString(typed, line+"\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitFor("Every minute of every day")
+	waitFor("Your answer:")
+	typeLine("hint")
+	waitFor("Hint 1: First hint")
+
+	write("title: [broken\n")
+	waitFor("Changed: 01_dev.yaml")
+	waitFor("01_dev.yaml:")
+	waitFor("Keeping the last version that loaded")
+
+	write(fmt.Sprintf(devLesson, "At the top of every hour", "0"))
+	waitFor("Reloaded dev_1.")
+	waitFor("At the top of every hour")
+	waitFor("Your answer:")
+
+	// The session continues where it was: the next hint is the second one
+	typeLine("hint")
+	waitFor("Hint 2: Second hint")
+	typeLine("0")
+	waitFor("Correct!")
+	waitFor("0 * * * *")
+
+	typeLine("quit")
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("dev mode did not stop")
+	}
+}
@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/dwildt/cronkoans/internal/author"
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/internal/ui"
+)
+
+// GenerateOptions control what cronkoans generate produces
+type GenerateOptions struct {
+	Expressions []string       // full expressions to turn into koans; random ones are generated when empty
+	Concept     author.Concept // concept for random koans, and the field to blank in given expressions
+	Count       int            // number of random koans
+	Seed        int64          // random seed, so a lesson can be generated again
+	Title       string
+	Out         string // lesson file to write; defaults to the next numbered file in the lessons directory
+}
+
+// Generate writes a lesson of generated koans to lessonsDir for an author to review.
+// Numbers and koan IDs are chosen so they do not clash with anything in lessons.
+func Generate(lessons fs.FS, lessonsDir string, console ui.UI, opts GenerateOptions) error {
+	if opts.Title == "" {
+		opts.Title = "Generated - Review Before Publishing"
+		if opts.Concept != "" {
+			name := string(opts.Concept)
+			opts.Title = "Generated " + strings.ToUpper(name[:1]) + name[1:] + " - Review Before Publishing"
+		}
+	}
+
+	out := opts.Out
+	if out == "" {
+		if lessonsDir == "" {
+			return errors.New("no lessons directory to write to; give one with --lessons, or a file with --out")
+		}
+		next, err := author.NextLessonFile(lessons, lessonsDir, opts.Title)
+		if err != nil {
+			return err
+		}
+		out = next
+	}
+	if _, err := os.Stat(out); err == nil {
+		return fmt.Errorf("%s already exists; choose another file with --out", out)
+	}
+
+	prefix := author.Slug(opts.Title)
+	used := author.ExistingIDs(lessons)
+	lesson := &koan.Lesson{
+		Title:       opts.Title,
+		Description: "Koans generated by cronkoans generate",
+	}
+
+	add := func(expression string, field int) error {
+		id := author.SuggestID(prefix, used)
+		k, err := author.KoanFromExpression(expression, field, id)
+		if err != nil {
+			return err
+		}
+		used[id] = true
+		lesson.Koans = append(lesson.Koans, *k)
+		return nil
+	}
+
+	if len(opts.Expressions) > 0 {
+		for _, expression := range opts.Expressions {
+			field := -1
+			if !strings.HasPrefix(strings.TrimSpace(expression), "@") {
+				field = author.BlankField(expression, opts.Concept)
+			}
+			if err := add(expression, field); err != nil {
+				return err
+			}
+			// The author chose the expression, so keep it but say why it may confuse
+			if warning, _ := reachWarning(strings.Join(strings.Fields(expression), " ")); warning != "" {
+				console.DisplayWarning(warning)
+			}
+		}
+	} else {
+		if opts.Count <= 0 {
+			return fmt.Errorf("count must be positive, got %d", opts.Count)
+		}
+		rng := rand.New(rand.NewSource(opts.Seed))
+		seen := make(map[string]bool)
+		for attempts := 0; len(lesson.Koans) < opts.Count && attempts < opts.Count*20; attempts++ {
+			concept := opts.Concept
+			if concept == "" {
+				concept = author.Concepts[rng.Intn(len(author.Concepts))]
+			}
+			expression, field := author.RandomExpression(concept, rng)
+			if _, never := reachWarning(expression); seen[expression] || never {
+				continue
+			}
+			seen[expression] = true
+			if err := add(expression, field); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := author.WriteNode(out, author.NewLessonNode(lesson)); err != nil {
+		return err
+	}
+	console.DisplaySuccess(fmt.Sprintf("Wrote %d koans to %s", len(lesson.Koans), out))
+	console.DisplayInfo("Generated questions and hints are drafts: review them, then run cronkoans validate")
+	return nil
+}
+
+// ReadExpressions reads one expression per line from a file, skipping blank lines and # comments
+func ReadExpressions(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read expressions: %w", err)
+	}
+	defer f.Close()
+
+	var expressions []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		expressions = append(expressions, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read expressions: %w", err)
+	}
+	return expressions, nil
+}
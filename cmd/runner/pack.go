@@ -0,0 +1,231 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/internal/pack"
+	"github.com/dwildt/cronkoans/internal/progress"
+	"github.com/dwildt/cronkoans/internal/ui"
+	"github.com/dwildt/cronkoans/lessons"
+	"github.com/dwildt/cronkoans/lessons/systemd"
+)
+
+// Version is the cronkoans version, which packs can require a minimum of
+const Version = "1.0.0"
+
+// BuiltinPack is the name of the bundled lesson pack
+const BuiltinPack = "cronkoans"
+
+// SystemdPack is the name of the bundled pack on systemd timers
+const SystemdPack = "systemd"
+
+// bundledPacks are the packs built into cronkoans, which cannot be installed over or removed
+var bundledPacks = []string{BuiltinPack, SystemdPack}
+
+// builtinLessonsPath is shown in messages for files from the bundled lessons
+const builtinLessonsPath = "(built-in)"
+
+// LoadLessonsFS stacks the lesson directories in dirs on top of a lesson pack,
+// each one on top of the last, so a file replaces any lesson with the same
+// name below it. packName is BuiltinPack for the bundled lessons, SystemdPack
+// for the bundled systemd lessons, the name of an installed pack, or empty
+// for the directories alone.
+func LoadLessonsFS(packName string, dirs []string) (*koan.Overlay, error) {
+	var layers []koan.Layer
+	switch packName {
+	case "":
+	case BuiltinPack:
+		layers = append(layers, koan.Layer{Path: builtinLessonsPath, FS: lessons.FS})
+	case SystemdPack:
+		layers = append(layers, koan.Layer{Path: builtinLessonsPath, FS: systemd.FS})
+	default:
+		store, err := pack.NewStore()
+		if err != nil {
+			return nil, err
+		}
+		layer, err := store.Layer(packName)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("lessons directory %s: %w", dir, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("lessons directory %s is not a directory", dir)
+		}
+		layers = append(layers, koan.DirLayer(dir))
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("no lessons to load: --no-builtin needs at least one --lessons directory")
+	}
+	return koan.NewOverlay(layers...), nil
+}
+
+// AuthoringDir returns the directory that new lessons are written to: the last
+// lessons directory given, or "" when there is none. The bundled lessons are
+// not a directory, so there is no default.
+func AuthoringDir(dirs []string) string {
+	if len(dirs) > 0 {
+		return dirs[len(dirs)-1]
+	}
+	return ""
+}
+
+// packTracker opens the progress of a pack. The bundled lessons, and lessons
+// without a pack name, keep the progress file that predates packs.
+func packTracker(name string) (*progress.Tracker, error) {
+	if name == BuiltinPack {
+		name = ""
+	}
+	tracker, err := progress.NewPackTracker(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create progress tracker: %w", err)
+	}
+	return tracker, nil
+}
+
+// PackInstall installs a pack from a directory, .zip or .tar.gz, replacing any
+// installed version of it
+func PackInstall(console ui.UI, src string) error {
+	store, err := pack.NewStore()
+	if err != nil {
+		return err
+	}
+	installed, _, _ := store.List()
+
+	p, err := store.Install(src, Version, bundledPacks...)
+	if err != nil {
+		return err
+	}
+
+	for _, old := range installed {
+		if old.Name == p.Name {
+			console.DisplaySuccess(fmt.Sprintf("Replaced %s %s with %s", p.Name, old.Version, p.Version))
+			return nil
+		}
+	}
+	console.DisplaySuccess(fmt.Sprintf("Installed %s %s. Start it with cronkoans --pack %s", p.Name, p.Version, p.Name))
+	return nil
+}
+
+// PackList shows the bundled packs and every installed pack with progress
+func PackList(console ui.UI) error {
+	store, err := pack.NewStore()
+	if err != nil {
+		return err
+	}
+	installed, broken, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	// A pack that does not load is listed as broken rather than failing the list
+	var summaries []ui.PackSummary
+	for _, name := range append(append([]string{}, bundledPacks...), packNames(installed)...) {
+		summary, _, _, err := packSummary(name)
+		if err != nil {
+			broken = append(broken, pack.Broken{Name: name, Err: err})
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+	for _, b := range broken {
+		summaries = append(summaries, ui.PackSummary{Pack: &koan.Pack{Name: b.Name}, Err: b.Err})
+	}
+	console.DisplayPackList(summaries)
+	return nil
+}
+
+// PackInfo shows a pack's manifest and its lessons with progress
+func PackInfo(console ui.UI, name string) error {
+	summary, packLessons, tracker, err := packSummary(name)
+	if err != nil {
+		return err
+	}
+	console.DisplayPackInfo(summary)
+	console.DisplayLessonList(packLessons, tracker)
+	return nil
+}
+
+// PackRemove uninstalls a pack. Its progress is kept, so reinstalling it
+// continues where the learner left off.
+func PackRemove(console ui.UI, name string) error {
+	if isBundledPack(name) {
+		return fmt.Errorf("the bundled pack %s cannot be removed", name)
+	}
+	store, err := pack.NewStore()
+	if err != nil {
+		return err
+	}
+	p, err := store.Get(name)
+	if err != nil {
+		return err
+	}
+	if err := store.Remove(name); err != nil {
+		return err
+	}
+	console.DisplaySuccess(fmt.Sprintf("Removed %s %s. Your progress in it is kept.", p.Name, p.Version))
+	return nil
+}
+
+// packSummary loads a pack and counts its lessons, koans and completed koans.
+// It also returns the lessons and the pack's progress.
+func packSummary(name string) (ui.PackSummary, []*koan.Lesson, *progress.Tracker, error) {
+	var summary ui.PackSummary
+	lessonsFS, err := LoadLessonsFS(name, nil)
+	if err != nil {
+		return summary, nil, nil, err
+	}
+	p, err := koan.LoadPackFS(lessonsFS)
+	if err != nil {
+		return summary, nil, nil, err
+	}
+	packLessons, err := koan.LoadAllLessonsFS(lessonsFS)
+	if err != nil {
+		return summary, nil, nil, err
+	}
+	tracker, err := packTracker(name)
+	if err != nil {
+		return summary, nil, nil, err
+	}
+
+	summary = ui.PackSummary{
+		Pack:     p,
+		Location: lessonsFS.String(),
+		Builtin:  isBundledPack(name),
+		Lessons:  len(packLessons),
+	}
+	for _, k := range koan.GetAllKoans(packLessons) {
+		summary.Koans++
+		if tracker.IsCompleted(k.ID) {
+			summary.Completed++
+		}
+	}
+	return summary, packLessons, tracker, nil
+}
+
+// isBundledPack reports whether name is one of the packs built into cronkoans
+func isBundledPack(name string) bool {
+	for _, bundled := range bundledPacks {
+		if name == bundled {
+			return true
+		}
+	}
+	return false
+}
+
+// packNames lists the names of packs
+func packNames(packs []*koan.Pack) []string {
+	names := make([]string, len(packs))
+	for i, p := range packs {
+		names[i] = p.Name
+	}
+	return names
+}
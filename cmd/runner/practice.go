@@ -0,0 +1,156 @@
+package runner
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/author"
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/internal/progress"
+	"github.com/dwildt/cronkoans/internal/ui"
+)
+
+// ConceptMixed practises every concept in turn
+const ConceptMixed = "mixed"
+
+// maxPracticeAttempts is how many wrong answers a practice koan allows before showing the answer
+const maxPracticeAttempts = 3
+
+// PracticeOptions control a practice session
+type PracticeOptions struct {
+	Concept    string // ranges, steps, lists, names or mixed
+	Difficulty int    // starting level from 1 to 5; 0 continues at the saved level
+	Seed       int64  // random seed; 0 uses the current time
+	Count      int    // number of koans; 0 practises until the learner quits
+}
+
+// Practice runs an endless session of generated koans. Answers are graded by
+// schedule, so any expression that fires at the same times is correct. The
+// difficulty follows the learner's recent accuracy, and results are saved
+// apart from lesson progress.
+func Practice(console ui.UI, opts PracticeOptions) error {
+	if opts.Concept == "" {
+		opts.Concept = ConceptMixed
+	}
+	if opts.Concept != ConceptMixed {
+		if _, err := author.ParseConcept(opts.Concept); err != nil {
+			return fmt.Errorf("unknown concept %q (want ranges, steps, lists, names or mixed)", opts.Concept)
+		}
+	}
+	if opts.Difficulty != 0 && (opts.Difficulty < progress.MinDifficulty || opts.Difficulty > progress.MaxDifficulty) {
+		return fmt.Errorf("difficulty must be between %d and %d, got %d",
+			progress.MinDifficulty, progress.MaxDifficulty, opts.Difficulty)
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+
+	tracker, err := progress.NewPracticeTracker()
+	if err != nil {
+		return fmt.Errorf("failed to initialize practice stats: %w", err)
+	}
+	if opts.Difficulty != 0 {
+		tracker.SetDifficulty(opts.Concept, opts.Difficulty)
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	console.DisplayInfo(fmt.Sprintf("Practice: %s, difficulty %d. Any answer that fires at the same times is correct.",
+		opts.Concept, tracker.Concept(opts.Concept).Difficulty))
+	console.DisplayInfo("Type 'hint' for a hint, 'skip' to see the answer, or 'quit' to stop.")
+
+	solved, presented := 0, 0
+	for n := 1; opts.Count == 0 || n <= opts.Count; n++ {
+		concept := author.Concept(opts.Concept)
+		if opts.Concept == ConceptMixed {
+			concept = author.Concepts[rng.Intn(len(author.Concepts))]
+		}
+
+		difficulty := tracker.Concept(opts.Concept).Difficulty
+		expression, field := author.RandomExpressionAt(concept, difficulty, rng)
+		k, err := author.KoanFromExpression(expression, field, fmt.Sprintf("practice_%d", n))
+		if err != nil {
+			return err
+		}
+
+		result, quit := practiceKoan(console, k, n)
+		if quit {
+			break
+		}
+		presented++
+		if result.solved {
+			solved++
+		}
+
+		next, err := tracker.Record(opts.Concept, result.firstTry, result.solved)
+		if err != nil {
+			return fmt.Errorf("failed to save practice stats: %w", err)
+		}
+		// Mixed practice also counts towards the concept that was drawn
+		if opts.Concept == ConceptMixed {
+			if _, err := tracker.Record(string(concept), result.firstTry, result.solved); err != nil {
+				return fmt.Errorf("failed to save practice stats: %w", err)
+			}
+		}
+		switch {
+		case next > difficulty:
+			console.DisplaySuccess(fmt.Sprintf("Level up! Difficulty is now %d.", next))
+		case next < difficulty:
+			console.DisplayInfo(fmt.Sprintf("Let's slow down. Difficulty is now %d.", next))
+		}
+	}
+
+	cs := tracker.Concept(opts.Concept)
+	console.DisplayInfo(fmt.Sprintf("This session: %d of %d solved.", solved, presented))
+	console.DisplayInfo(fmt.Sprintf("All %s practice: %d koans, %d solved, %d at the first try. Next difficulty: %d.",
+		opts.Concept, cs.Attempted, cs.Solved, cs.Correct, cs.Difficulty))
+	return nil
+}
+
+// practiceResult is how the learner did on one practice koan
+type practiceResult struct {
+	firstTry bool // solved with the first answer and no hints
+	solved   bool
+}
+
+// practiceKoan presents one generated koan, reporting the result and whether the learner quit
+func practiceKoan(console ui.UI, k *koan.Koan, number int) (practiceResult, bool) {
+	console.DisplayKoan(k, number, 0)
+
+	attempts, hintLevel := 0, 0
+	for attempts < maxPracticeAttempts {
+		answer := strings.TrimSpace(console.PromptForAnswerWithPreview(k))
+
+		switch strings.ToLower(answer) {
+		case "quit", "exit":
+			return practiceResult{}, true
+		case "skip":
+			console.DisplayInfo("The answer was " + k.Answer + ": " + k.CompleteCronExpression())
+			return practiceResult{}, false
+		case "hint", "h":
+			if hint := k.GetHint(hintLevel); hint != "" {
+				console.DisplayHint(hint, hintLevel)
+				hintLevel++
+			} else {
+				console.DisplayInfo("No more hints available for this koan.")
+			}
+			continue
+		case "":
+			continue
+		}
+
+		attempts++
+		if k.SameSchedule(answer) {
+			console.DisplayCorrect(k)
+			if !k.CheckAnswer(answer) {
+				console.DisplayInfo(fmt.Sprintf("Your answer %s fires at the same times, so it counts.", answer))
+			}
+			return practiceResult{firstTry: attempts == 1 && hintLevel == 0, solved: true}, false
+		}
+		console.DisplayIncorrect(k.AnalyzeAnswer(answer))
+	}
+
+	console.DisplayInfo("The answer was " + k.Answer + ": " + k.CompleteCronExpression())
+	return practiceResult{}, false
+}
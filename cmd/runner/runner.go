@@ -2,12 +2,14 @@ package runner
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
 	"strings"
+	"time"
 
 	"github.com/dwildt/cronkoans/internal/koan"
 	"github.com/dwildt/cronkoans/internal/progress"
+	"github.com/dwildt/cronkoans/internal/report"
+	"github.com/dwildt/cronkoans/internal/tui"
 	"github.com/dwildt/cronkoans/internal/ui"
 )
 
@@ -15,20 +17,28 @@ import (
 type Runner struct {
 	lessons      []*koan.Lesson
 	tracker      *progress.Tracker
-	lessonsDir   string
+	console      ui.UI
+	outputFormat ui.OutputFormat
+	lessonsFS    fs.FS
 	currentIndex int
 }
 
-// NewRunner creates a new runner
-func NewRunner(lessonsDir string) (*Runner, error) {
+// NewRunner creates a new runner that talks to the learner through console.
+// The lessons are the lesson files at the root of lessonsFS, usually a
+// koan.Overlay of the bundled lessons and the user's lesson directories.
+func NewRunner(lessonsFS fs.FS, console ui.UI) (*Runner, error) {
 	// Load all lessons
-	lessons, err := koan.LoadAllLessons(lessonsDir)
+	lessons, err := koan.LoadAllLessonsFS(lessonsFS)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load lessons: %w", err)
 	}
 
-	// Create progress tracker
-	tracker, err := progress.NewTracker()
+	// Each pack keeps its own progress
+	pack, err := koan.LoadPackFS(lessonsFS)
+	if err != nil {
+		return nil, err
+	}
+	tracker, err := packTracker(pack.Name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create progress tracker: %w", err)
 	}
@@ -36,14 +46,43 @@ func NewRunner(lessonsDir string) (*Runner, error) {
 	return &Runner{
 		lessons:      lessons,
 		tracker:      tracker,
-		lessonsDir:   lessonsDir,
+		console:      console,
+		outputFormat: ui.OutputText,
+		lessonsFS:    lessonsFS,
 		currentIndex: 0,
 	}, nil
 }
 
-// RunInteractive starts the interactive learning mode
+// SetOutputFormat selects text or structured output for status, list and validate
+func (r *Runner) SetOutputFormat(format ui.OutputFormat) {
+	r.outputFormat = format
+}
+
+// FilterTag narrows the session to the koans tagged tag, directly or through their lesson
+func (r *Runner) FilterTag(tag string) error {
+	filtered := koan.FilterByTag(r.lessons, tag)
+	if len(filtered) == 0 {
+		return fmt.Errorf("no koans are tagged %q", tag)
+	}
+	r.lessons = filtered
+	return nil
+}
+
+// FilterKoan narrows the session to the koan with the given ID, such as one
+// a lint finding links to
+func (r *Runner) FilterKoan(id string) error {
+	filtered := koan.FilterByID(r.lessons, id)
+	if len(filtered) == 0 {
+		return fmt.Errorf("no koan has the ID %q", id)
+	}
+	r.lessons = filtered
+	return nil
+}
+
+// RunInteractive starts the interactive learning mode. Lessons come in order,
+// except that a lesson waits until the lessons it builds on are finished.
 func (r *Runner) RunInteractive() error {
-	ui.DisplayWelcome()
+	r.console.DisplayWelcome()
 
 	allKoans := koan.GetAllKoans(r.lessons)
 	if len(allKoans) == 0 {
@@ -67,10 +106,11 @@ func (r *Runner) RunInteractive() error {
 		}
 	}
 
-	// Start from the first incomplete koan
-	for i := startIndex; i < len(allKoans); i++ {
-		if !r.tracker.IsCompleted(allKoans[i].ID) {
-			startIndex = i
+	// Start from the first incomplete koan, going back to the beginning when
+	// only earlier koans are left, such as after a session with --tag
+	for i := startIndex; i < startIndex+len(allKoans); i++ {
+		if !r.tracker.IsCompleted(allKoans[i%len(allKoans)].ID) {
+			startIndex = i % len(allKoans)
 			break
 		}
 	}
@@ -87,59 +127,127 @@ func (r *Runner) RunInteractive() error {
 
 		if allCompleted {
 			stats := r.tracker.GetStats(len(allKoans))
-			ui.DisplayCompletion(stats)
+			r.console.DisplayCompletion(stats)
 			return nil
 		}
 	}
 
-	// Run through koans
-	for i := startIndex; i < len(allKoans); i++ {
-		k := &allKoans[i]
+	// Koans keep their numbers in the whole curriculum when lessons are reordered
+	numbers := make(map[string]int)
+	for i, k := range allKoans {
+		numbers[k.ID] = i + 1
+	}
 
-		// Skip if already completed (in case user is reviewing)
-		if r.tracker.IsCompleted(k.ID) {
-			continue
+	// Run through the lessons from the one with the starting koan. Earlier
+	// lessons that were locked have not been offered yet, so they wait.
+	var queue, waiting []*koan.Lesson
+	for _, lesson := range r.lessons {
+		switch {
+		case len(queue) > 0 || containsKoan(lesson, allKoans[startIndex].ID):
+			queue = append(queue, lesson)
+		case !koan.Unlocked(r.lessons, lesson, r.tracker.IsCompleted):
+			waiting = append(waiting, lesson)
 		}
+	}
+	startLesson := queue[0]
+	for lesson := r.nextLesson(&queue, &waiting); lesson != nil; lesson = r.nextLesson(&queue, &waiting) {
+		for i := range lesson.Koans {
+			k := &lesson.Koans[i]
+
+			// Skip koans already completed, or passed over before this session
+			if r.tracker.IsCompleted(k.ID) || (lesson == startLesson && numbers[k.ID] <= startIndex) {
+				continue
+			}
 
-		if err := r.runKoan(k, i+1, len(allKoans)); err != nil {
-			if err.Error() == "quit" {
-				return nil
+			if err := r.runKoan(k, numbers[k.ID], len(allKoans)); err != nil {
+				if err.Error() == "quit" {
+					return nil
+				}
+				return err
 			}
-			return err
 		}
 	}
 
 	// Show completion message
 	stats := r.tracker.GetStats(len(allKoans))
-	ui.DisplayCompletion(stats)
+	r.console.DisplayCompletion(stats)
 
 	return nil
 }
 
+// nextLesson takes the next lesson to learn from the queue: a waiting lesson
+// as soon as it is unlocked, otherwise the next one in order. A locked lesson
+// waits until the lessons it builds on are finished, and comes at the end
+// anyway if they never are, since a learner may skip koans.
+func (r *Runner) nextLesson(queue, waiting *[]*koan.Lesson) *koan.Lesson {
+	for i, lesson := range *waiting {
+		if koan.Unlocked(r.lessons, lesson, r.tracker.IsCompleted) {
+			*waiting = append((*waiting)[:i], (*waiting)[i+1:]...)
+			r.console.DisplayInfo(fmt.Sprintf("You have unlocked %s.", lesson.Title))
+			return lesson
+		}
+	}
+
+	for len(*queue) > 0 {
+		lesson := (*queue)[0]
+		*queue = (*queue)[1:]
+		missing := koan.MissingPrerequisites(r.lessons, lesson, r.tracker.IsCompleted)
+		if len(missing) == 0 || koan.LessonComplete(lesson, r.tracker.IsCompleted) {
+			return lesson
+		}
+		r.console.DisplayInfo(fmt.Sprintf("%s builds on %s, so it comes later.", lesson.Title, koan.Titles(missing)))
+		*waiting = append(*waiting, lesson)
+	}
+
+	if len(*waiting) > 0 {
+		lesson := (*waiting)[0]
+		*waiting = (*waiting)[1:]
+		r.console.DisplayInfo(fmt.Sprintf("Next is %s, although you have not finished the lessons it builds on.", lesson.Title))
+		return lesson
+	}
+	return nil
+}
+
+// containsKoan reports whether a lesson has the koan with the given ID
+func containsKoan(lesson *koan.Lesson, id string) bool {
+	for _, k := range lesson.Koans {
+		if k.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// RunTUI starts the full-screen terminal interface
+func (r *Runner) RunTUI() error {
+	in, out := r.console.Streams()
+	return tui.New(in, out, r.lessons, r.tracker, r.console.Theme()).Run()
+}
+
 // runKoan runs a single koan
 func (r *Runner) runKoan(k *koan.Koan, number, total int) error {
-	ui.DisplayKoan(k, number, total)
+	r.console.DisplayKoan(k, number, total)
 
 	attempts := 0
 	hintsUsed := 0
 	currentHintLevel := 0
 
 	for {
-		answer := ui.PromptForAnswer()
-		answer = strings.TrimSpace(strings.ToLower(answer))
+		answer := r.console.PromptForAnswerWithPreview(k)
+		answer = strings.TrimSpace(answer)
 
 		// Handle special commands
-		switch answer {
+		switch strings.ToLower(answer) {
 		case "quit", "exit":
 			return fmt.Errorf("quit")
 		case "skip":
-			ui.DisplayWarning("Skipping this koan...")
+			r.console.DisplayWarning("Skipping this koan...")
 			return nil
 		case "hint", "h":
 			if k.HasMoreHints(currentHintLevel - 1) {
 				hint := k.GetHint(currentHintLevel)
 				if hint != "" {
-					ui.DisplayHint(hint, currentHintLevel)
+					r.console.DisplayHint(hint, currentHintLevel)
 					currentHintLevel++
 					hintsUsed++
 					r.tracker.RecordHint(k.ID)
@@ -148,13 +256,13 @@ func (r *Runner) runKoan(k *koan.Koan, number, total int) error {
 				if currentHintLevel == 0 {
 					hint := k.GetHint(0)
 					if hint != "" {
-						ui.DisplayHint(hint, 0)
+						r.console.DisplayHint(hint, 0)
 						currentHintLevel++
 						hintsUsed++
 						r.tracker.RecordHint(k.ID)
 					}
 				} else {
-					ui.DisplayInfo("No more hints available for this koan.")
+					r.console.DisplayInfo("No more hints available for this koan.")
 				}
 			}
 			continue
@@ -165,7 +273,7 @@ func (r *Runner) runKoan(k *koan.Koan, number, total int) error {
 
 		// Check the answer
 		if k.CheckAnswer(answer) {
-			ui.DisplayCorrect(k)
+			r.console.DisplayCorrect(k)
 
 			// Mark as completed
 			if err := r.tracker.MarkCompleted(k.ID, attempts, hintsUsed); err != nil {
@@ -173,18 +281,18 @@ func (r *Runner) runKoan(k *koan.Koan, number, total int) error {
 			}
 
 			// Small pause before continuing
-			ui.PressEnterToContinue()
+			r.console.PressEnterToContinue()
 			return nil
 		}
 
-		ui.DisplayIncorrect()
+		r.console.DisplayIncorrect(k.AnalyzeAnswer(answer))
 
 		// Offer hint after 2 failed attempts
 		if attempts >= 2 && currentHintLevel < len(k.Hints) {
-			if ui.PromptYesNo("Would you like a hint?") {
+			if r.console.PromptYesNo("Would you like a hint?") {
 				hint := k.GetHint(currentHintLevel)
 				if hint != "" {
-					ui.DisplayHint(hint, currentHintLevel)
+					r.console.DisplayHint(hint, currentHintLevel)
 					currentHintLevel++
 					hintsUsed++
 					r.tracker.RecordHint(k.ID)
@@ -194,41 +302,93 @@ func (r *Runner) runKoan(k *koan.Koan, number, total int) error {
 	}
 }
 
-// RunValidation validates all koans
+// RunValidation validates all of the runner's lesson files
 func (r *Runner) RunValidation() error {
-	allKoans := koan.GetAllKoans(r.lessons)
-	var results []ui.ValidationResult
+	return Validate(r.lessonsFS, r.console, ValidateOptions{}, r.outputFormat)
+}
 
-	for _, k := range allKoans {
-		result := ui.ValidationResult{
-			KoanID: k.ID,
-			Passed: true,
-		}
+// ValidateOptions are the optional checks of cronkoans validate
+type ValidateOptions struct {
+	// Reach reports answers whose expression never fires, such as February 30th
+	Reach bool
+	// MinRuns also reports answers whose expression fires fewer times a year,
+	// such as February 29th for a MinRuns of 1. It implies Reach.
+	MinRuns int
+}
 
-		// Validate that the answer creates a valid cron expression
-		complete := k.CompleteCronExpression()
-		if err := koan.ValidateCronExpression(complete); err != nil {
-			result.Passed = false
-			result.Error = err.Error()
+// Validate checks every lesson file in lessonsFS independently and reports all
+// problems. It returns an error if any lesson has problems, so that CI fails.
+// It does not need the lessons to load, so it works when NewRunner would fail.
+func Validate(lessonsFS fs.FS, console ui.UI, opts ValidateOptions, format ui.OutputFormat) error {
+	checks, err := koan.CheckLessonFilesFS(lessonsFS)
+	if err != nil {
+		return err
+	}
+	if opts.Reach || opts.MinRuns > 0 {
+		koan.CheckReachability(checks, opts.MinRuns, time.Now())
+	}
+
+	var results []ui.ValidationResult
+	failed := 0
+	for _, fc := range checks {
+		if len(fc.Problems) > 0 {
+			results = append(results, newValidationResult("", fc.File, 0, fc.Problems))
 		}
+		for _, kc := range fc.Koans {
+			results = append(results, newValidationResult(kc.ID, fc.File, kc.Line, kc.Problems))
+		}
+		if !fc.OK() {
+			failed++
+		}
+	}
 
-		results = append(results, result)
+	switch format {
+	case ui.OutputText:
+		console.DisplayValidationResults(results, len(results))
+	case ui.OutputJUnit:
+		err = console.DisplayDocument(format, report.NewJUnitReport(results))
+	default:
+		err = console.DisplayDocument(format, report.NewValidationDocument(results))
+	}
+	if err != nil {
+		return err
 	}
 
-	ui.DisplayValidationResults(results, len(allKoans))
+	if failed > 0 {
+		return fmt.Errorf("validation failed: %d of %d lesson files have problems", failed, len(checks))
+	}
 	return nil
 }
 
+// newValidationResult summarises the problems found for one koan or file
+func newValidationResult(koanID, file string, line int, problems []koan.Problem) ui.ValidationResult {
+	result := ui.ValidationResult{
+		KoanID:   koanID,
+		File:     file,
+		Line:     line,
+		Passed:   len(problems) == 0,
+		Problems: problems,
+	}
+	if len(problems) > 0 {
+		result.Error = problems[0].Message
+	}
+	return result
+}
+
 // ShowStatus displays progress statistics
 func (r *Runner) ShowStatus() error {
 	allKoans := koan.GetAllKoans(r.lessons)
+	if r.outputFormat != ui.OutputText {
+		return r.console.DisplayDocument(r.outputFormat, report.NewStatusDocument(r.tracker, len(allKoans)))
+	}
+
 	stats := r.tracker.GetStats(len(allKoans))
-	ui.DisplayProgress(stats)
+	r.console.DisplayProgress(stats)
 
 	if r.tracker.Exists() {
-		ui.DisplayInfo(fmt.Sprintf("Progress file: %s", r.tracker.GetFilePath()))
+		r.console.DisplayInfo(fmt.Sprintf("Progress file: %s", r.tracker.GetFilePath()))
 	} else {
-		ui.DisplayInfo("No progress file yet. Start learning to create one!")
+		r.console.DisplayInfo("No progress file yet. Start learning to create one!")
 	}
 
 	return nil
@@ -236,51 +396,29 @@ func (r *Runner) ShowStatus() error {
 
 // ListLessons displays all available lessons
 func (r *Runner) ListLessons() error {
-	ui.DisplayLessonList(r.lessons, r.tracker)
+	if r.outputFormat != ui.OutputText {
+		return r.console.DisplayDocument(r.outputFormat, report.NewLessonsDocument(r.lessons, r.tracker))
+	}
+
+	r.console.DisplayLessonList(r.lessons, r.tracker)
 	return nil
 }
 
 // Reset resets all progress
 func (r *Runner) Reset() error {
 	if !r.tracker.Exists() {
-		ui.DisplayInfo("No progress to reset.")
+		r.console.DisplayInfo("No progress to reset.")
 		return nil
 	}
 
-	if ui.PromptYesNo("Are you sure you want to reset all progress?") {
+	if r.console.PromptYesNo("Are you sure you want to reset all progress?") {
 		if err := r.tracker.Reset(); err != nil {
 			return fmt.Errorf("failed to reset progress: %w", err)
 		}
-		ui.DisplaySuccess("Progress has been reset.")
+		r.console.DisplaySuccess("Progress has been reset.")
 	} else {
-		ui.DisplayInfo("Reset cancelled.")
+		r.console.DisplayInfo("Reset cancelled.")
 	}
 
 	return nil
 }
-
-// GetLessonsDir returns a default lessons directory path
-func GetLessonsDir() string {
-	// Try to find lessons directory relative to executable
-	exePath, err := os.Executable()
-	if err != nil {
-		return "lessons"
-	}
-
-	exeDir := filepath.Dir(exePath)
-
-	// Check if lessons directory exists relative to executable
-	lessonsPath := filepath.Join(exeDir, "lessons")
-	if _, err := os.Stat(lessonsPath); err == nil {
-		return lessonsPath
-	}
-
-	// Check if we're in development (running with go run)
-	// Look for lessons in current directory
-	if _, err := os.Stat("lessons"); err == nil {
-		return "lessons"
-	}
-
-	// Default fallback
-	return "lessons"
-}
@@ -0,0 +1,167 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/ui"
+)
+
+// ansiPattern matches terminal color codes so transcripts can ignore them
+var ansiPattern = regexp.MustCompile("\033\\[[0-9;?]*[a-zA-Z]")
+
+// session is one command from a transcript with its scripted input and expected output
+type session struct {
+	command  string
+	input    []string
+	expected []string
+	wantErr  string
+}
+
+// parseTranscript reads a transcript file.
+//
+//	$ command   starts a new session running the given command
+//	> text      is a line of input typed by the learner
+//	< text      must appear in the output, after the previous expectation
+//	! text      the command must fail with an error containing text
+//	# text      is a comment
+func parseTranscript(t *testing.T, path string) []*session {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read transcript: %v", err)
+	}
+
+	var sessions []*session
+	var current *session
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		marker, text := line[:1], strings.TrimPrefix(line[1:], " ")
+		if marker == "$" {
+			current = &session{command: text}
+			sessions = append(sessions, current)
+			continue
+		}
+		if current == nil {
+			t.Fatalf("%s:%d: expected a '$ command' line first", path, i+1)
+		}
+
+		switch marker {
+		case ">":
+			current.input = append(current.input, text)
+		case "<":
+			current.expected = append(current.expected, text)
+		case "!":
+			current.wantErr = text
+		default:
+			t.Fatalf("%s:%d: unknown transcript line %q", path, i+1, line)
+		}
+	}
+	return sessions
+}
+
+// runCommand runs a transcript command the way the cronkoans binary would,
+// with the words of the command as its arguments; double quotes keep an
+// argument with spaces together. A command that does not choose its lessons
+// with --lessons, --pack or --no-builtin learns from testdata/lessons alone.
+func runCommand(console *ui.Console, command string) error {
+	args := splitCommand(command)
+	choosesLessons := false
+	for _, arg := range args {
+		for _, name := range []string{"--lessons", "--pack", "--no-builtin"} {
+			if arg == name || strings.HasPrefix(arg, name+"=") {
+				choosesLessons = true
+			}
+		}
+	}
+	if !choosesLessons {
+		args = append([]string{"--no-builtin", "--lessons=testdata/lessons"}, args...)
+	}
+	return Main(args, console)
+}
+
+// splitCommand splits a transcript command into words, keeping text between
+// double quotes as one word
+func splitCommand(command string) []string {
+	var fields []string
+	for i, part := range strings.Split(command, "\"") {
+		if i%2 == 1 {
+			fields = append(fields, part)
+			continue
+		}
+		fields = append(fields, strings.Fields(part)...)
+	}
+	return fields
+}
+
+func TestTranscripts(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "transcripts", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no transcripts found")
+	}
+
+	// Commands that take dates read them in the local time zone, and print
+	// symbols unless the terminal is dumb; pin both so transcripts pass anywhere
+	local := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = local })
+	t.Setenv("TERM", "xterm")
+
+	for _, file := range files {
+		file := file
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".txt"), func(t *testing.T) {
+			// Every transcript gets its own progress file, and its own copy of
+			// testdata so that authoring commands can write lessons
+			t.Setenv("HOME", t.TempDir())
+			transcript, err := filepath.Abs(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			work := t.TempDir()
+			if err := os.CopyFS(filepath.Join(work, "testdata"), os.DirFS("testdata")); err != nil {
+				t.Fatal(err)
+			}
+			t.Chdir(work)
+
+			for _, s := range parseTranscript(t, transcript) {
+				input := strings.Join(s.input, "\n")
+				if len(s.input) > 0 {
+					input += "\n"
+				}
+
+				var out bytes.Buffer
+				console := ui.NewConsole(strings.NewReader(input), &out, &out)
+				err := runCommand(console, s.command)
+				switch {
+				case s.wantErr == "" && err != nil:
+					t.Fatalf("$ %s: unexpected error: %v", s.command, err)
+				case s.wantErr != "" && (err == nil || !strings.Contains(err.Error(), s.wantErr)):
+					t.Fatalf("$ %s: expected error containing %q, got %v", s.command, s.wantErr, err)
+				}
+
+				output := ansiPattern.ReplaceAllString(out.String(), "")
+				rest := output
+				for _, want := range s.expected {
+					idx := strings.Index(rest, want)
+					if idx < 0 {
+						t.Fatalf("$ %s: expected output %q not found after previous expectations\n--- output ---\n%s",
+							s.command, want, output)
+					}
+					rest = rest[idx+len(want):]
+				}
+			}
+		})
+	}
+}
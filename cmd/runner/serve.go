@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/internal/server"
+	"github.com/dwildt/cronkoans/internal/ui"
+)
+
+// serverDataDir is where the server keeps progress, in the home directory
+const serverDataDir = ".cronkoans_server"
+
+// ServeOptions control the HTTP server
+type ServeOptions struct {
+	Addr       string // address to listen on, such as :8080
+	DataDir    string // directory for per-user progress; empty uses ~/.cronkoans_server
+	TokensFile string // YAML file mapping users to tokens; empty trusts the user header
+}
+
+// Serve runs the web interface and koan API over the lessons until the server stops
+func Serve(lessonsFS fs.FS, console ui.UI, opts ServeOptions) error {
+	handler, err := NewServer(lessonsFS, opts)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	console.DisplayInfo(fmt.Sprintf("Serving cronkoans on %s: open it in a browser, or use the API under /api (Ctrl+C to stop)", opts.Addr))
+	return srv.ListenAndServe()
+}
+
+// NewServer loads the lessons and pack behind the web interface and koan API
+func NewServer(lessonsFS fs.FS, opts ServeOptions) (*server.Server, error) {
+	lessons, err := koan.LoadAllLessonsFS(lessonsFS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lessons: %w", err)
+	}
+	pack, err := koan.LoadPackFS(lessonsFS)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DataDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		opts.DataDir = filepath.Join(homeDir, serverDataDir)
+	}
+
+	serverOpts := server.Options{DataDir: opts.DataDir}
+	if opts.TokensFile != "" {
+		if serverOpts.Tokens, err = server.LoadTokens(opts.TokensFile); err != nil {
+			return nil, err
+		}
+	}
+	return server.New(lessons, pack.Name, serverOpts), nil
+}
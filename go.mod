@@ -2,4 +2,9 @@ module github.com/dwildt/cronkoans
 
 go 1.24.1
 
-require gopkg.in/yaml.v3 v3.0.1
+require (
+	golang.org/x/term v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.31.0 // indirect
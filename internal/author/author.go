@@ -0,0 +1,270 @@
+package author
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+)
+
+// lessonFilePattern matches numbered lesson files such as 04_steps.yaml
+var lessonFilePattern = regexp.MustCompile(`^(\d+)_.*\.yaml$`)
+
+// Slug turns a lesson title into a file name part, using the text before
+// any " - " subtitle: "Time Zones - Running in UTC" becomes "time_zones"
+func Slug(title string) string {
+	title, _, _ = strings.Cut(title, " - ")
+
+	var sb strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if underscore && sb.Len() > 0 {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(r)
+			underscore = false
+		} else {
+			underscore = true
+		}
+	}
+
+	if sb.Len() == 0 {
+		return "lesson"
+	}
+	return sb.String()
+}
+
+// NextLessonFile returns the path in lessonsDir for a new lesson, numbered one
+// past the highest numbered lesson in lessons, which includes every layer
+func NextLessonFile(lessons fs.FS, lessonsDir, title string) (string, error) {
+	entries, err := fs.ReadDir(lessons, ".")
+	if err != nil {
+		return "", fmt.Errorf("failed to read lessons directory: %w", err)
+	}
+
+	highest := 0
+	for _, entry := range entries {
+		m := lessonFilePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		if n, err := strconv.Atoi(m[1]); err == nil && n > highest {
+			highest = n
+		}
+	}
+
+	return filepath.Join(lessonsDir, fmt.Sprintf("%02d_%s.yaml", highest+1, Slug(title))), nil
+}
+
+// LatestLessonFile returns the highest numbered lesson file in the directory
+func LatestLessonFile(lessonsDir string) (string, error) {
+	entries, err := os.ReadDir(lessonsDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read lessons directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if lessonFilePattern.MatchString(entry.Name()) {
+			files = append(files, entry.Name())
+		}
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no lesson files found in %s", lessonsDir)
+	}
+
+	sort.Strings(files)
+	return filepath.Join(lessonsDir, files[len(files)-1]), nil
+}
+
+// ExistingIDs collects the koan IDs of every lesson that can be loaded
+func ExistingIDs(lessons fs.FS) map[string]bool {
+	ids := make(map[string]bool)
+	matches, _ := fs.Glob(lessons, "*.yaml")
+	for _, match := range matches {
+		lesson, err := koan.LoadLessonFS(lessons, match)
+		if err != nil {
+			continue
+		}
+		for _, k := range lesson.Koans {
+			ids[k.ID] = true
+		}
+	}
+	return ids
+}
+
+// SuggestID returns the first unused ID of the form <prefix>_<number>
+func SuggestID(prefix string, used map[string]bool) string {
+	for n := 1; ; n++ {
+		id := fmt.Sprintf("%s_%d", prefix, n)
+		if !used[id] {
+			return id
+		}
+	}
+}
+
+// ProposeExplanation drafts an explanation for a koan from the description of its expression
+func ProposeExplanation(answer, expression string) string {
+	description := lowerFirst(cronexpr.Describe(expression))
+	return fmt.Sprintf("With %s filled in, %s runs %s.", answer, expression, description)
+}
+
+// NewLessonNode builds the YAML document for a new lesson, with comments
+// that point authors at the parts they may want to extend
+func NewLessonNode(lesson *koan.Lesson) *yaml.Node {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	addString(root, "title", lesson.Title)
+	addString(root, "description", lesson.Description)
+
+	koans := &yaml.Node{Kind: yaml.SequenceNode}
+	for i := range lesson.Koans {
+		koans.Content = append(koans.Content, KoanNode(&lesson.Koans[i]))
+	}
+	root.Content = append(root.Content,
+		&yaml.Node{
+			Kind: yaml.ScalarNode, Value: "koans",
+			HeadComment: "# Each lesson should have 3-5 koans. Add more with: cronkoans new koan",
+		},
+		koans)
+
+	return &yaml.Node{
+		Kind:        yaml.DocumentNode,
+		HeadComment: "# " + lesson.Title,
+		Content:     []*yaml.Node{root},
+	}
+}
+
+// KoanNode builds the YAML mapping for a koan, quoting strings like the built-in lessons
+func KoanNode(k *koan.Koan) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	addString(node, "id", k.ID)
+	addString(node, "description", k.Description)
+	addString(node, "question", k.Question)
+	addString(node, "incomplete", k.Incomplete)
+	addString(node, "answer", k.Answer)
+
+	hints := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, hint := range k.Hints {
+		hints.Content = append(hints.Content, quoted(hint))
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "hints"}, hints)
+
+	addString(node, "explanation", k.Explanation)
+	return node
+}
+
+// AppendKoan adds a koan to the end of an existing lesson file.
+// The file is edited as a YAML node tree so its comments are kept.
+func AppendKoan(filename string, k *koan.Koan) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse YAML from %s: %w", filename, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a lesson file", filename)
+	}
+
+	root := doc.Content[0]
+	var koans *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "koans" {
+			koans = root.Content[i+1]
+		}
+	}
+	if koans == nil {
+		koans = &yaml.Node{Kind: yaml.SequenceNode}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "koans"}, koans)
+	}
+	koans.Kind = yaml.SequenceNode
+	koans.Content = append(koans.Content, KoanNode(k))
+
+	return WriteNode(filename, &doc)
+}
+
+// WriteNode writes a YAML document to a file in the layout of the built-in lessons
+func WriteNode(filename string, doc *yaml.Node) error {
+	data, err := EncodeNode(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filename, err)
+	}
+
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", filename, err)
+	}
+	return nil
+}
+
+// EncodeNode encodes a YAML document in the layout of the built-in lessons:
+// two-space indentation and a blank line between koans
+func EncodeNode(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return separateKoans(buf.Bytes()), nil
+}
+
+// separateKoans puts a blank line before every koan after the first,
+// which the encoder does not preserve
+func separateKoans(data []byte) []byte {
+	lines := strings.Split(string(data), "\n")
+	var out []string
+	inKoans, first := false, true
+
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "koans:"):
+			inKoans, first = true, true
+		case inKoans && line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "#"):
+			inKoans = false
+		case inKoans && strings.HasPrefix(line, "  - "):
+			// Keep comments attached to the koan that follows them
+			at := len(out)
+			for at > 0 && strings.HasPrefix(strings.TrimSpace(out[at-1]), "#") {
+				at--
+			}
+			if !first && at > 0 && out[at-1] != "" {
+				out = append(out[:at], append([]string{""}, out[at:]...)...)
+			}
+			first = false
+		}
+		out = append(out, line)
+	}
+
+	return []byte(strings.Join(out, "\n"))
+}
+
+// addString appends a double-quoted key/value pair to a mapping, skipping empty values
+func addString(mapping *yaml.Node, key, value string) {
+	if value == "" {
+		return
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, quoted(value))
+}
+
+// quoted returns a double-quoted string scalar
+func quoted(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: yaml.DoubleQuotedStyle}
+}
@@ -0,0 +1,351 @@
+package author
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+)
+
+// Concept is the cron feature a generated koan practises
+type Concept string
+
+const (
+	ConceptSteps  Concept = "steps"
+	ConceptRanges Concept = "ranges"
+	ConceptLists  Concept = "lists"
+	ConceptNames  Concept = "names"
+)
+
+// Concepts lists every concept the generator knows, in teaching order
+var Concepts = []Concept{ConceptRanges, ConceptSteps, ConceptLists, ConceptNames}
+
+// ParseConcept parses a concept name
+func ParseConcept(s string) (Concept, error) {
+	for _, c := range Concepts {
+		if strings.EqualFold(s, string(c)) {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown concept %q (want ranges, steps, lists or names)", s)
+}
+
+// cronField describes one of the five fields for generated text
+type cronField struct {
+	name     string // as used in hints: "minute", "day of month"
+	unit     string // what one value counts: "minute", "day"
+	min, max int
+	legend   string // how values map to names, if useful
+}
+
+// cronFields are the five fields in order
+var cronFields = []cronField{
+	{"minute", "minute", 0, 59, ""},
+	{"hour", "hour", 0, 23, ""},
+	{"day of month", "day", 1, 31, ""},
+	{"month", "month", 1, 12, " (1 = January, 12 = December)"},
+	{"day of week", "weekday", 0, 6, " (0 = Sunday, 6 = Saturday)"},
+}
+
+// stepSizes are the steps that divide each field evenly, so generated koans stay realistic
+var stepSizes = [][]int{
+	{2, 5, 10, 15, 20, 30},
+	{2, 3, 4, 6, 8, 12},
+	{2, 5, 10},
+	{2, 3, 4, 6},
+	{2},
+}
+
+// RandomExpression generates an expression that uses concept in one field and
+// returns it with the index of that field
+func RandomExpression(concept Concept, rng *rand.Rand) (string, int) {
+	return RandomExpressionAt(concept, 2, rng)
+}
+
+// RandomExpressionAt generates an expression for a concept at a difficulty from 1 to 5:
+//
+//  1. the concept in the minute, hour or weekday field
+//  2. the concept in any field
+//  3. as 2, plus another restriction on the day, month or weekday
+//  4. as 3, with compound values such as 9-17/2 or 1-5,0
+//  5. as 4, with the whole expression as the blank (field -1)
+func RandomExpressionAt(concept Concept, difficulty int, rng *rand.Rand) (string, int) {
+	fields := []string{"*", "*", "*", "*", "*"}
+
+	var field int
+	switch {
+	case concept == ConceptNames && difficulty <= 1:
+		field = 4
+	case concept == ConceptNames:
+		field = 3 + rng.Intn(2)
+	case difficulty <= 1:
+		field = []int{0, 1, 4}[rng.Intn(3)]
+		if concept == ConceptSteps {
+			field = rng.Intn(2)
+		}
+	case concept == ConceptSteps:
+		field = rng.Intn(4)
+	default:
+		field = rng.Intn(5)
+	}
+	fields[field] = randomValue(concept, field, difficulty >= 4, rng)
+
+	// Harder koans restrict another calendar field as well. Day of month and day
+	// of week are never both set, since cron would combine them with OR.
+	if difficulty >= 3 {
+		other := 3
+		if field == 3 {
+			other = 4
+		}
+		if field <= 1 {
+			other = 2 + rng.Intn(3)
+		}
+		extra := Concepts[rng.Intn(len(Concepts))]
+		if extra == ConceptNames && other == 2 {
+			extra = ConceptRanges
+		}
+		if extra == ConceptSteps && other == 4 {
+			extra = ConceptLists
+		}
+		fields[other] = randomValue(extra, other, false, rng)
+	}
+
+	// Pin the time of day for day-level schedules so the koan reads naturally
+	if field >= 1 && fields[0] == "*" {
+		fields[0] = strconv.Itoa(15 * rng.Intn(4))
+	}
+	if field >= 2 && fields[1] == "*" {
+		fields[1] = strconv.Itoa(rng.Intn(24))
+	}
+
+	if difficulty >= 5 {
+		field = -1
+	}
+	return strings.Join(fields, " "), field
+}
+
+// randomValue generates a value for one field that uses concept.
+// Compound values combine two forms, such as a range with a step.
+func randomValue(concept Concept, field int, compound bool, rng *rand.Rand) string {
+	f := cronFields[field]
+	switch concept {
+	case ConceptSteps:
+		steps := stepSizes[field]
+		step := strconv.Itoa(steps[rng.Intn(len(steps))])
+		if compound {
+			start := f.min + rng.Intn((f.max-f.min)/2)
+			end := start + (f.max-start)/2 + rng.Intn((f.max-start)/2+1)
+			return fmt.Sprintf("%d-%d/%s", start, end, step)
+		}
+		return "*/" + step
+	case ConceptRanges:
+		start := f.min + rng.Intn(f.max-f.min)
+		end := start + 1 + rng.Intn(f.max-start)
+		if compound && end+2 <= f.max {
+			return fmt.Sprintf("%d-%d,%d", start, end, end+2+rng.Intn(f.max-end-1))
+		}
+		return fmt.Sprintf("%d-%d", start, end)
+	case ConceptLists:
+		values := randomValues(rng, f.min, f.max, 2+rng.Intn(3))
+		if compound && values[0]+2 < values[1] {
+			return fmt.Sprintf("%d-%d,%s", values[0], values[0]+1, joinInts(values[1:]))
+		}
+		return joinInts(values)
+	case ConceptNames:
+		names := []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+		offset := 1
+		if field == 4 {
+			names, offset = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}, 0
+		}
+		values := randomValues(rng, f.min, f.max, 2+rng.Intn(2))
+		if compound && len(values) == 3 && values[0]+1 < values[1] {
+			return names[values[0]-offset] + "-" + names[values[1]-offset] + "," + names[values[2]-offset]
+		}
+		if rng.Intn(2) == 0 {
+			// A range of names such as MON-FRI
+			return names[values[0]-offset] + "-" + names[values[len(values)-1]-offset]
+		}
+		var list []string
+		for _, v := range values {
+			list = append(list, names[v-offset])
+		}
+		return strings.Join(list, ",")
+	}
+	return strconv.Itoa(f.min + rng.Intn(f.max-f.min+1))
+}
+
+// randomValues picks n distinct values between min and max, sorted
+func randomValues(rng *rand.Rand, min, max, n int) []int {
+	values := rng.Perm(max - min + 1)[:n]
+	for i := range values {
+		values[i] += min
+	}
+	sort.Ints(values)
+	return values
+}
+
+// joinInts formats values as a comma-separated list
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
+
+// fieldConcept reports which concept a field value uses
+func fieldConcept(value string) Concept {
+	switch {
+	case strings.IndexFunc(value, func(r rune) bool { return r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' }) >= 0:
+		return ConceptNames
+	case strings.Contains(value, "/"):
+		return ConceptSteps
+	case strings.Contains(value, ","):
+		return ConceptLists
+	case strings.Contains(value, "-"):
+		return ConceptRanges
+	}
+	return ""
+}
+
+// BlankField picks the field to blank out: the first that uses concept, or
+// without a concept the most instructive one
+func BlankField(expression string, concept Concept) int {
+	fields := strings.Fields(expression)
+	if concept != "" {
+		for i, f := range fields {
+			if fieldConcept(f) == concept {
+				return i
+			}
+		}
+	}
+	for _, c := range []Concept{ConceptNames, ConceptSteps, ConceptLists, ConceptRanges} {
+		for i, f := range fields {
+			if fieldConcept(f) == c {
+				return i
+			}
+		}
+	}
+	for i, f := range fields {
+		if f != "*" {
+			return i
+		}
+	}
+	return 0
+}
+
+// KoanFromExpression turns a full expression into a koan by blanking out one
+// field, with three hints graded from general to almost the answer.
+// A field of -1 blanks out the whole expression; special strings are always blanked whole.
+func KoanFromExpression(expression string, field int, id string) (*koan.Koan, error) {
+	expression = strings.Join(strings.Fields(expression), " ")
+	if err := cronexpr.Validate(expression); err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", expression, err)
+	}
+
+	fields := strings.Fields(expression)
+	description := cronexpr.Describe(expression)
+	k := &koan.Koan{
+		ID:       id,
+		Question: "Schedule a job to run " + lowerFirst(description),
+	}
+
+	if len(fields) == 5 && (field < 0 || field > 4) {
+		k.Description = "A whole expression"
+		k.Incomplete = "__"
+		k.Answer = expression
+		k.Hints = []string{
+			"Write all five fields: minute, hour, day of month, month and day of week",
+			"Start with the time of day, then restrict the days; use * for fields that do not matter",
+			"Use " + expression,
+		}
+		k.Explanation = fmt.Sprintf("%s runs %s.", expression, lowerFirst(description))
+		return k, nil
+	}
+
+	if len(fields) != 5 {
+		k.Description = "Special strings"
+		k.Incomplete = "__"
+		k.Answer = expression
+		k.Hints = []string{
+			"This schedule has a shorthand that starts with @",
+			"The special strings are @yearly, @monthly, @weekly, @daily, @hourly and @reboot",
+			"Use " + expression,
+		}
+		k.Explanation = fmt.Sprintf("%s is a shorthand: %s.", expression, lowerFirst(description))
+		return k, nil
+	}
+
+	f := cronFields[field]
+	answer := fields[field]
+	fields[field] = "__"
+	k.Incomplete = strings.Join(fields, " ")
+	k.Answer = answer
+
+	concept := fieldConcept(answer)
+	k.Description = conceptTitle(concept) + " in the " + f.name + " field"
+	k.Hints = []string{
+		fmt.Sprintf("The blank is the %s field, which takes values from %d to %d%s", f.name, f.min, f.max, f.legend),
+		conceptHint(concept, f),
+		"Use " + answer,
+	}
+	k.Explanation = ProposeExplanation(answer, expression) + " " + conceptExplanation(concept)
+	return k, nil
+}
+
+// conceptTitle names a concept for koan descriptions
+func conceptTitle(c Concept) string {
+	switch c {
+	case ConceptSteps:
+		return "Step values"
+	case ConceptRanges:
+		return "A range"
+	case ConceptLists:
+		return "A list"
+	case ConceptNames:
+		return "Names"
+	}
+	return "A single value"
+}
+
+// conceptHint is the second, more specific hint for a concept
+func conceptHint(c Concept, f cronField) string {
+	switch c {
+	case ConceptSteps:
+		return fmt.Sprintf("Use the step operator: */n repeats every n %ss", f.unit)
+	case ConceptRanges:
+		return fmt.Sprintf("Use a dash between the first and the last %s", f.unit)
+	case ConceptLists:
+		return fmt.Sprintf("Separate each %s with a comma", f.unit)
+	case ConceptNames:
+		return "Use three-letter English names such as MON or JAN, with a dash for a range or commas for a list"
+	}
+	return fmt.Sprintf("A single %s is just a number", f.unit)
+}
+
+// conceptExplanation adds what the concept means to a generated explanation
+func conceptExplanation(c Concept) string {
+	switch c {
+	case ConceptSteps:
+		return "The / operator repeats a value at a fixed interval."
+	case ConceptRanges:
+		return "A dash includes every value from the start to the end."
+	case ConceptLists:
+		return "Commas list the exact values to run at."
+	case ConceptNames:
+		return "Months and weekdays can be written as names instead of numbers."
+	}
+	return "A single value matches exactly one time."
+}
+
+// lowerFirst lowercases the first letter, so describer output can continue a sentence
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,149 @@
+package author
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+)
+
+// conceptMarks are the characters that show a field uses a concept. Compound
+// values mix forms, so a range may come with a list and still be a range.
+var conceptMarks = map[Concept]string{
+	ConceptRanges: "-",
+	ConceptSteps:  "/",
+	ConceptLists:  ",",
+	ConceptNames:  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+}
+
+func TestRandomExpressionAt(t *testing.T) {
+	tests := []struct {
+		difficulty int
+		fields     []int // where the concept may be; nil for the whole expression
+	}{
+		{1, []int{0, 1, 4}},
+		{2, []int{0, 1, 2, 3, 4}},
+		{3, []int{0, 1, 2, 3, 4}},
+		{4, []int{0, 1, 2, 3, 4}},
+		{5, nil},
+	}
+	for _, concept := range Concepts {
+		for _, tt := range tests {
+			for seed := int64(0); seed < 200; seed++ {
+				expr, field := RandomExpressionAt(concept, tt.difficulty, rand.New(rand.NewSource(seed)))
+				name := fmt.Sprintf("%s at %d, %s", concept, tt.difficulty, expr)
+
+				if err := cronexpr.Validate(expr); err != nil {
+					t.Errorf("%s: %v", name, err)
+					continue
+				}
+				fields := strings.Fields(expr)
+				if fields[2] != "*" && fields[4] != "*" {
+					t.Errorf("%s: restricts both day of month and day of week", name)
+				}
+
+				if tt.fields == nil {
+					if field != -1 {
+						t.Errorf("%s: field %d, want -1", name, field)
+					}
+					continue
+				}
+				allowed := false
+				for _, f := range tt.fields {
+					allowed = allowed || f == field
+				}
+				if !allowed {
+					t.Errorf("%s: concept in field %d, want one of %v", name, field, tt.fields)
+					continue
+				}
+				if !strings.ContainsAny(fields[field], conceptMarks[concept]) {
+					t.Errorf("%s: field %d does not use %s", name, field, concept)
+				}
+
+				k, err := KoanFromExpression(expr, field, "generated_1")
+				if err != nil {
+					t.Errorf("%s: %v", name, err)
+				} else if !k.CheckAnswer(k.Answer) {
+					t.Errorf("%s: the answer %q is not accepted", name, k.Answer)
+				}
+			}
+		}
+	}
+}
+
+func TestKoanFromExpression(t *testing.T) {
+	tests := []struct {
+		expression  string
+		field       int
+		incomplete  string
+		answer      string
+		description string
+		hints       []string
+	}{
+		{"*/15 * * * *", 0, "__ * * * *", "*/15", "Step values in the minute field", []string{
+			"The blank is the minute field, which takes values from 0 to 59",
+			"Use the step operator: */n repeats every n minutes",
+			"Use */15",
+		}},
+		{"0 9-17 * * *", 1, "0 __ * * *", "9-17", "A range in the hour field", []string{
+			"The blank is the hour field, which takes values from 0 to 23",
+			"Use a dash between the first and the last hour",
+			"Use 9-17",
+		}},
+		{"0 0 1,15 * *", 2, "0 0 __ * *", "1,15", "A list in the day of month field", []string{
+			"The blank is the day of month field, which takes values from 1 to 31",
+			"Separate each day with a comma",
+			"Use 1,15",
+		}},
+		{"0 0 1 JAN,JUL *", 3, "0 0 1 __ *", "JAN,JUL", "Names in the month field", []string{
+			"The blank is the month field, which takes values from 1 to 12 (1 = January, 12 = December)",
+			"Use three-letter English names such as MON or JAN, with a dash for a range or commas for a list",
+			"Use JAN,JUL",
+		}},
+		{"0  9 * *  1-5", 4, "0 9 * * __", "1-5", "A range in the day of week field", []string{
+			"The blank is the day of week field, which takes values from 0 to 6 (0 = Sunday, 6 = Saturday)",
+			"Use a dash between the first and the last weekday",
+			"Use 1-5",
+		}},
+		{"30 2 * * *", 0, "__ 2 * * *", "30", "A single value in the minute field", []string{
+			"The blank is the minute field, which takes values from 0 to 59",
+			"A single minute is just a number",
+			"Use 30",
+		}},
+		{"0 9 * * 1-5", -1, "__", "0 9 * * 1-5", "A whole expression", []string{
+			"Write all five fields: minute, hour, day of month, month and day of week",
+			"Start with the time of day, then restrict the days; use * for fields that do not matter",
+			"Use 0 9 * * 1-5",
+		}},
+		{"@daily", 0, "__", "@daily", "Special strings", []string{
+			"This schedule has a shorthand that starts with @",
+			"The special strings are @yearly, @monthly, @weekly, @daily, @hourly and @reboot",
+			"Use @daily",
+		}},
+	}
+	for _, tt := range tests {
+		k, err := KoanFromExpression(tt.expression, tt.field, "generated_1")
+		if err != nil {
+			t.Errorf("%s: %v", tt.expression, err)
+			continue
+		}
+		if k.Incomplete != tt.incomplete || k.Answer != tt.answer {
+			t.Errorf("%s: blank %q with answer %q, want %q with %q", tt.expression, k.Incomplete, k.Answer, tt.incomplete, tt.answer)
+		}
+		if k.Description != tt.description {
+			t.Errorf("%s: description %q, want %q", tt.expression, k.Description, tt.description)
+		}
+		if strings.Join(k.Hints, "\n") != strings.Join(tt.hints, "\n") {
+			t.Errorf("%s: hints\n%s\nwant\n%s", tt.expression, strings.Join(k.Hints, "\n"), strings.Join(tt.hints, "\n"))
+		}
+		if !k.CheckAnswer(k.Answer) {
+			t.Errorf("%s: the answer %q is not accepted", tt.expression, k.Answer)
+		}
+	}
+
+	if _, err := KoanFromExpression("61 * * * *", 0, "generated_1"); err == nil || !strings.Contains(err.Error(), "invalid expression") {
+		t.Errorf("61 * * * *: error %v, want an invalid expression", err)
+	}
+}
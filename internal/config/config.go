@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configDirName  = "cronkoans"
+	configFileName = "config.yaml"
+)
+
+// Config holds user preferences read from the config file
+type Config struct {
+	// Color is the default color mode: auto, always or never
+	Color string `yaml:"color"`
+
+	// ASCII replaces symbols and emoji with plain ASCII
+	ASCII bool `yaml:"ascii"`
+
+	// Calendar shows the current month's runs after each solved koan
+	Calendar bool `yaml:"calendar"`
+
+	// Theme maps output roles (title, info, muted, hint, warning, success,
+	// error, accent, bold) to color specs such as "bold cyan" or "208"
+	Theme map[string]string `yaml:"theme"`
+
+	// Lessons are lesson directories layered over the bundled lessons, before
+	// any given with --lessons. A leading ~ is the home directory and relative
+	// paths are relative to the config file.
+	Lessons []string `yaml:"lessons"`
+}
+
+// DefaultPath returns the default location of the config file
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, configDirName, configFileName)
+}
+
+// Load reads the config file at path. A missing file yields an empty config.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	for i, dir := range cfg.Lessons {
+		cfg.Lessons[i] = resolvePath(dir, filepath.Dir(path))
+	}
+
+	return cfg, nil
+}
+
+// resolvePath expands a leading ~ and makes a relative path relative to base
+func resolvePath(path, base string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
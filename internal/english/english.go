@@ -0,0 +1,30 @@
+// Package english formats lists for the messages of the cron packages
+package english
+
+import (
+	"fmt"
+	"strings"
+)
+
+// shown is how many items List names before counting the rest
+const shown = 4
+
+// List joins items as "a, b and c", or for long lists as "a, b, c, d and
+// 3 other <noun>"
+func List(items []string, noun string) string {
+	if len(items) > shown+1 {
+		return fmt.Sprintf("%s and %d other %s", strings.Join(items[:shown], ", "), len(items)-shown, noun)
+	}
+	return Join(items)
+}
+
+// Join joins items as "a, b and c", and no items as ""
+func Join(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
@@ -0,0 +1,24 @@
+package english
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		items []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"09:00"}, "09:00"},
+		{[]string{"09:00", "10:00"}, "09:00 and 10:00"},
+		{strings.Fields("1 2 3 4 5"), "1, 2, 3, 4 and 5"},
+		{strings.Fields("1 2 3 4 5 6"), "1, 2, 3, 4 and 2 other times"},
+	}
+	for _, tt := range tests {
+		if got := List(tt.items, "times"); got != tt.want {
+			t.Errorf("List(%q) = %q, want %q", tt.items, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,603 @@
+package koan
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+	"github.com/dwildt/cronkoans/pkg/oncalendar"
+)
+
+// requiredHints is the number of hints every koan must provide
+const requiredHints = 3
+
+// Names of the individual lesson checks, used in reports
+const (
+	CheckYAML        = "yaml"
+	CheckRequired    = "required-field"
+	CheckUnknown     = "unknown-field"
+	CheckDuplicateID = "duplicate-id"
+	CheckPlaceholder = "placeholder"
+	CheckAnswer      = "answer"
+	CheckHints       = "hints"
+	CheckIDStyle     = "id-convention"
+	CheckMetadata    = "metadata"
+	CheckPrereqs     = "prerequisites"
+	CheckReach       = "reach"
+)
+
+// Problem is a single issue found in a lesson file
+type Problem struct {
+	File    string `json:"file" yaml:"file"`
+	Line    int    `json:"line,omitempty" yaml:"line,omitempty"`
+	Column  int    `json:"column,omitempty" yaml:"column,omitempty"`
+	KoanID  string `json:"koan_id,omitempty" yaml:"koan_id,omitempty"`
+	Check   string `json:"check" yaml:"check"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// Position formats the problem location as file:line:column
+func (p Problem) Position() string {
+	switch {
+	case p.Line > 0 && p.Column > 0:
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	case p.Line > 0:
+		return fmt.Sprintf("%s:%d", p.File, p.Line)
+	}
+	return p.File
+}
+
+// String formats the problem the way compilers report errors
+func (p Problem) String() string {
+	return p.Position() + ": " + p.Message
+}
+
+// KoanCheck holds the problems found in one koan
+type KoanCheck struct {
+	ID       string
+	Line     int
+	Problems []Problem
+
+	complete string     // the expression the answer completes, when it is valid
+	dialect  Dialect    // the dialect complete is written in
+	answer   *yaml.Node // where the koan gives its answer
+}
+
+// FileCheck holds the problems found in one lesson file.
+// Problems that do not belong to a single koan are kept on the file itself.
+type FileCheck struct {
+	File     string
+	Lesson   *Lesson // nil when the file could not be parsed
+	Problems []Problem
+	Koans    []KoanCheck
+
+	prerequisitesLine int // where the lesson lists its prerequisites
+}
+
+// OK reports whether the file and all of its koans are free of problems
+func (fc *FileCheck) OK() bool {
+	if len(fc.Problems) > 0 {
+		return false
+	}
+	for _, kc := range fc.Koans {
+		if len(kc.Problems) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Err reports the file's first problem as an error, or nil when it has none
+func (fc *FileCheck) Err() error {
+	problems := fc.Problems
+	for _, kc := range fc.Koans {
+		problems = append(problems, kc.Problems...)
+	}
+	switch len(problems) {
+	case 0:
+		return nil
+	case 1:
+		return errors.New(problems[0].String())
+	}
+	return fmt.Errorf("%s (and %d more, see cronkoans validate)", problems[0], len(problems)-1)
+}
+
+// yamlLinePattern extracts the line number from yaml.v3 error messages
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// yamlLinePrefix is the redundant "line N: " at the start of yaml.v3 errors
+var yamlLinePrefix = regexp.MustCompile(`^line \d+: `)
+
+// CheckLessonFiles checks every lesson file in a directory independently,
+// collecting all problems instead of stopping at the first one.
+// Koan IDs are also checked for uniqueness across files.
+func CheckLessonFiles(lessonsDir string) ([]*FileCheck, error) {
+	return CheckLessonFilesFS(LessonDirs(lessonsDir))
+}
+
+// CheckLessonFilesFS is CheckLessonFiles for the lesson files at the root of a file system
+func CheckLessonFilesFS(fsys fs.FS) ([]*FileCheck, error) {
+	files, err := findLessonFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	var checks []*FileCheck
+
+	pack, err := LoadPackFS(fsys)
+	if err != nil {
+		// Keep checking the lessons, with the default settings
+		packFile := displayPath(fsys, PackFile)
+		checks = append(checks, &FileCheck{
+			File:     packFile,
+			Problems: []Problem{yamlProblem(packFile, err)},
+		})
+		pack = &Pack{Dialect: DialectCron, IDConvention: IDConventionNone}
+	}
+
+	for _, file := range files {
+		filename := displayPath(fsys, file)
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			checks = append(checks, &FileCheck{
+				File:     filename,
+				Problems: []Problem{{File: filename, Check: CheckYAML, Message: err.Error()}},
+			})
+			continue
+		}
+		checks = append(checks, checkLessonData(data, filename, pack.Dialect))
+	}
+
+	checkDuplicateIDs(checks)
+	checkIDConvention(checks, pack.IDConvention)
+	checkLessonPrerequisites(checks)
+	return checks, nil
+}
+
+// CheckLessonFile parses and checks a single lesson file, in the dialect of
+// the pack.yaml next to it
+func CheckLessonFile(filename string) *FileCheck {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return &FileCheck{
+			File:     filename,
+			Problems: []Problem{{File: filename, Check: CheckYAML, Message: err.Error()}},
+		}
+	}
+	return checkLessonData(data, filename, packDialect(os.DirFS(filepath.Dir(filename))))
+}
+
+// checkLessonData parses and checks the contents of a lesson file whose
+// koans are in dialect
+func checkLessonData(data []byte, filename string, dialect Dialect) *FileCheck {
+	fc := &FileCheck{File: filename}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		fc.Problems = append(fc.Problems, yamlProblem(filename, err))
+		return fc
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		fc.Problems = append(fc.Problems, Problem{
+			File: filename, Line: 1, Check: CheckYAML, Message: "lesson file must be a YAML mapping",
+		})
+		return fc
+	}
+	root := doc.Content[0]
+
+	var lesson Lesson
+	if err := root.Decode(&lesson); err != nil {
+		fc.Problems = append(fc.Problems, yamlProblem(filename, err))
+		return fc
+	}
+	lesson.Filename = filename
+	fc.Lesson = &lesson
+
+	// Lesson level fields
+	fc.Problems = append(fc.Problems, unknownFields(filename, "", root, reflect.TypeOf(Lesson{}))...)
+	if lesson.Title == "" {
+		fc.Problems = append(fc.Problems, Problem{
+			File: filename, Line: root.Line, Column: root.Column, Check: CheckRequired,
+			Message: "lesson must have a title",
+		})
+	}
+
+	if err := checkMetadata(lesson.Difficulty, lesson.Minutes); err != nil {
+		fc.Problems = append(fc.Problems, metadataProblem(filename, "", root, err))
+	}
+	if node := mappingValue(root, "prerequisites"); node != nil {
+		fc.prerequisitesLine = node.Line
+	}
+
+	koansNode := mappingValue(root, "koans")
+	if koansNode == nil || len(koansNode.Content) == 0 {
+		fc.Problems = append(fc.Problems, Problem{
+			File: filename, Line: root.Line, Column: root.Column, Check: CheckRequired,
+			Message: "lesson must have at least one koan",
+		})
+		return fc
+	}
+
+	// Koans, paired with their nodes for positions
+	seenIDs := make(map[string]int)
+	for i, node := range koansNode.Content {
+		if i >= len(lesson.Koans) {
+			break
+		}
+		k := &lesson.Koans[i]
+		k.Dialect = dialect
+		kc := KoanCheck{ID: k.ID, Line: node.Line, answer: mappingValue(node, "answer")}
+		kc.Problems = checkKoanNode(filename, k, node)
+		if k.Incomplete != "" && k.Answer != "" && containsBlank(k.Incomplete) {
+			if complete := replaceBlank(k.Incomplete, k.Answer); dialect.Validate(complete) == nil {
+				kc.complete, kc.dialect = complete, dialect
+			}
+		}
+
+		if k.ID != "" {
+			if line, seen := seenIDs[k.ID]; seen {
+				idNode := mappingValue(node, "id")
+				kc.Problems = append(kc.Problems, Problem{
+					File: filename, Line: idNode.Line, Column: idNode.Column, KoanID: k.ID, Check: CheckDuplicateID,
+					Message: fmt.Sprintf("duplicate koan ID %q (first defined at line %d)", k.ID, line),
+				})
+			} else {
+				seenIDs[k.ID] = node.Line
+			}
+		}
+
+		fc.Koans = append(fc.Koans, kc)
+	}
+
+	return fc
+}
+
+// checkKoanNode checks one koan against its YAML node
+func checkKoanNode(filename string, k *Koan, node *yaml.Node) []Problem {
+	var problems []Problem
+	add := func(n *yaml.Node, check, format string, args ...any) {
+		if n == nil {
+			n = node
+		}
+		problems = append(problems, Problem{
+			File: filename, Line: n.Line, Column: n.Column, KoanID: k.ID, Check: check,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	problems = append(problems, unknownFields(filename, k.ID, node, reflect.TypeOf(Koan{}))...)
+
+	required := []struct {
+		key   string
+		value string
+	}{
+		{"id", k.ID},
+		{"description", k.Description},
+		{"question", k.Question},
+		{"incomplete", k.Incomplete},
+		{"answer", k.Answer},
+	}
+	for _, field := range required {
+		if field.value == "" {
+			add(mappingValue(node, field.key), CheckRequired, "koan must have a %s", field.key)
+		}
+	}
+
+	if k.Incomplete != "" && !containsBlank(k.Incomplete) {
+		add(mappingValue(node, "incomplete"), CheckPlaceholder, "incomplete expression must contain __ placeholder")
+	}
+
+	if k.Incomplete != "" && k.Answer != "" && containsBlank(k.Incomplete) {
+		complete := replaceBlank(k.Incomplete, k.Answer)
+		if err := k.validAnswer(); err != nil {
+			add(mappingValue(node, "answer"), CheckAnswer,
+				"answer '%s' does not create a valid %s: %s (%v)", k.Answer, k.Dialect.Noun(), complete, err)
+		}
+	}
+
+	if err := checkMetadata(k.Difficulty, k.Minutes); err != nil {
+		problems = append(problems, metadataProblem(filename, k.ID, node, err))
+	}
+
+	if len(k.Hints) != requiredHints {
+		add(mappingValue(node, "hints"), CheckHints, "koan must have exactly %d hints, got %d", requiredHints, len(k.Hints))
+	}
+
+	for i, entry := range k.Feedback {
+		if entry.Answer == "" || entry.Message == "" {
+			feedbackNode := mappingValue(node, "feedback")
+			if feedbackNode != nil && i < len(feedbackNode.Content) {
+				feedbackNode = feedbackNode.Content[i]
+			}
+			add(feedbackNode, CheckRequired, "feedback entry %d must have an answer and a message", i+1)
+		}
+	}
+	if feedbackNode := mappingValue(node, "feedback"); feedbackNode != nil {
+		for _, entryNode := range feedbackNode.Content {
+			problems = append(problems, unknownFields(filename, k.ID, entryNode, reflect.TypeOf(FeedbackEntry{}))...)
+		}
+	}
+
+	return problems
+}
+
+// metadataProblem reports a metadata error at the difficulty or
+// estimated_minutes field of a lesson or koan node
+func metadataProblem(filename, koanID string, node *yaml.Node, err error) Problem {
+	field := mappingValue(node, "difficulty")
+	if strings.HasPrefix(err.Error(), "estimated_minutes") || field == nil {
+		field = mappingValue(node, "estimated_minutes")
+	}
+	if field == nil {
+		field = node
+	}
+	return Problem{
+		File: filename, Line: field.Line, Column: field.Column, KoanID: koanID, Check: CheckMetadata,
+		Message: err.Error(),
+	}
+}
+
+// checkDuplicateIDs reports koan IDs that appear in more than one file
+func checkDuplicateIDs(checks []*FileCheck) {
+	type location struct {
+		file string
+		line int
+	}
+	first := make(map[string]location)
+
+	for _, fc := range checks {
+		fileIDs := make(map[string]bool)
+		for i := range fc.Koans {
+			kc := &fc.Koans[i]
+			if kc.ID == "" || fileIDs[kc.ID] {
+				continue
+			}
+			fileIDs[kc.ID] = true
+
+			if loc, seen := first[kc.ID]; seen {
+				kc.Problems = append(kc.Problems, Problem{
+					File: fc.File, Line: kc.Line, KoanID: kc.ID, Check: CheckDuplicateID,
+					Message: fmt.Sprintf("koan ID %q is already used in %s:%d", kc.ID, loc.file, loc.line),
+				})
+				continue
+			}
+			first[kc.ID] = location{fc.File, kc.Line}
+		}
+	}
+}
+
+// checkIDConvention reports koan IDs that break the pack's naming convention,
+// and lessons that reuse another lesson's prefix
+func checkIDConvention(checks []*FileCheck, convention IDConvention) {
+	if convention != IDConventionLessonPrefix {
+		return
+	}
+
+	prefixes := make(map[string]string)
+	for _, fc := range checks {
+		if fc.Lesson == nil || len(fc.Lesson.Koans) == 0 {
+			continue
+		}
+
+		prefix := fc.Lesson.IDPrefix()
+		if other, used := prefixes[prefix]; used {
+			fc.Problems = append(fc.Problems, Problem{
+				File: fc.File, Line: 1, Check: CheckIDStyle,
+				Message: fmt.Sprintf("lesson prefix %q is already used by %s", prefix, other),
+			})
+		} else {
+			prefixes[prefix] = fc.File
+		}
+
+		for i := range fc.Koans {
+			kc := &fc.Koans[i]
+			if kc.ID == "" {
+				continue
+			}
+			if err := convention.CheckID(prefix, kc.ID); err != nil {
+				kc.Problems = append(kc.Problems, Problem{
+					File: fc.File, Line: kc.Line, KoanID: kc.ID, Check: CheckIDStyle, Message: err.Error(),
+				})
+			}
+		}
+	}
+}
+
+// CheckReachability reports koans whose answer completes an expression that
+// never fires, or that fires fewer than minPerYear times a year, listing the
+// next years it fires in from from's year. OnCalendar expressions can name a
+// year, so theirs must also fire after from. It is not part of the regular
+// checks, since a lesson may well teach a rare schedule such as February 29th.
+func CheckReachability(checks []*FileCheck, minPerYear int, from time.Time) {
+	for _, fc := range checks {
+		for i := range fc.Koans {
+			kc := &fc.Koans[i]
+			if kc.complete == "" {
+				continue
+			}
+
+			message := cronReach(kc.complete, minPerYear, from)
+			if kc.dialect == DialectSystemd {
+				message = calendarReach(kc.complete, minPerYear, from)
+			}
+			if message == "" {
+				continue
+			}
+
+			p := Problem{File: fc.File, Line: kc.Line, KoanID: kc.ID, Check: CheckReach, Message: message}
+			if kc.answer != nil {
+				p.Line, p.Column = kc.answer.Line, kc.answer.Column
+			}
+			kc.Problems = append(kc.Problems, p)
+		}
+	}
+}
+
+// cronReach describes a cron expression that never fires, or fires fewer than
+// minPerYear times a year, and is empty for one that fires often enough
+func cronReach(expr string, minPerYear int, from time.Time) string {
+	var rare *cronexpr.RareError
+	err := cronexpr.CheckReach(expr, minPerYear, from)
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &rare):
+		return fmt.Sprintf("%s %v", expr, rare)
+	case errors.Is(err, cronexpr.ErrNeverFires):
+		return fmt.Sprintf("this expression never fires: %s", expr)
+	default:
+		return err.Error()
+	}
+}
+
+// calendarReach describes an OnCalendar expression that never fires after
+// from, such as a date that has passed, or fires fewer than minPerYear times
+// in the year after from. It is empty for one that fires often enough.
+func calendarReach(expr string, minPerYear int, from time.Time) string {
+	s, err := oncalendar.Parse(expr)
+	if err != nil {
+		return err.Error()
+	}
+	next, ok := s.Next(from)
+	if !ok {
+		return fmt.Sprintf("this expression never fires after %s: %s", from.Format("2 Jan 2006"), expr)
+	}
+
+	yearLater := from.AddDate(1, 0, 0)
+	runs := 0
+	for t := next; ok && t.Before(yearLater) && runs < minPerYear; t, ok = s.Next(t) {
+		runs++
+	}
+	if runs >= minPerYear {
+		return ""
+	}
+	times := strconv.Itoa(runs) + " times"
+	if runs == 1 {
+		times = "once"
+	}
+	return fmt.Sprintf("%s fires %s in the year from %s, fewer than %d; next on %s",
+		expr, times, from.Format("2 Jan 2006"), minPerYear, next.Format("2 Jan 2006"))
+}
+
+// unknownFields reports mapping keys that do not correspond to a yaml tag on t
+func unknownFields(filename, koanID string, node *yaml.Node, t reflect.Type) []Problem {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	known := yamlFieldNames(t)
+	var problems []Problem
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if !known[key.Value] {
+			problems = append(problems, Problem{
+				File: filename, Line: key.Line, Column: key.Column, KoanID: koanID, Check: CheckUnknown,
+				Message: fmt.Sprintf("unknown field %q (known fields: %s)", key.Value, strings.Join(sortedKeys(known), ", ")),
+			})
+		}
+	}
+	return problems
+}
+
+// yamlFieldNames returns the YAML keys a struct type accepts
+func yamlFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("yaml")
+		name := strings.Split(tag, ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// sortedKeys returns the keys of a set in alphabetical order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// yamlProblem converts a YAML parse or decode error into a problem with a line number
+func yamlProblem(filename string, err error) Problem {
+	p := Problem{File: filename, Check: CheckYAML, Message: err.Error()}
+	if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		p.Line, _ = strconv.Atoi(m[1])
+	}
+	p.Message = strings.TrimPrefix(p.Message, "yaml: ")
+	p.Message = yamlLinePrefix.ReplaceAllString(p.Message, "")
+	return p
+}
+
+// findLessonFiles lists the lesson files at the root of a file system in
+// curriculum order, skipping the template and the pack settings
+func findLessonFiles(fsys fs.FS) ([]string, error) {
+	matches, err := fs.Glob(fsys, "*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find lesson files: %w", err)
+	}
+
+	var lessonFiles []string
+	for _, match := range matches {
+		if match != "template.yaml" && match != PackFile {
+			lessonFiles = append(lessonFiles, match)
+		}
+	}
+
+	if len(lessonFiles) == 0 {
+		return nil, fmt.Errorf("no lesson files found in %s", describeFS(fsys))
+	}
+
+	sort.Strings(lessonFiles)
+	return lessonFiles, nil
+}
+
+// checkLessonPrerequisites reports prerequisites that name no lesson or form a cycle
+func checkLessonPrerequisites(checks []*FileCheck) {
+	var lessons []*Lesson
+	for _, fc := range checks {
+		if fc.Lesson != nil {
+			lessons = append(lessons, fc.Lesson)
+		}
+	}
+
+	for _, fc := range checks {
+		if fc.Lesson == nil {
+			continue
+		}
+		if err := prerequisiteError(lessons, fc.Lesson); err != nil {
+			fc.Problems = append(fc.Problems, Problem{
+				File: fc.File, Line: fc.prerequisitesLine, Check: CheckPrereqs, Message: err.Error(),
+			})
+		}
+	}
+}
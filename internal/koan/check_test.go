@@ -0,0 +1,168 @@
+package koan
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// testKoan is a koan in YAML, indented to sit under koans:
+func testKoan(id, incomplete, answer string) string {
+	return fmt.Sprintf(`  - id: %q
+    description: "A koan"
+    question: "A question"
+    incomplete: %q
+    answer: %q
+    hints: ["One", "Two", "Three"]
+`, id, incomplete, answer)
+}
+
+// testLesson is a lesson in YAML with the given koans
+func testLesson(title string, koans ...string) string {
+	return fmt.Sprintf("title: %q\ndescription: \"A lesson\"\nkoans:\n%s", title, strings.Join(koans, ""))
+}
+
+// problemChecks lists the checks that reported problems, as check@line
+func problemChecks(fc *FileCheck) []string {
+	var checks []string
+	for _, p := range fc.Problems {
+		checks = append(checks, fmt.Sprintf("%s@%d", p.Check, p.Line))
+	}
+	for _, kc := range fc.Koans {
+		for _, p := range kc.Problems {
+			checks = append(checks, fmt.Sprintf("%s@%d", p.Check, p.Line))
+		}
+	}
+	return checks
+}
+
+func TestCheckLessonData(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		data    string
+		want    []string
+	}{
+		{"valid", DialectCron, testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "*")), nil},
+		{"not YAML", DialectCron, "title: [", []string{"yaml@1"}},
+		{"not a mapping", DialectCron, "- one\n- two\n", []string{"yaml@1"}},
+		{"no title", DialectCron, "description: \"A lesson\"\nkoans:\n" + testKoan("minutes_1", "__ * * * *", "*"), []string{"required-field@1"}},
+		{"no koans", DialectCron, "title: \"Empty\"\nkoans: []\n", []string{"required-field@1"}},
+		{"unknown lesson field", DialectCron, "titel: \"Typo\"\n" + testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "*")),
+			[]string{"unknown-field@1"}},
+		{"no placeholder", DialectCron, testLesson("Minutes", testKoan("minutes_1", "* * * * *", "*")), []string{"placeholder@7"}},
+		{"invalid answer", DialectCron, testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "60")), []string{"answer@8"}},
+		{"answer in the wrong dialect", DialectSystemd, testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "*")), []string{"answer@8"}},
+		{"OnCalendar answer", DialectSystemd, testLesson("Times", testKoan("times_1", "*-*-* __", "09:00")), nil},
+		{"duplicate ID in one file", DialectCron, testLesson("Minutes",
+			testKoan("minutes_1", "__ * * * *", "*"), testKoan("minutes_1", "__ * * * *", "*/5")), []string{"duplicate-id@10"}},
+		{"missing hints", DialectCron, strings.Replace(testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "*")), `, "Two", "Three"`, "", 1),
+			[]string{"hints@9"}},
+		{"difficulty out of range", DialectCron, "difficulty: 9\n" + testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "*")),
+			[]string{"metadata@1"}},
+	}
+	for _, tt := range tests {
+		fc := checkLessonData([]byte(tt.data), "01_minutes.yaml", tt.dialect)
+		if got := problemChecks(fc); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s: got problems %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckLessonFilesFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pack.yaml":          {Data: []byte("id_convention: lesson-prefix\n")},
+		"01_minutes.yaml":    {Data: []byte(testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "*")))},
+		"02_hours.yaml":      {Data: []byte("prerequisites: [minutes, days]\n" + testLesson("Hours", testKoan("hours_1", "0 __ * * *", "9"), testKoan("minutes_1", "0 __ * * *", "10")))},
+		"03_more_hours.yaml": {Data: []byte(testLesson("More Hours", testKoan("hours_2", "0 __ * * *", "9-17")))},
+		"notes.txt":          {Data: []byte("not a lesson")},
+	}
+	checks, err := CheckLessonFilesFS(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"01_minutes.yaml":    nil,
+		"02_hours.yaml":      {"prerequisites@1", "duplicate-id@11", "id-convention@11"},
+		"03_more_hours.yaml": {"id-convention@1"},
+	}
+	if len(checks) != len(want) {
+		t.Fatalf("checked %d files, want %d", len(checks), len(want))
+	}
+	for _, fc := range checks {
+		if got := problemChecks(fc); strings.Join(got, " ") != strings.Join(want[fc.File], " ") {
+			t.Errorf("%s: got problems %v, want %v", fc.File, got, want[fc.File])
+		}
+		if ok := len(want[fc.File]) == 0; fc.OK() != ok {
+			t.Errorf("%s: OK() = %v, want %v", fc.File, fc.OK(), ok)
+		}
+	}
+
+	if _, err := CheckLessonFilesFS(fstest.MapFS{"notes.txt": {Data: []byte("")}}); err == nil {
+		t.Error("CheckLessonFilesFS without lessons: no error")
+	}
+}
+
+func TestCheckReachability(t *testing.T) {
+	// Saturday October 17th 2026
+	from := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		dialect    Dialect
+		incomplete string
+		answer     string
+		minPerYear int
+		want       string
+	}{
+		{DialectCron, "0 0 __ * *", "1", 0, ""},
+		{DialectCron, "0 0 __ 2 *", "30", 0, "this expression never fires: 0 0 30 2 *"},
+		{DialectCron, "0 0 __ 2 *", "29", 0, ""},
+		{DialectCron, "0 0 __ 2 *", "29", 1, "0 0 29 2 * fires 0.25 times a year on average, fewer than 1; next in 2028"},
+		{DialectSystemd, "*-*-01 __", "00:00", 0, ""},
+		{DialectSystemd, "__ 12:00", "2020-01-01", 0, "this expression never fires after 17 Oct 2026: 2020-01-01 12:00"},
+		{DialectSystemd, "*-02-__", "30", 0, "this expression never fires after 17 Oct 2026: *-02-30"},
+		{DialectSystemd, "__ 12:00", "2100-01-01", 0, ""},
+		{DialectSystemd, "__ 12:00", "2100-01-01", 1, "2100-01-01 12:00 fires 0 times in the year from 17 Oct 2026, fewer than 1; next on 1 Jan 2100"},
+		{DialectSystemd, "*-__ 12:00", "01-01", 2, "*-01-01 12:00 fires once in the year from 17 Oct 2026, fewer than 2; next on 1 Jan 2027"},
+		{DialectSystemd, "*-*-01 __", "00:00", 12, ""},
+	}
+	for _, tt := range tests {
+		fc := checkLessonData([]byte(testLesson("Dates", testKoan("dates_1", tt.incomplete, tt.answer))), "01_dates.yaml", tt.dialect)
+		CheckReachability([]*FileCheck{fc}, tt.minPerYear, from)
+
+		var got string
+		for _, p := range fc.Koans[0].Problems {
+			if p.Check != CheckReach {
+				t.Fatalf("%s: unexpected problem %v", tt.incomplete, p)
+			}
+			got = p.Message
+		}
+		if !strings.HasPrefix(got, tt.want) || (tt.want == "" && got != "") {
+			t.Errorf("CheckReachability(%s with %s, %d) = %q, want %q", tt.incomplete, tt.answer, tt.minPerYear, got, tt.want)
+		}
+	}
+}
+
+func TestLoadAllLessonsFSAppliesChecks(t *testing.T) {
+	oneHint := func(data string) string { return strings.Replace(data, `, "Two", "Three"`, "", 1) }
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"titel: \"Typo\"\n" + testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "*")),
+			`01_minutes.yaml:1:1: unknown field "titel"`},
+		{oneHint(testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "*"))),
+			"01_minutes.yaml:9:12: koan must have exactly 3 hints, got 1"},
+		{oneHint(testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "60"))),
+			"01_minutes.yaml:8:13: answer '60' does not create a valid cron expression"},
+		{oneHint(testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "60"))),
+			"(and 1 more, see cronkoans validate)"},
+	}
+	for _, tt := range tests {
+		_, err := LoadAllLessonsFS(fstest.MapFS{"01_minutes.yaml": {Data: []byte(tt.data)}})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadAllLessonsFS = %v, want an error containing %q", err, tt.want)
+		}
+	}
+}
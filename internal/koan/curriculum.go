@@ -0,0 +1,233 @@
+package koan
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MinDifficulty and MaxDifficulty bound the difficulty of lessons and koans
+const (
+	MinDifficulty = 1
+	MaxDifficulty = 5
+)
+
+// Name is how other lessons refer to a lesson in their prerequisites: the
+// file name without its number and extension, so 03_ranges.yaml is "ranges"
+func (l *Lesson) Name() string {
+	name := strings.TrimSuffix(path.Base(filepath.ToSlash(l.Filename)), ".yaml")
+	if number, rest, ok := strings.Cut(name, "_"); ok && strings.Trim(number, "0123456789") == "" {
+		return rest
+	}
+	return name
+}
+
+// EstimatedMinutes is the lesson's estimated time, or else the sum of its koans' estimates
+func (l *Lesson) EstimatedMinutes() int {
+	if l.Minutes > 0 {
+		return l.Minutes
+	}
+	total := 0
+	for _, k := range l.Koans {
+		total += k.Minutes
+	}
+	return total
+}
+
+// KoanDifficulty is the koan's difficulty, or the lesson's when the koan has none
+func (l *Lesson) KoanDifficulty(k *Koan) int {
+	if k.Difficulty != 0 {
+		return k.Difficulty
+	}
+	return l.Difficulty
+}
+
+// HasTag reports whether a koan in the lesson is tagged, directly or through the lesson
+func (l *Lesson) HasTag(k *Koan, tag string) bool {
+	return hasTag(l.Tags, tag) || hasTag(k.Tags, tag)
+}
+
+// hasTag reports whether tags contains tag, ignoring case
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllTags lists the lesson's tags followed by any other tags of its koans
+func (l *Lesson) AllTags() []string {
+	seen := make(map[string]bool)
+	var tags []string
+	add := func(list []string) {
+		for _, t := range list {
+			if !seen[strings.ToLower(t)] {
+				seen[strings.ToLower(t)] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	add(l.Tags)
+	for _, k := range l.Koans {
+		add(k.Tags)
+	}
+	return tags
+}
+
+// FilterByTag keeps the koans that have the tag, dropping lessons left empty
+func FilterByTag(lessons []*Lesson, tag string) []*Lesson {
+	var filtered []*Lesson
+	for _, lesson := range lessons {
+		copied := *lesson
+		copied.Koans = nil
+		for i := range lesson.Koans {
+			if lesson.HasTag(&lesson.Koans[i], tag) {
+				copied.Koans = append(copied.Koans, lesson.Koans[i])
+			}
+		}
+		if len(copied.Koans) > 0 {
+			filtered = append(filtered, &copied)
+		}
+	}
+	return filtered
+}
+
+// FilterByID keeps only the koan with the given ID, in its lesson
+func FilterByID(lessons []*Lesson, id string) []*Lesson {
+	for _, lesson := range lessons {
+		for i := range lesson.Koans {
+			if lesson.Koans[i].ID == id {
+				copied := *lesson
+				copied.Koans = []Koan{lesson.Koans[i]}
+				return []*Lesson{&copied}
+			}
+		}
+	}
+	return nil
+}
+
+// FindLesson finds a lesson by name
+func FindLesson(lessons []*Lesson, name string) *Lesson {
+	for _, lesson := range lessons {
+		if lesson.Name() == name {
+			return lesson
+		}
+	}
+	return nil
+}
+
+// LessonComplete reports whether every koan in the lesson is completed
+func LessonComplete(lesson *Lesson, completed func(id string) bool) bool {
+	for _, k := range lesson.Koans {
+		if !completed(k.ID) {
+			return false
+		}
+	}
+	return true
+}
+
+// MissingPrerequisites lists the prerequisites of a lesson that are not
+// complete yet. Prerequisites that are not among lessons are ignored, so a
+// lesson filtered out by a tag does not lock the lessons that need it.
+func MissingPrerequisites(lessons []*Lesson, lesson *Lesson, completed func(id string) bool) []*Lesson {
+	var missing []*Lesson
+	for _, name := range lesson.Prerequisites {
+		if p := FindLesson(lessons, name); p != nil && !LessonComplete(p, completed) {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+// Unlocked reports whether every prerequisite of a lesson is complete
+func Unlocked(lessons []*Lesson, lesson *Lesson, completed func(id string) bool) bool {
+	return len(MissingPrerequisites(lessons, lesson, completed)) == 0
+}
+
+// NextLesson suggests what to learn next: the first unfinished lesson whose
+// prerequisites are complete, or nil when there is none
+func NextLesson(lessons []*Lesson, completed func(id string) bool) *Lesson {
+	for _, lesson := range lessons {
+		if !LessonComplete(lesson, completed) && Unlocked(lessons, lesson, completed) {
+			return lesson
+		}
+	}
+	return nil
+}
+
+// Titles joins the titles of lessons for messages
+func Titles(lessons []*Lesson) string {
+	titles := make([]string, len(lessons))
+	for i, lesson := range lessons {
+		titles[i] = lesson.Title
+	}
+	return strings.Join(titles, ", ")
+}
+
+// checkMetadata reports a difficulty or estimated time that is out of range
+func checkMetadata(difficulty, minutes int) error {
+	if difficulty != 0 && (difficulty < MinDifficulty || difficulty > MaxDifficulty) {
+		return fmt.Errorf("difficulty must be between %d and %d, got %d", MinDifficulty, MaxDifficulty, difficulty)
+	}
+	if minutes < 0 {
+		return fmt.Errorf("estimated_minutes cannot be negative, got %d", minutes)
+	}
+	return nil
+}
+
+// checkPrerequisites reports the first prerequisite that names no lesson, or
+// that makes a lesson depend on itself
+func checkPrerequisites(lessons []*Lesson) error {
+	for _, lesson := range lessons {
+		if err := prerequisiteError(lessons, lesson); err != nil {
+			return fmt.Errorf("%s: %w", lesson.Filename, err)
+		}
+	}
+	return nil
+}
+
+// prerequisiteError checks the prerequisites of one lesson
+func prerequisiteError(lessons []*Lesson, lesson *Lesson) error {
+	for _, name := range lesson.Prerequisites {
+		if FindLesson(lessons, name) == nil {
+			return fmt.Errorf("prerequisite %q is not a lesson (use the file name without number and .yaml)", name)
+		}
+	}
+	if cycle := prerequisiteCycle(lessons, lesson, nil); cycle != nil {
+		return fmt.Errorf("prerequisites form a cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// prerequisiteCycle follows prerequisites depth first from lesson and returns
+// the trail of names that leads back to the first lesson, if there is one
+func prerequisiteCycle(lessons []*Lesson, lesson *Lesson, trail []string) []string {
+	trail = append(trail, lesson.Name())
+	for _, name := range lesson.Prerequisites {
+		if name == trail[0] {
+			return append(trail, name)
+		}
+		if containsString(trail, name) {
+			continue // a cycle without the first lesson is reported for its own lessons
+		}
+		if p := FindLesson(lessons, name); p != nil {
+			if cycle := prerequisiteCycle(lessons, p, trail); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
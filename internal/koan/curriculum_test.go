@@ -0,0 +1,157 @@
+package koan
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLessonName(t *testing.T) {
+	tests := map[string]string{
+		"03_ranges.yaml":              "ranges",
+		"lessons/10_day_of_week.yaml": "day_of_week",
+		"extras.yaml":                 "extras",
+		"v2_ranges.yaml":              "v2_ranges",
+	}
+	for filename, want := range tests {
+		if got := (&Lesson{Filename: filename}).Name(); got != want {
+			t.Errorf("Name() of %s = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestLessonMetadata(t *testing.T) {
+	lesson := &Lesson{
+		Difficulty: 2,
+		Tags:       []string{"ranges"},
+		Koans: []Koan{
+			{ID: "a_1", Minutes: 2, Tags: []string{"DST"}},
+			{ID: "a_2", Minutes: 3, Difficulty: 4, Tags: []string{"dst", "steps"}},
+		},
+	}
+	if got := lesson.EstimatedMinutes(); got != 5 {
+		t.Errorf("EstimatedMinutes() = %d, want the koans' 5", got)
+	}
+	lesson.Minutes = 10
+	if got := lesson.EstimatedMinutes(); got != 10 {
+		t.Errorf("EstimatedMinutes() = %d, want the lesson's 10", got)
+	}
+	if got := lesson.KoanDifficulty(&lesson.Koans[0]); got != 2 {
+		t.Errorf("KoanDifficulty(a_1) = %d, want the lesson's 2", got)
+	}
+	if got := lesson.KoanDifficulty(&lesson.Koans[1]); got != 4 {
+		t.Errorf("KoanDifficulty(a_2) = %d, want 4", got)
+	}
+	if got := strings.Join(lesson.AllTags(), ","); got != "ranges,DST,steps" {
+		t.Errorf("AllTags() = %s, want ranges,DST,steps", got)
+	}
+	if !lesson.HasTag(&lesson.Koans[0], "dst") || !lesson.HasTag(&lesson.Koans[0], "Ranges") || lesson.HasTag(&lesson.Koans[0], "steps") {
+		t.Error("HasTag does not combine the lesson's and the koan's tags")
+	}
+}
+
+func TestFilterByTag(t *testing.T) {
+	lessons := []*Lesson{
+		{Title: "Minutes", Koans: []Koan{{ID: "minutes_1", Tags: []string{"steps"}}, {ID: "minutes_2"}}},
+		{Title: "Hours", Koans: []Koan{{ID: "hours_1"}}},
+		{Title: "Steps", Tags: []string{"steps"}, Koans: []Koan{{ID: "steps_1"}, {ID: "steps_2"}}},
+	}
+	filtered := FilterByTag(lessons, "STEPS")
+	var ids []string
+	for _, lesson := range filtered {
+		for _, k := range lesson.Koans {
+			ids = append(ids, k.ID)
+		}
+	}
+	if got := strings.Join(ids, ","); got != "minutes_1,steps_1,steps_2" {
+		t.Errorf("FilterByTag = %s, want minutes_1,steps_1,steps_2", got)
+	}
+	if len(lessons[0].Koans) != 2 {
+		t.Error("FilterByTag changed the lessons it filtered")
+	}
+
+	if byID := FilterByID(lessons, "steps_2"); len(byID) != 1 || byID[0].Title != "Steps" || len(byID[0].Koans) != 1 {
+		t.Errorf("FilterByID(steps_2) = %v", byID)
+	}
+	if byID := FilterByID(lessons, "nope_1"); byID != nil {
+		t.Errorf("FilterByID(nope_1) = %v, want nil", byID)
+	}
+}
+
+func TestPrerequisites(t *testing.T) {
+	minutes := &Lesson{Title: "Minutes", Filename: "01_minutes.yaml", Koans: []Koan{{ID: "minutes_1"}, {ID: "minutes_2"}}}
+	hours := &Lesson{Title: "Hours", Filename: "02_hours.yaml", Prerequisites: []string{"minutes"}, Koans: []Koan{{ID: "hours_1"}}}
+	days := &Lesson{Title: "Days", Filename: "03_days.yaml", Prerequisites: []string{"minutes", "hours", "steps"}, Koans: []Koan{{ID: "days_1"}}}
+	lessons := []*Lesson{minutes, hours, days}
+
+	tests := []struct {
+		completed string
+		missing   string // titles of the lessons days is missing
+		next      string
+	}{
+		{"", "Minutes, Hours", "Minutes"},
+		{"minutes_1", "Minutes, Hours", "Minutes"},
+		{"minutes_1 minutes_2", "Hours", "Hours"},
+		{"minutes_1 minutes_2 hours_1", "", "Days"},
+		{"minutes_1 minutes_2 hours_1 days_1", "", ""},
+	}
+	for _, tt := range tests {
+		done := make(map[string]bool)
+		for _, id := range strings.Fields(tt.completed) {
+			done[id] = true
+		}
+		completed := func(id string) bool { return done[id] }
+
+		// steps is not among the lessons, so it does not lock days
+		if got := Titles(MissingPrerequisites(lessons, days, completed)); got != tt.missing {
+			t.Errorf("with %q completed, days is missing %q, want %q", tt.completed, got, tt.missing)
+		}
+		if unlocked := Unlocked(lessons, days, completed); unlocked != (tt.missing == "") {
+			t.Errorf("with %q completed, Unlocked(days) = %v", tt.completed, unlocked)
+		}
+		next := NextLesson(lessons, completed)
+		if (next == nil && tt.next != "") || (next != nil && next.Title != tt.next) {
+			t.Errorf("with %q completed, NextLesson = %v, want %q", tt.completed, next, tt.next)
+		}
+	}
+}
+
+func TestCheckPrerequisites(t *testing.T) {
+	lesson := func(filename string, prerequisites ...string) *Lesson {
+		return &Lesson{Filename: filename, Prerequisites: prerequisites}
+	}
+	tests := []struct {
+		name    string
+		lessons []*Lesson
+		want    string
+	}{
+		{"valid", []*Lesson{lesson("01_minutes.yaml"), lesson("02_hours.yaml", "minutes")}, ""},
+		{"unknown lesson", []*Lesson{lesson("01_minutes.yaml", "02_hours")}, `01_minutes.yaml: prerequisite "02_hours" is not a lesson`},
+		{"itself", []*Lesson{lesson("01_minutes.yaml", "minutes")}, "prerequisites form a cycle: minutes -> minutes"},
+		{"cycle", []*Lesson{lesson("01_a.yaml", "b"), lesson("02_b.yaml", "c"), lesson("03_c.yaml", "a")},
+			"01_a.yaml: prerequisites form a cycle: a -> b -> c -> a"},
+	}
+	for _, tt := range tests {
+		err := checkPrerequisites(tt.lessons)
+		if (tt.want == "" && err != nil) || (tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want))) {
+			t.Errorf("%s: checkPrerequisites = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	if err := checkMetadata(6, 0); err == nil || !strings.Contains(err.Error(), "difficulty must be between 1 and 5") {
+		t.Errorf("checkMetadata(6, 0) = %v", err)
+	}
+	if err := checkMetadata(0, -1); err == nil || !strings.Contains(err.Error(), "estimated_minutes cannot be negative") {
+		t.Errorf("checkMetadata(0, -1) = %v", err)
+	}
+}
+
+func TestLoadAllLessonsFSChecksPrerequisites(t *testing.T) {
+	fsys := fstest.MapFS{
+		"01_minutes.yaml": {Data: []byte("prerequisites: [hours]\n" + testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "*")))},
+		"02_hours.yaml":   {Data: []byte("prerequisites: [minutes]\n" + testLesson("Hours", testKoan("hours_1", "0 __ * * *", "9")))},
+	}
+	if _, err := LoadAllLessonsFS(fsys); err == nil || !strings.Contains(err.Error(), "prerequisites form a cycle: minutes -> hours -> minutes") {
+		t.Errorf("LoadAllLessonsFS with a cycle = %v", err)
+	}
+}
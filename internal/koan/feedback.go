@@ -0,0 +1,319 @@
+package koan
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+	"github.com/dwildt/cronkoans/pkg/oncalendar"
+)
+
+// Mistake classifies what is wrong with an incorrect answer
+type Mistake string
+
+const (
+	MistakeAuthored          Mistake = "authored"
+	MistakeInvalidSyntax     Mistake = "invalid_syntax"
+	MistakeEquivalentForm    Mistake = "equivalent_form"
+	MistakeZeroBased         Mistake = "zero_based"
+	MistakeOffByOne          Mistake = "off_by_one"
+	MistakeStepWrongField    Mistake = "step_wrong_field"
+	MistakeDifferentSchedule Mistake = "different_schedule"
+)
+
+// FeedbackEntry is an authored message shown for a specific wrong answer
+type FeedbackEntry struct {
+	Answer  string `yaml:"answer"`
+	Message string `yaml:"message"`
+}
+
+// Feedback explains why an answer was not accepted
+type Feedback struct {
+	Kind    Mistake
+	Message string
+}
+
+// AnalyzeAnswer compares a wrong answer with the expected one and explains the difference
+func (k *Koan) AnalyzeAnswer(userAnswer string) Feedback {
+	return k.analyzeAnswer(userAnswer, time.Now())
+}
+
+// SameSchedule reports whether an answer fires at exactly the same times as the
+// expected answer, however it is written
+func (k *Koan) SameSchedule(userAnswer string) bool {
+	answer := strings.TrimSpace(userAnswer)
+	if k.Dialect == DialectSystemd {
+		got, err := oncalendar.Parse(replaceBlank(k.Incomplete, answer))
+		if err != nil {
+			return false
+		}
+		want, err := oncalendar.Parse(k.CompleteCronExpression())
+		if err != nil {
+			return false
+		}
+		return got.Equal(want)
+	}
+
+	got, err := cronexpr.Parse(replaceBlank(k.Incomplete, answer))
+	if err != nil {
+		return false
+	}
+	want, err := cronexpr.Parse(k.CompleteCronExpression())
+	if err != nil {
+		return false
+	}
+	return got.Equal(want)
+}
+
+// analyzeAnswer is AnalyzeAnswer with an explicit reference time for fire time comparisons
+func (k *Koan) analyzeAnswer(userAnswer string, now time.Time) Feedback {
+	answer := normalizeAnswer(userAnswer)
+
+	// Authored feedback always wins
+	for _, entry := range k.Feedback {
+		if normalizeAnswer(entry.Answer) == answer {
+			return Feedback{Kind: MistakeAuthored, Message: entry.Message}
+		}
+	}
+
+	// Time zone names are case sensitive, so OnCalendar answers keep their case
+	if k.Dialect == DialectSystemd {
+		return k.analyzeCalendarAnswer(strings.TrimSpace(userAnswer), now)
+	}
+
+	expected := k.CompleteCronExpression()
+	blankField := blankFieldIndex(k.Incomplete)
+
+	// Learners sometimes type the whole expression instead of just the blank
+	complete := replaceBlank(k.Incomplete, answer)
+	typedWhole := blankField >= 0 && len(strings.Fields(answer)) > 1 && len(strings.Fields(answer)) == len(strings.Fields(expected))
+	if typedWhole {
+		complete = answer
+		blankField = -1
+	}
+
+	if err := cronexpr.Validate(complete); err != nil {
+		if fb, ok := zeroBasedMistake(answer, blankField); ok {
+			return fb
+		}
+		return Feedback{
+			Kind:    MistakeInvalidSyntax,
+			Message: fmt.Sprintf("'%s' is not a valid cron expression: %v", complete, err),
+		}
+	}
+
+	got, err := cronexpr.Parse(complete)
+	if err != nil {
+		return Feedback{Kind: MistakeInvalidSyntax, Message: err.Error()}
+	}
+	want, err := cronexpr.Parse(expected)
+	if err != nil {
+		return Feedback{Kind: MistakeDifferentSchedule, Message: "Not quite. Try again!"}
+	}
+
+	if got.Equal(want) {
+		if typedWhole {
+			return Feedback{
+				Kind:    MistakeEquivalentForm,
+				Message: fmt.Sprintf("That schedule is right, but only type the part that replaces __ in '%s'.", k.Incomplete),
+			}
+		}
+		if describeForm(answer) == describeForm(k.Answer) {
+			return Feedback{
+				Kind:    MistakeEquivalentForm,
+				Message: fmt.Sprintf("'%s' runs at the same times, but this koan expects a different way of writing it.", answer),
+			}
+		}
+		return Feedback{
+			Kind: MistakeEquivalentForm,
+			Message: fmt.Sprintf("'%s' runs at the same times, but this koan asks for it written as %s.",
+				answer, describeForm(k.Answer)),
+		}
+	}
+
+	if fb, ok := stepWrongField(complete, expected); ok {
+		return fb
+	}
+
+	if blankField >= 0 {
+		if fb, ok := offByOneMistake(answer, k.Answer, blankField); ok {
+			return fb
+		}
+	}
+
+	return Feedback{Kind: MistakeDifferentSchedule, Message: describeScheduleDifference(got, want, now)}
+}
+
+// analyzeCalendarAnswer explains a wrong answer to a koan in the systemd
+// dialect, whose fields are not separated the way cron's are
+func (k *Koan) analyzeCalendarAnswer(answer string, now time.Time) Feedback {
+	complete := replaceBlank(k.Incomplete, answer)
+	got, err := oncalendar.Parse(complete)
+	if err != nil {
+		return Feedback{
+			Kind:    MistakeInvalidSyntax,
+			Message: fmt.Sprintf("'%s' is not a valid OnCalendar expression: %v", complete, err),
+		}
+	}
+	want, err := oncalendar.Parse(k.CompleteCronExpression())
+	if err != nil {
+		return Feedback{Kind: MistakeDifferentSchedule, Message: "Not quite. Try again!"}
+	}
+
+	if got.Equal(want) {
+		return Feedback{
+			Kind: MistakeEquivalentForm,
+			Message: fmt.Sprintf("'%s' runs at the same times (systemd reads it as %s), but this koan expects a different way of writing it.",
+				answer, got),
+		}
+	}
+
+	return Feedback{Kind: MistakeDifferentSchedule, Message: describeScheduleDifference(got, want, now)}
+}
+
+// blankFieldIndex returns the position of the field containing the __ placeholder.
+// It returns -1 for a placeholder that stands for the whole expression, and
+// for an expression without one.
+func blankFieldIndex(incomplete string) int {
+	fields := strings.Fields(incomplete)
+	if len(fields) < 2 {
+		return -1
+	}
+	for i, field := range fields {
+		if strings.Contains(field, "__") {
+			return i
+		}
+	}
+	return -1
+}
+
+// describeForm names the syntax used by an answer, with an article
+func describeForm(answer string) string {
+	switch {
+	case strings.HasPrefix(answer, "@"):
+		return "a special string"
+	case strings.Contains(answer, "/"):
+		return "a step value"
+	case strings.Contains(answer, ","):
+		return "a list"
+	case strings.Contains(answer, "-"):
+		return "a range"
+	case answer == "*":
+		return "a wildcard"
+	default:
+		return "a single value"
+	}
+}
+
+// zeroBasedMistake detects answers that count days or months from 0 instead of 1
+func zeroBasedMistake(answer string, field int) (Feedback, bool) {
+	if field != 2 && field != 3 {
+		return Feedback{}, false
+	}
+	// Expand with a lower bound of 0 so that answers containing 0 can be inspected
+	f := cronexpr.Field(field)
+	got, err := cronexpr.ExpandField(answer, 0, f.Max())
+	if err != nil {
+		return Feedback{}, false
+	}
+	// Only an answer that names 0 counts from 0. One that is merely a day or
+	// month early, such as 14 for 15, is left to the checks that follow.
+	if got&1 != 0 {
+		unit := "Days of the month"
+		if field == 3 {
+			unit = "Months (January is 1)"
+		}
+		return Feedback{
+			Kind:    MistakeZeroBased,
+			Message: fmt.Sprintf("%s are numbered from 1, not 0. Check the %s field again.", unit, f),
+		}, true
+	}
+	return Feedback{}, false
+}
+
+// offByOneMistake detects ranges whose start or end is one away from the expected bound
+func offByOneMistake(answer, expected string, field int) (Feedback, bool) {
+	if !strings.Contains(answer, "-") || !strings.Contains(expected, "-") {
+		return Feedback{}, false
+	}
+	f := cronexpr.Field(field)
+	got, err := cronexpr.ExpandField(answer, f.Min(), f.Max())
+	if err != nil {
+		return Feedback{}, false
+	}
+	want, err := cronexpr.ExpandField(expected, f.Min(), f.Max())
+	if err != nil {
+		return Feedback{}, false
+	}
+
+	diff := got ^ want
+	if diff == 0 || diff&(diff-1) != 0 {
+		return Feedback{}, false
+	}
+	return Feedback{
+		Kind: MistakeOffByOne,
+		Message: fmt.Sprintf("Your %s range is off by one. Cron ranges include both ends, so check where it starts and stops.",
+			f),
+	}, true
+}
+
+// stepWrongField detects a step value placed in a different field than expected
+func stepWrongField(got, want string) (Feedback, bool) {
+	gotFields := strings.Fields(got)
+	wantFields := strings.Fields(want)
+	if len(gotFields) != 5 || len(wantFields) != 5 {
+		return Feedback{}, false
+	}
+
+	gotStep, wantStep := -1, -1
+	for i := 0; i < 5; i++ {
+		if strings.Contains(gotFields[i], "/") {
+			gotStep = i
+		}
+		if strings.Contains(wantFields[i], "/") {
+			wantStep = i
+		}
+	}
+	if gotStep == -1 || wantStep == -1 || gotStep == wantStep {
+		return Feedback{}, false
+	}
+	return Feedback{
+		Kind: MistakeStepWrongField,
+		Message: fmt.Sprintf("The step is in the %s field, but this schedule repeats by %s.",
+			cronexpr.Field(gotStep), cronexpr.Field(wantStep)),
+	}, true
+}
+
+// schedule is what cron expressions and OnCalendar expressions have in common
+type schedule interface {
+	Next(after time.Time) (time.Time, bool)
+}
+
+// describeScheduleDifference finds the first fire time that differs between two schedules
+func describeScheduleDifference(got, want schedule, now time.Time) string {
+	const maxSteps = 10000
+
+	gotNext, gotOK := got.Next(now)
+	wantNext, wantOK := want.Next(now)
+
+	for i := 0; i < maxSteps && (gotOK || wantOK); i++ {
+		switch {
+		case !wantOK || (gotOK && gotNext.Before(wantNext)):
+			return fmt.Sprintf("Your answer is valid but describes a different schedule: it also fires at %s.",
+				formatFireTime(gotNext))
+		case !gotOK || wantNext.Before(gotNext):
+			return fmt.Sprintf("Your answer is valid but describes a different schedule: it misses the run at %s.",
+				formatFireTime(wantNext))
+		}
+		gotNext, gotOK = got.Next(gotNext)
+		wantNext, wantOK = want.Next(wantNext)
+	}
+
+	return "Your answer is valid but describes a different schedule."
+}
+
+// formatFireTime formats a fire time for feedback messages
+func formatFireTime(t time.Time) string {
+	return t.Format("15:04 on Monday, Jan 2 2006")
+}
@@ -0,0 +1,182 @@
+package koan
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeAnswer(t *testing.T) {
+	// Saturday October 17th 2026, at noon
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		koan    Koan
+		answer  string
+		kind    Mistake
+		message string
+	}{
+		{
+			name:   "authored feedback wins",
+			koan:   Koan{Incomplete: "0 9 * * __", Answer: "1-5", Feedback: []FeedbackEntry{{Answer: "Mon-Fri", Message: "Use numbers"}}},
+			answer: "mon-fri", kind: MistakeAuthored, message: "Use numbers",
+		},
+		{
+			name: "invalid syntax",
+			koan: Koan{Incomplete: "0 __ * * *", Answer: "9"}, answer: "25",
+			kind: MistakeInvalidSyntax, message: "'0 25 * * *' is not a valid cron expression",
+		},
+		{
+			name: "day counted from 0",
+			koan: Koan{Incomplete: "0 0 __ * *", Answer: "1"}, answer: "0",
+			kind: MistakeZeroBased, message: "Days of the month are numbered from 1",
+		},
+		{
+			name: "months shifted down to 0",
+			koan: Koan{Incomplete: "0 0 1 __ *", Answer: "1-6"}, answer: "0-5",
+			kind: MistakeZeroBased, message: "Months (January is 1)",
+		},
+		{
+			name: "a day early is not zero-based",
+			koan: Koan{Incomplete: "0 0 __ * *", Answer: "15"}, answer: "14",
+			kind: MistakeDifferentSchedule, message: "it also fires at 00:00 on Saturday, Nov 14 2026",
+		},
+		{
+			name: "range that stops an hour early",
+			koan: Koan{Incomplete: "0 __ * * *", Answer: "9-17"}, answer: "9-16",
+			kind: MistakeOffByOne, message: "Your hour range is off by one",
+		},
+		{
+			name: "step in the wrong field",
+			koan: Koan{Incomplete: "__", Answer: "*/15 * * * *"}, answer: "0 */15 * * *",
+			kind: MistakeStepWrongField, message: "The step is in the hour field, but this schedule repeats by minute",
+		},
+		{
+			name: "whole-expression koan in another form",
+			koan: Koan{Incomplete: "__", Answer: "*/30 * * * *"}, answer: "0,30 * * * *",
+			kind: MistakeEquivalentForm, message: "asks for it written as a step value",
+		},
+		{
+			name: "same schedule in another form",
+			koan: Koan{Incomplete: "__ * * * *", Answer: "*/30"}, answer: "0,30",
+			kind: MistakeEquivalentForm, message: "asks for it written as a step value",
+		},
+		{
+			name: "same schedule in the same form",
+			koan: Koan{Incomplete: "__ * * * *", Answer: "0,30"}, answer: "30,0",
+			kind: MistakeEquivalentForm, message: "expects a different way of writing it",
+		},
+		{
+			name: "whole expression typed",
+			koan: Koan{Incomplete: "0 __ * * *", Answer: "9"}, answer: "0 9 * * *",
+			kind: MistakeEquivalentForm, message: "only type the part that replaces __",
+		},
+		{
+			name: "different schedule",
+			koan: Koan{Incomplete: "0 __ * * *", Answer: "9"}, answer: "10",
+			kind: MistakeDifferentSchedule, message: "it misses the run at 09:00 on Sunday, Oct 18 2026",
+		},
+		{
+			name: "OnCalendar syntax",
+			koan: Koan{Incomplete: "*-*-* __", Answer: "09:00:00", Dialect: DialectSystemd}, answer: "9",
+			kind: MistakeInvalidSyntax, message: "is not a valid OnCalendar expression",
+		},
+		{
+			name: "OnCalendar in another form",
+			koan: Koan{Incomplete: "*-*-* __", Answer: "09:00:00", Dialect: DialectSystemd}, answer: "9:00",
+			kind: MistakeEquivalentForm, message: "systemd reads it as *-*-* 09:00:00",
+		},
+		{
+			name: "OnCalendar different schedule",
+			koan: Koan{Incomplete: "*-*-* __", Answer: "09:00:00", Dialect: DialectSystemd}, answer: "10:00",
+			kind: MistakeDifferentSchedule, message: "it misses the run at 09:00 on Sunday, Oct 18 2026",
+		},
+	}
+	for _, tt := range tests {
+		fb := tt.koan.analyzeAnswer(tt.answer, now)
+		if fb.Kind != tt.kind || !strings.Contains(fb.Message, tt.message) {
+			t.Errorf("%s: analyzeAnswer(%q) = %s %q, want %s containing %q", tt.name, tt.answer, fb.Kind, fb.Message, tt.kind, tt.message)
+		}
+	}
+}
+
+func TestStepWrongField(t *testing.T) {
+	tests := []struct {
+		got, want string
+		ok        bool
+	}{
+		{"0 */2 * * *", "*/2 * * * *", true},
+		{"*/5 * * * *", "0 0 */5 * *", true},
+		{"*/5 * * * *", "*/10 * * * *", false},
+		{"0 9 * * *", "*/10 * * * *", false},
+		{"*/5 * * *", "0 */5 * * *", false},
+	}
+	for _, tt := range tests {
+		fb, ok := stepWrongField(tt.got, tt.want)
+		if ok != tt.ok || (ok && fb.Kind != MistakeStepWrongField) {
+			t.Errorf("stepWrongField(%q, %q) = %v, %v, want %v", tt.got, tt.want, fb, ok, tt.ok)
+		}
+	}
+}
+
+func TestOffByOneMistake(t *testing.T) {
+	tests := []struct {
+		answer, expected string
+		field            int
+		ok               bool
+	}{
+		{"9-16", "9-17", 1, true},
+		{"8-17", "9-17", 1, true},
+		{"1-4", "1-5", 4, true},
+		{"9-17", "9-17", 1, false},
+		{"8-18", "9-17", 1, false},
+		{"16", "9-17", 1, false},
+		{"10-20", "9-17", 1, false},
+	}
+	for _, tt := range tests {
+		fb, ok := offByOneMistake(tt.answer, tt.expected, tt.field)
+		if ok != tt.ok || (ok && fb.Kind != MistakeOffByOne) {
+			t.Errorf("offByOneMistake(%q, %q, %d) = %v, %v, want %v", tt.answer, tt.expected, tt.field, fb, ok, tt.ok)
+		}
+	}
+}
+
+func TestZeroBasedMistake(t *testing.T) {
+	tests := []struct {
+		answer string
+		field  int
+		ok     bool
+	}{
+		{"0", 2, true},
+		{"0-30", 2, true},
+		{"0,6", 3, true},
+		{"14", 2, false},
+		{"1-5", 3, false},
+		{"0", 1, false}, // hours do start at 0
+		{"nope", 2, false},
+	}
+	for _, tt := range tests {
+		fb, ok := zeroBasedMistake(tt.answer, tt.field)
+		if ok != tt.ok || (ok && fb.Kind != MistakeZeroBased) {
+			t.Errorf("zeroBasedMistake(%q, %d) = %v, %v, want %v", tt.answer, tt.field, fb, ok, tt.ok)
+		}
+	}
+}
+
+func TestBlankFieldIndex(t *testing.T) {
+	tests := map[string]int{
+		"__ * * * *":   0,
+		"0 __ * * *":   1,
+		"0 9 __ * *":   2,
+		"0 0 1 __ *":   3,
+		"0 9 * * __":   4,
+		"0 9 * * 1-__": 4,
+		"__":           -1,
+		"0 9 * * *":    -1,
+	}
+	for incomplete, want := range tests {
+		if got := blankFieldIndex(incomplete); got != want {
+			t.Errorf("blankFieldIndex(%q) = %d, want %d", incomplete, got, want)
+		}
+	}
+}
@@ -6,24 +6,41 @@ import (
 
 // Koan represents a single learning exercise
 type Koan struct {
-	ID          string   `yaml:"id"`
-	Description string   `yaml:"description"`
-	Question    string   `yaml:"question"`
-	Incomplete  string   `yaml:"incomplete"`
-	Answer      string   `yaml:"answer"`
-	Hints       []string `yaml:"hints"`
-	Explanation string   `yaml:"explanation"`
+	ID          string          `yaml:"id"`
+	Description string          `yaml:"description"`
+	Question    string          `yaml:"question"`
+	Incomplete  string          `yaml:"incomplete"`
+	Answer      string          `yaml:"answer"`
+	Hints       []string        `yaml:"hints"`
+	Explanation string          `yaml:"explanation"`
+	Feedback    []FeedbackEntry `yaml:"feedback,omitempty"`
+	Preview     *bool           `yaml:"preview,omitempty"`           // nil inherits the lesson setting
+	Difficulty  int             `yaml:"difficulty,omitempty"`        // 1 to 5; 0 inherits the lesson's
+	Tags        []string        `yaml:"tags,omitempty"`              // in addition to the lesson's tags
+	Minutes     int             `yaml:"estimated_minutes,omitempty"` // time to solve it
+	Line        int             `yaml:"-"`                           // Line of the koan in its lesson file
+	Dialect     Dialect         `yaml:"-"`                           // Syntax of the expression, from the pack
 }
 
 // Lesson represents a collection of related koans
 type Lesson struct {
-	Title       string  `yaml:"title"`
-	Description string  `yaml:"description"`
-	Koans       []Koan  `yaml:"koans"`
-	Filename    string  `yaml:"-"` // Not from YAML, set programmatically
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	Koans       []Koan `yaml:"koans"`
+	Preview     *bool  `yaml:"preview,omitempty"`   // Set to false for exam-style lessons
+	Prefix      string `yaml:"id_prefix,omitempty"` // Prefix of koan IDs, for the lesson-prefix convention
+
+	// Optional metadata for the curriculum
+	Difficulty    int      `yaml:"difficulty,omitempty"`        // 1 (easiest) to 5
+	Tags          []string `yaml:"tags,omitempty"`              // topics, such as dst or ranges
+	Minutes       int      `yaml:"estimated_minutes,omitempty"` // time for the whole lesson
+	Prerequisites []string `yaml:"prerequisites,omitempty"`     // names of lessons to finish first
+
+	Filename string `yaml:"-"` // Not from YAML, set programmatically
 }
 
-// CompleteCronExpression returns the complete cron expression with the answer filled in
+// CompleteCronExpression returns the complete expression with the answer filled in,
+// which is an OnCalendar expression in systemd packs
 func (k *Koan) CompleteCronExpression() string {
 	return replaceBlank(k.Incomplete, k.Answer)
 }
@@ -50,11 +67,11 @@ func (k *Koan) HasMoreHints(currentLevel int) bool {
 
 // KoanResult represents the result of attempting a koan
 type KoanResult struct {
-	Koan        *Koan
-	UserAnswer  string
-	IsCorrect   bool
-	HintsUsed   int
-	Attempts    int
+	Koan       *Koan
+	UserAnswer string
+	IsCorrect  bool
+	HintsUsed  int
+	Attempts   int
 }
 
 // String provides a formatted representation of the koan
@@ -0,0 +1,135 @@
+package koan
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// Layer is one set of lesson files in an Overlay
+type Layer struct {
+	Path string // where the files come from, used to show file names to the user
+	FS   fs.FS
+}
+
+// DirLayer is a layer for a lessons directory on disk
+func DirLayer(dir string) Layer {
+	return Layer{Path: dir, FS: os.DirFS(dir)}
+}
+
+// Overlay is a read-only fs.FS of lesson files made of layers stacked in order.
+// A file in a later layer replaces the file with the same name in earlier ones,
+// so a user directory can override a bundled lesson by reusing its filename.
+type Overlay struct {
+	layers []Layer
+}
+
+// NewOverlay stacks the layers, the last one on top
+func NewOverlay(layers ...Layer) *Overlay {
+	return &Overlay{layers: layers}
+}
+
+// LessonDirs is an overlay of lesson directories on disk
+func LessonDirs(dirs ...string) *Overlay {
+	o := &Overlay{}
+	for _, dir := range dirs {
+		o.layers = append(o.layers, DirLayer(dir))
+	}
+	return o
+}
+
+// Layers returns the layers, bottom first
+func (o *Overlay) Layers() []Layer {
+	return o.layers
+}
+
+// Open opens the named file from the topmost layer that has it
+func (o *Overlay) Open(name string) (fs.File, error) {
+	if name == "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("use ReadDir to list an overlay")}
+	}
+	l, ok := o.find(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return l.FS.Open(name)
+}
+
+// ReadDir lists the files of every layer, each name once
+func (o *Overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	byName := make(map[string]fs.DirEntry)
+	found := false
+	for _, l := range o.layers {
+		entries, err := fs.ReadDir(l.FS, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		for _, e := range entries {
+			byName[e.Name()] = e
+		}
+	}
+	if !found && len(o.layers) > 0 {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(byName))
+	for _, e := range byName {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// Path returns where the named file comes from, for messages
+func (o *Overlay) Path(name string) string {
+	l, ok := o.find(name)
+	if !ok || l.Path == "" {
+		return name
+	}
+	return filepath.Join(l.Path, filepath.FromSlash(name))
+}
+
+// String lists the layers, for messages
+func (o *Overlay) String() string {
+	s := ""
+	for i, l := range o.layers {
+		if i > 0 {
+			s += ", "
+		}
+		s += l.Path
+	}
+	return s
+}
+
+// find returns the topmost layer that contains the named file
+func (o *Overlay) find(name string) (Layer, bool) {
+	for i := len(o.layers) - 1; i >= 0; i-- {
+		if _, err := fs.Stat(o.layers[i].FS, name); err == nil {
+			return o.layers[i], true
+		}
+	}
+	return Layer{}, false
+}
+
+// displayPath returns the name to show for a file in fsys
+func displayPath(fsys fs.FS, name string) string {
+	if p, ok := fsys.(interface{ Path(string) string }); ok {
+		return p.Path(name)
+	}
+	return path.Clean(name)
+}
+
+// describeFS names a file system in messages
+func describeFS(fsys fs.FS) string {
+	if s, ok := fsys.(interface{ String() string }); ok {
+		return s.String()
+	}
+	return "the lessons"
+}
@@ -0,0 +1,281 @@
+package koan
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+	"github.com/dwildt/cronkoans/pkg/oncalendar"
+)
+
+// PackFile is the name of the pack manifest, which is optional in a lessons directory
+const PackFile = "pack.yaml"
+
+// Dialect is the schedule syntax that a pack's koans are written in
+type Dialect string
+
+const (
+	// DialectCron is the five-field crontab syntax, the default
+	DialectCron Dialect = "cron"
+	// DialectSystemd is the calendar syntax of OnCalendar= in systemd timers
+	DialectSystemd Dialect = "systemd"
+)
+
+// Dialects lists every dialect cronkoans can check answers in
+var Dialects = []Dialect{DialectCron, DialectSystemd}
+
+// packNamePattern is what pack names look like, since they name directories and progress files
+var packNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// IDConvention is a naming rule that koan IDs in a lesson pack must follow
+type IDConvention string
+
+const (
+	// IDConventionNone accepts any koan ID
+	IDConventionNone IDConvention = "none"
+	// IDConventionLessonPrefix requires IDs of the form <prefix>_<number>,
+	// with one prefix per lesson that no other lesson uses
+	IDConventionLessonPrefix IDConvention = "lesson-prefix"
+)
+
+// Pack is the manifest of a lesson pack and the settings shared by all its lessons.
+// Only packs that are installed need a name and a version.
+type Pack struct {
+	Name         string       `yaml:"name,omitempty"`
+	Version      string       `yaml:"version,omitempty"`
+	Author       string       `yaml:"author,omitempty"`
+	Description  string       `yaml:"description,omitempty"`
+	Dialect      Dialect      `yaml:"dialect,omitempty"`
+	MinVersion   string       `yaml:"min_cronkoans_version,omitempty"`
+	IDConvention IDConvention `yaml:"id_convention,omitempty"`
+	Filename     string       `yaml:"-"` // Empty when the directory has no pack.yaml
+}
+
+// LoadPack reads pack.yaml from a lessons directory.
+// A directory without one gets the default settings.
+func LoadPack(lessonsDir string) (*Pack, error) {
+	return LoadPackFS(os.DirFS(lessonsDir))
+}
+
+// LoadPackFS reads pack.yaml from the root of a file system
+func LoadPackFS(fsys fs.FS) (*Pack, error) {
+	filename := displayPath(fsys, PackFile)
+	data, err := fs.ReadFile(fsys, PackFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Pack{Dialect: DialectCron, IDConvention: IDConventionNone}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+
+	var pack Pack
+	if err := yaml.Unmarshal(data, &pack); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML from %s: %w", filename, err)
+	}
+	pack.Filename = filename
+
+	// The name picks the pack's progress file, so it must not reach outside it
+	if pack.Name != "" {
+		if err := CheckPackName(pack.Name); err != nil {
+			return nil, fmt.Errorf("%w in %s", err, filename)
+		}
+	}
+	if pack.Dialect == "" {
+		pack.Dialect = DialectCron
+	}
+	if !pack.Dialect.Known() {
+		return nil, fmt.Errorf("unknown dialect %q in %s (want %s)", pack.Dialect, filename, joinDialects())
+	}
+	for _, v := range []string{pack.Version, pack.MinVersion} {
+		if _, err := parseVersion(v); v != "" && err != nil {
+			return nil, fmt.Errorf("%w in %s", err, filename)
+		}
+	}
+
+	switch pack.IDConvention {
+	case "":
+		pack.IDConvention = IDConventionNone
+	case IDConventionNone, IDConventionLessonPrefix:
+	default:
+		return nil, fmt.Errorf("invalid id_convention %q in %s (want %s or %s)",
+			pack.IDConvention, filename, IDConventionNone, IDConventionLessonPrefix)
+	}
+
+	return &pack, nil
+}
+
+// CheckManifest reports whether the pack has what an installable pack needs
+func (p *Pack) CheckManifest() error {
+	switch {
+	case p.Filename == "":
+		return fmt.Errorf("no %s manifest found", PackFile)
+	case p.Name == "":
+		return fmt.Errorf("%s: a pack needs a name", p.Filename)
+	case CheckPackName(p.Name) != nil:
+		return fmt.Errorf("%s: %w", p.Filename, CheckPackName(p.Name))
+	case p.Version == "":
+		return fmt.Errorf("%s: a pack needs a version", p.Filename)
+	}
+	return nil
+}
+
+// CheckPackName reports whether name can be used for a pack
+func CheckPackName(name string) error {
+	if !packNamePattern.MatchString(name) {
+		return fmt.Errorf("pack name %q must be lowercase letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// CheckCompatible reports whether this version of cronkoans can run the pack
+func (p *Pack) CheckCompatible(version string) error {
+	if p.MinVersion == "" {
+		return nil
+	}
+	if compareVersions(version, p.MinVersion) < 0 {
+		return fmt.Errorf("pack %s needs cronkoans %s or newer, this is %s", p.Name, p.MinVersion, version)
+	}
+	return nil
+}
+
+// Known reports whether cronkoans can check answers in the dialect
+func (d Dialect) Known() bool {
+	for _, known := range Dialects {
+		if d == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate reports whether expr is a valid expression in the dialect.
+// The empty dialect is cron.
+func (d Dialect) Validate(expr string) error {
+	if d == DialectSystemd {
+		return oncalendar.Validate(expr)
+	}
+	return cronexpr.Validate(expr)
+}
+
+// Noun names an expression in the dialect, for messages
+func (d Dialect) Noun() string {
+	if d == DialectSystemd {
+		return "OnCalendar expression"
+	}
+	return "cron expression"
+}
+
+// joinDialects lists the known dialects for messages
+func joinDialects() string {
+	names := make([]string, len(Dialects))
+	for i, d := range Dialects {
+		names[i] = string(d)
+	}
+	return strings.Join(names, " or ")
+}
+
+// parseVersion parses a version such as 1.2 or v1.2.3 into its numbers
+func parseVersion(v string) ([3]int, error) {
+	var numbers [3]int
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(parts) > 3 {
+		return numbers, fmt.Errorf("invalid version %q (want major.minor.patch)", v)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return numbers, fmt.Errorf("invalid version %q (want major.minor.patch)", v)
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
+
+// compareVersions returns -1, 0 or 1 as version a is older than, the same as or
+// newer than b. Versions that do not parse compare as 0.0.0.
+func compareVersions(a, b string) int {
+	va, _ := parseVersion(a)
+	vb, _ := parseVersion(b)
+	for i := range va {
+		switch {
+		case va[i] < vb[i]:
+			return -1
+		case va[i] > vb[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// idNumberSuffix matches the _<number> that ends a conventional koan ID
+var idNumberSuffix = regexp.MustCompile(`_[0-9]+$`)
+
+// IDPrefix returns the prefix the lesson's koan IDs share: the lesson's
+// id_prefix if set, otherwise the prefix of its first koan's ID
+func (l *Lesson) IDPrefix() string {
+	if l.Prefix != "" {
+		return l.Prefix
+	}
+	if len(l.Koans) == 0 {
+		return ""
+	}
+	return idNumberSuffix.ReplaceAllString(l.Koans[0].ID, "")
+}
+
+// CheckID reports whether a koan ID follows the convention for a lesson with the given prefix
+func (c IDConvention) CheckID(prefix, id string) error {
+	if c != IDConventionLessonPrefix {
+		return nil
+	}
+	number, ok := strings.CutPrefix(id, prefix+"_")
+	if !ok || !idNumberSuffix.MatchString("_"+number) {
+		return fmt.Errorf("koan ID %q does not follow the lesson-prefix convention (want %s_<number>)", id, prefix)
+	}
+	return nil
+}
+
+// checkLessonIDs checks koan IDs across all lessons: every ID must be unique,
+// and must follow the pack's naming convention
+func checkLessonIDs(lessons []*Lesson, pack *Pack) error {
+	seen := make(map[string]string)
+	prefixes := make(map[string]string)
+
+	for _, lesson := range lessons {
+		prefix := lesson.IDPrefix()
+		if pack.IDConvention == IDConventionLessonPrefix {
+			if other, used := prefixes[prefix]; used {
+				return fmt.Errorf("lesson prefix %q in %s is already used by %s", prefix, lesson.Filename, other)
+			}
+			prefixes[prefix] = lesson.Filename
+		}
+
+		for _, k := range lesson.Koans {
+			location := koanLocation(lesson.Filename, k.Line)
+			if first, used := seen[k.ID]; used {
+				return fmt.Errorf("duplicate koan ID %q in %s and %s", k.ID, first, location)
+			}
+			seen[k.ID] = location
+
+			if err := pack.IDConvention.CheckID(prefix, k.ID); err != nil {
+				return fmt.Errorf("%s: %w", location, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// koanLocation formats a koan's position as file:line
+func koanLocation(filename string, line int) string {
+	if line == 0 {
+		return filename
+	}
+	return fmt.Sprintf("%s:%d", filename, line)
+}
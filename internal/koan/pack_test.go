@@ -0,0 +1,144 @@
+package koan
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadPackFS(t *testing.T) {
+	pack, err := LoadPackFS(fstest.MapFS{})
+	if err != nil || pack.Dialect != DialectCron || pack.IDConvention != IDConventionNone || pack.Filename != "" {
+		t.Errorf("LoadPackFS without pack.yaml = %+v, %v", pack, err)
+	}
+	if err := pack.CheckManifest(); err == nil || !strings.Contains(err.Error(), "no pack.yaml manifest") {
+		t.Errorf("CheckManifest without pack.yaml = %v", err)
+	}
+
+	pack, err = LoadPackFS(fstest.MapFS{PackFile: {Data: []byte("name: ops\nversion: 1.2.0\ndialect: systemd\nid_convention: lesson-prefix\n")}})
+	if err != nil || pack.Dialect != DialectSystemd || pack.IDConvention != IDConventionLessonPrefix || pack.CheckManifest() != nil {
+		t.Errorf("LoadPackFS = %+v, %v", pack, err)
+	}
+
+	invalid := map[string]string{
+		"dialect: quartz\n":                `unknown dialect "quartz"`,
+		"version: one\n":                   `invalid version "one"`,
+		"min_cronkoans_version: 1.2.3.4\n": `invalid version "1.2.3.4"`,
+		"id_convention: camel\n":           `invalid id_convention "camel"`,
+		"name: [\n":                        "failed to parse YAML",
+		"name: /../../tmp/x\n":             `pack name "/../../tmp/x" must be lowercase`,
+	}
+	for data, want := range invalid {
+		if _, err := LoadPackFS(fstest.MapFS{PackFile: {Data: []byte(data)}}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadPackFS(%q) = %v, want an error containing %q", data, err, want)
+		}
+	}
+}
+
+func TestCheckManifest(t *testing.T) {
+	tests := []struct {
+		pack Pack
+		want string
+	}{
+		{Pack{Filename: PackFile, Name: "ops", Version: "1.0.0"}, ""},
+		{Pack{Filename: PackFile, Version: "1.0.0"}, "needs a name"},
+		{Pack{Filename: PackFile, Name: "Ops Team", Version: "1.0.0"}, "must be lowercase letters"},
+		{Pack{Filename: PackFile, Name: "ops"}, "needs a version"},
+	}
+	for _, tt := range tests {
+		err := tt.pack.CheckManifest()
+		if (tt.want == "" && err != nil) || (tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want))) {
+			t.Errorf("CheckManifest(%+v) = %v, want %q", tt.pack, err, tt.want)
+		}
+	}
+}
+
+func TestCheckCompatible(t *testing.T) {
+	tests := []struct {
+		min, version string
+		ok           bool
+	}{
+		{"", "0.1.0", true},
+		{"1.2", "1.2.0", true},
+		{"1.2.0", "v1.10.0", true},
+		{"1.10.0", "1.9.9", false},
+		{"2", "1.99", false},
+	}
+	for _, tt := range tests {
+		p := &Pack{Name: "ops", MinVersion: tt.min}
+		if err := p.CheckCompatible(tt.version); (err == nil) != tt.ok {
+			t.Errorf("CheckCompatible(%q) with min %q = %v, want ok %v", tt.version, tt.min, err, tt.ok)
+		}
+	}
+}
+
+func TestCheckID(t *testing.T) {
+	tests := []struct {
+		convention IDConvention
+		prefix, id string
+		ok         bool
+	}{
+		{IDConventionNone, "hours", "anything", true},
+		{IDConventionLessonPrefix, "hours", "hours_1", true},
+		{IDConventionLessonPrefix, "hours", "hours_12", true},
+		{IDConventionLessonPrefix, "hours", "hours_one", false},
+		{IDConventionLessonPrefix, "hours", "minutes_1", false},
+		{IDConventionLessonPrefix, "hours", "hours1", false},
+	}
+	for _, tt := range tests {
+		if err := tt.convention.CheckID(tt.prefix, tt.id); (err == nil) != tt.ok {
+			t.Errorf("%s.CheckID(%q, %q) = %v, want ok %v", tt.convention, tt.prefix, tt.id, err, tt.ok)
+		}
+	}
+}
+
+func TestIDPrefix(t *testing.T) {
+	tests := []struct {
+		lesson Lesson
+		want   string
+	}{
+		{Lesson{Koans: []Koan{{ID: "ranges_3"}}}, "ranges"},
+		{Lesson{Koans: []Koan{{ID: "day_of_week_1"}}}, "day_of_week"},
+		{Lesson{Prefix: "dow", Koans: []Koan{{ID: "day_of_week_1"}}}, "dow"},
+		{Lesson{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.lesson.IDPrefix(); got != tt.want {
+			t.Errorf("IDPrefix() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestLoadAllLessonsFSChecksIDs(t *testing.T) {
+	prefixed := []byte("id_convention: lesson-prefix\n")
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{"valid", fstest.MapFS{
+			PackFile:          {Data: prefixed},
+			"01_minutes.yaml": {Data: []byte(testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "*")))},
+			"02_hours.yaml":   {Data: []byte(testLesson("Hours", testKoan("hours_1", "0 __ * * *", "9")))},
+		}, ""},
+		{"duplicate ID across files", fstest.MapFS{
+			"01_minutes.yaml": {Data: []byte(testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "*")))},
+			"02_hours.yaml":   {Data: []byte(testLesson("Hours", testKoan("minutes_1", "0 __ * * *", "9")))},
+		}, `duplicate koan ID "minutes_1"`},
+		{"shared prefix", fstest.MapFS{
+			PackFile:          {Data: prefixed},
+			"01_minutes.yaml": {Data: []byte(testLesson("Minutes", testKoan("minutes_1", "__ * * * *", "*")))},
+			"02_hours.yaml":   {Data: []byte(testLesson("Hours", testKoan("minutes_2", "0 __ * * *", "9")))},
+		}, `lesson prefix "minutes" in 02_hours.yaml is already used by 01_minutes.yaml`},
+		{"ID against the convention", fstest.MapFS{
+			PackFile:        {Data: prefixed},
+			"01_hours.yaml": {Data: []byte(testLesson("Hours", testKoan("hours_1", "0 __ * * *", "9"), testKoan("hours_two", "0 __ * * *", "10")))},
+		}, `koan ID "hours_two" does not follow the lesson-prefix convention`},
+	}
+	for _, tt := range tests {
+		_, err := LoadAllLessonsFS(tt.fsys)
+		if (tt.want == "" && err != nil) || (tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want))) {
+			t.Errorf("%s: LoadAllLessonsFS = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -2,134 +2,106 @@ package koan
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"sort"
-
-	"gopkg.in/yaml.v3"
 )
 
-// LoadLesson loads a single lesson from a YAML file
+// LoadLesson loads a single lesson from a YAML file, in the dialect of the
+// pack.yaml next to it
 func LoadLesson(filename string) (*Lesson, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
 	}
+	return parseLesson(data, filename, packDialect(os.DirFS(filepath.Dir(filename))))
+}
 
-	var lesson Lesson
-	if err := yaml.Unmarshal(data, &lesson); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML from %s: %w", filename, err)
-	}
-
-	lesson.Filename = filename
+// LoadLessonFS loads a single lesson from a file system such as an Overlay or the bundled lessons
+func LoadLessonFS(fsys fs.FS, name string) (*Lesson, error) {
+	return loadLessonFS(fsys, name, packDialect(fsys))
+}
 
-	// Validate the lesson
-	if err := validateLesson(&lesson); err != nil {
-		return nil, fmt.Errorf("invalid lesson in %s: %w", filename, err)
+// loadLessonFS is LoadLessonFS for a pack whose dialect is already known
+func loadLessonFS(fsys fs.FS, name string, dialect Dialect) (*Lesson, error) {
+	filename := displayPath(fsys, name)
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
 	}
-
-	return &lesson, nil
+	return parseLesson(data, filename, dialect)
 }
 
-// LoadAllLessons loads all lesson files from a directory
-func LoadAllLessons(lessonsDir string) ([]*Lesson, error) {
-	// Find all YAML files in the lessons directory
-	pattern := filepath.Join(lessonsDir, "*.yaml")
-	matches, err := filepath.Glob(pattern)
+// packDialect is the dialect of the pack at the root of a file system.
+// A pack.yaml that does not load is reported elsewhere, so it counts as cron.
+func packDialect(fsys fs.FS) Dialect {
+	pack, err := LoadPackFS(fsys)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find lesson files: %w", err)
+		return DialectCron
 	}
+	return pack.Dialect
+}
 
-	// Filter out template.yaml
-	var lessonFiles []string
-	for _, match := range matches {
-		base := filepath.Base(match)
-		if base != "template.yaml" {
-			lessonFiles = append(lessonFiles, match)
+// parseLesson parses and validates a lesson whose koans are in dialect,
+// naming it filename in errors. It applies the same checks as validate, so a
+// lesson that validate rejects never loads.
+func parseLesson(data []byte, filename string, dialect Dialect) (*Lesson, error) {
+	fc := checkLessonData(data, filename, dialect)
+	if err := fc.Err(); err != nil {
+		return nil, fmt.Errorf("invalid lesson: %w", err)
+	}
+
+	// Remember where each koan starts so errors can point at it, and let koans
+	// inherit the lesson's preview setting unless they set their own
+	lesson := fc.Lesson
+	for i := range lesson.Koans {
+		lesson.Koans[i].Line = fc.Koans[i].Line
+		if lesson.Koans[i].Preview == nil {
+			lesson.Koans[i].Preview = lesson.Preview
 		}
 	}
 
-	if len(lessonFiles) == 0 {
-		return nil, fmt.Errorf("no lesson files found in %s", lessonsDir)
+	return lesson, nil
+}
+
+// LoadAllLessons loads all lesson files from a directory, checking that koan
+// IDs are unique across lessons and follow the naming convention in pack.yaml
+func LoadAllLessons(lessonsDir string) ([]*Lesson, error) {
+	return LoadAllLessonsFS(LessonDirs(lessonsDir))
+}
+
+// LoadAllLessonsFS loads all lesson files at the root of a file system, such
+// as an Overlay of the bundled lessons and the user's lesson directories
+func LoadAllLessonsFS(fsys fs.FS) ([]*Lesson, error) {
+	lessonFiles, err := findLessonFiles(fsys)
+	if err != nil {
+		return nil, err
 	}
 
-	// Sort files alphabetically (this ensures 01, 02, 03... order)
-	sort.Strings(lessonFiles)
+	pack, err := LoadPackFS(fsys)
+	if err != nil {
+		return nil, err
+	}
 
 	// Load each lesson
 	var lessons []*Lesson
 	for _, file := range lessonFiles {
-		lesson, err := LoadLesson(file)
+		lesson, err := loadLessonFS(fsys, file, pack.Dialect)
 		if err != nil {
 			return nil, err
 		}
 		lessons = append(lessons, lesson)
 	}
 
-	return lessons, nil
-}
-
-// validateLesson validates the structure and content of a lesson
-func validateLesson(lesson *Lesson) error {
-	if lesson.Title == "" {
-		return fmt.Errorf("lesson must have a title")
-	}
-
-	if len(lesson.Koans) == 0 {
-		return fmt.Errorf("lesson must have at least one koan")
+	// Progress is tracked by koan ID, so IDs must be unique across lessons
+	if err := checkLessonIDs(lessons, pack); err != nil {
+		return nil, err
 	}
-
-	// Validate each koan
-	seenIDs := make(map[string]bool)
-	for i, koan := range lesson.Koans {
-		if err := validateKoan(&koan); err != nil {
-			return fmt.Errorf("koan %d: %w", i+1, err)
-		}
-
-		// Check for duplicate IDs
-		if seenIDs[koan.ID] {
-			return fmt.Errorf("duplicate koan ID: %s", koan.ID)
-		}
-		seenIDs[koan.ID] = true
-	}
-
-	return nil
-}
-
-// validateKoan validates a single koan
-func validateKoan(koan *Koan) error {
-	if koan.ID == "" {
-		return fmt.Errorf("koan must have an ID")
+	if err := checkPrerequisites(lessons); err != nil {
+		return nil, err
 	}
 
-	if koan.Description == "" {
-		return fmt.Errorf("koan must have a description")
-	}
-
-	if koan.Question == "" {
-		return fmt.Errorf("koan must have a question")
-	}
-
-	if koan.Incomplete == "" {
-		return fmt.Errorf("koan must have an incomplete expression")
-	}
-
-	if koan.Answer == "" {
-		return fmt.Errorf("koan must have an answer")
-	}
-
-	// Check that incomplete expression contains the blank placeholder
-	if !containsBlank(koan.Incomplete) {
-		return fmt.Errorf("incomplete expression must contain __ placeholder")
-	}
-
-	// Validate that the answer creates a valid cron expression
-	if !IsValidCronAnswer(koan.Incomplete, koan.Answer) {
-		return fmt.Errorf("answer '%s' does not create a valid cron expression: %s",
-			koan.Answer, replaceBlank(koan.Incomplete, koan.Answer))
-	}
-
-	return nil
+	return lessons, nil
 }
 
 // containsBlank checks if a string contains the __ placeholder
@@ -0,0 +1,65 @@
+package koan
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+	"github.com/dwildt/cronkoans/pkg/oncalendar"
+)
+
+// previewRuns is the number of upcoming fire times included in a preview
+const previewRuns = 3
+
+// Preview describes what a partially typed answer would schedule
+type Preview struct {
+	Expression  string
+	Valid       bool
+	Error       string
+	Description string
+	NextRuns    []time.Time
+}
+
+// PreviewEnabled reports whether learners may preview answers to this koan.
+// Exam-style koans turn the preview off with `preview: false`.
+func (k *Koan) PreviewEnabled() bool {
+	return k.Preview == nil || *k.Preview
+}
+
+// PreviewAnswer substitutes an in-progress answer into the incomplete expression
+// and reports whether it is valid, what it means and when it would fire next
+func (k *Koan) PreviewAnswer(input string) Preview {
+	return k.previewAnswer(input, time.Now())
+}
+
+// previewAnswer is PreviewAnswer with an explicit reference time
+func (k *Koan) previewAnswer(input string, now time.Time) Preview {
+	input = strings.TrimSpace(input)
+	preview := Preview{Expression: replaceBlank(k.Incomplete, input)}
+	if input == "" {
+		return preview
+	}
+
+	if k.Dialect == DialectSystemd {
+		spec, err := oncalendar.Parse(preview.Expression)
+		if err != nil {
+			preview.Error = err.Error()
+			return preview
+		}
+		preview.Valid = true
+		preview.Description = "normalized: " + spec.String()
+		preview.NextRuns = spec.NextN(now, previewRuns)
+		return preview
+	}
+
+	schedule, err := cronexpr.Parse(preview.Expression)
+	if err != nil {
+		preview.Error = err.Error()
+		return preview
+	}
+
+	preview.Valid = true
+	preview.Description = cronexpr.Describe(preview.Expression)
+	preview.NextRuns = schedule.NextN(now, previewRuns)
+	return preview
+}
@@ -2,6 +2,8 @@ package koan
 
 import (
 	"strings"
+
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
 )
 
 // replaceBlank replaces the blank placeholder (__) with the answer
@@ -9,6 +11,17 @@ func replaceBlank(incomplete, answer string) string {
 	return strings.Replace(incomplete, "__", answer, 1)
 }
 
+// IsValidCronAnswer checks if the user's answer creates a valid cron expression
+func IsValidCronAnswer(incomplete, answer string) bool {
+	complete := replaceBlank(incomplete, answer)
+	return cronexpr.Validate(complete) == nil
+}
+
+// validAnswer checks that the koan's answer creates a valid expression in its dialect
+func (k *Koan) validAnswer() error {
+	return k.Dialect.Validate(replaceBlank(k.Incomplete, k.Answer))
+}
+
 // normalizeAnswer trims spaces and converts to lowercase for comparison
 func normalizeAnswer(answer string) string {
 	return strings.TrimSpace(strings.ToLower(answer))
@@ -0,0 +1,119 @@
+package pack
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// extract copies a pack directory, or unpacks a .zip or .tar.gz archive, into dest
+func extract(src, dest string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to read pack: %w", err)
+	}
+
+	switch {
+	case info.IsDir():
+		err = os.CopyFS(dest, os.DirFS(src))
+	case strings.HasSuffix(src, ".zip"):
+		err = extractZip(src, dest)
+	case strings.HasSuffix(src, ".tar.gz"), strings.HasSuffix(src, ".tgz"):
+		err = extractTarGz(src, dest)
+	default:
+		return fmt.Errorf("unsupported pack %s (want a directory, .zip or .tar.gz)", src)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unpack %s: %w", src, err)
+	}
+	return nil
+}
+
+// extractZip unpacks a zip archive, refusing entries whose names would
+// escape dest and links
+func extractZip(src, dest string) error {
+	r, err := zip.OpenReader(src)
+	if err != nil && !errors.Is(err, zip.ErrInsecurePath) {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if !filepath.IsLocal(filepath.FromSlash(f.Name)) {
+			return fmt.Errorf("unsafe path %q in archive", f.Name)
+		}
+		if f.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("link %q in archive is not supported", f.Name)
+		}
+	}
+	return os.CopyFS(dest, r)
+}
+
+// extractTarGz unpacks a gzipped tar archive, refusing entries whose names
+// would escape dest and links. Other special files are skipped.
+func extractTarGz(src, dest string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("unsafe path %q in archive", hdr.Name)
+		}
+		target := filepath.Join(dest, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFile(target, tr); err != nil {
+				return err
+			}
+		case tar.TypeSymlink, tar.TypeLink:
+			return fmt.Errorf("link %q in archive is not supported", hdr.Name)
+		}
+	}
+}
+
+// writeFile creates a file with the contents of r, and its directory if needed
+func writeFile(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,158 @@
+package pack
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// entry is a file, directory or link in a test archive
+type entry struct {
+	name     string
+	content  string
+	linkname string // set for links
+}
+
+// packEntries are the files of a small pack inside a top-level directory
+var packEntries = []entry{
+	{name: "acme/"},
+	{name: "acme/pack.yaml", content: "name: acme\nversion: 1.0.0\n"},
+	{name: "acme/01_basics.yaml", content: "title: Basics\n"},
+}
+
+// writeZip writes the entries into a zip archive and returns its path
+func writeZip(t *testing.T, entries []entry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pack.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		content := e.content
+		switch {
+		case e.linkname != "":
+			hdr.SetMode(fs.ModeSymlink | 0777)
+			content = e.linkname
+		case strings.HasSuffix(e.name, "/"):
+			hdr.SetMode(fs.ModeDir | 0755)
+		default:
+			hdr.SetMode(0644)
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeTarGz writes the entries into a gzipped tar archive and returns its path
+func writeTarGz(t *testing.T, entries []entry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pack.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		switch {
+		case e.linkname != "":
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeSymlink, e.linkname, 0
+		case strings.HasSuffix(e.name, "/"):
+			hdr.Typeflag, hdr.Mode = tar.TypeDir, 0755
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// archiveFormats write the same entries as each kind of archive
+var archiveFormats = []struct {
+	name  string
+	write func(*testing.T, []entry) string
+}{
+	{"zip", writeZip},
+	{"tar.gz", writeTarGz},
+}
+
+func TestExtractRoundTrip(t *testing.T) {
+	for _, format := range archiveFormats {
+		dest := t.TempDir()
+		if err := extract(format.write(t, packEntries), dest); err != nil {
+			t.Errorf("%s: %v", format.name, err)
+			continue
+		}
+		for _, e := range packEntries {
+			if strings.HasSuffix(e.name, "/") {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(e.name)))
+			if err != nil {
+				t.Errorf("%s: %v", format.name, err)
+			} else if string(data) != e.content {
+				t.Errorf("%s: %s = %q, want %q", format.name, e.name, data, e.content)
+			}
+		}
+	}
+}
+
+func TestExtractRefusesUnsafeEntries(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry entry
+		want  string
+	}{
+		{"parent directory", entry{name: "../escaped.yaml", content: "title: Out\n"}, `unsafe path "../escaped.yaml"`},
+		{"nested parent directory", entry{name: "acme/../../escaped.yaml", content: "title: Out\n"}, `unsafe path "acme/../../escaped.yaml"`},
+		{"absolute path", entry{name: "/tmp/escaped.yaml", content: "title: Out\n"}, `unsafe path "/tmp/escaped.yaml"`},
+		{"link", entry{name: "acme/passwd", linkname: "/etc/passwd"}, `link "acme/passwd" in archive is not supported`},
+		{"relative link", entry{name: "acme/up", linkname: "../.."}, `link "acme/up" in archive is not supported`},
+	}
+	for _, format := range archiveFormats {
+		for _, tt := range tests {
+			parent := t.TempDir()
+			dest := filepath.Join(parent, "dest")
+			err := extract(format.write(t, append(append([]entry{}, packEntries...), tt.entry)), dest)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%s, %s: error %v, want %q", format.name, tt.name, err, tt.want)
+			}
+			if _, err := os.Stat(filepath.Join(parent, "escaped.yaml")); err == nil {
+				t.Errorf("%s, %s: wrote a file outside dest", format.name, tt.name)
+			}
+		}
+	}
+}
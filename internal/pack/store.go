@@ -0,0 +1,182 @@
+// Package pack installs lesson packs into the user's home directory and finds
+// them again. A pack is a directory, .zip or .tar.gz of lesson files with a
+// pack.yaml manifest.
+package pack
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+)
+
+const storeDirName = ".cronkoans_packs"
+
+// Store is the directory that installed packs live in, one subdirectory per pack
+type Store struct {
+	dir string
+}
+
+// NewStore opens the pack store in the home directory. The directory is
+// created when the first pack is installed.
+func NewStore() (*Store, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return &Store{dir: filepath.Join(homeDir, storeDirName)}, nil
+}
+
+// Dir returns the directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Path returns the directory an installed pack lives in
+func (s *Store) Path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+// Get reads the manifest of an installed pack
+func (s *Store) Get(name string) (*koan.Pack, error) {
+	if err := koan.CheckPackName(name); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(s.Path(name), koan.PackFile)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("pack %q is not installed; see cronkoans pack list", name)
+		}
+		return nil, err
+	}
+	return koan.LoadPackFS(koan.LessonDirs(s.Path(name)))
+}
+
+// Layer returns the lessons of an installed pack, for a koan.Overlay
+func (s *Store) Layer(name string) (koan.Layer, error) {
+	if _, err := s.Get(name); err != nil {
+		return koan.Layer{}, err
+	}
+	return koan.DirLayer(s.Path(name)), nil
+}
+
+// Broken is an installed pack whose manifest does not load
+type Broken struct {
+	Name string
+	Err  error
+}
+
+// List reads the manifests of every installed pack, sorted by name. Packs
+// whose manifest does not load are listed apart as broken, so that one bad
+// pack does not hide the others.
+func (s *Store) List() ([]*koan.Pack, []Broken, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read pack directory: %w", err)
+	}
+
+	var packs []*koan.Pack
+	var broken []Broken
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		p, err := s.Get(e.Name())
+		if err != nil {
+			broken = append(broken, Broken{Name: e.Name(), Err: err})
+			continue
+		}
+		packs = append(packs, p)
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+	return packs, broken, nil
+}
+
+// Install checks the pack at src and copies it into the store, replacing any
+// installed version. The pack needs a complete manifest, must support this
+// version of cronkoans and its lessons must load. Names in reserved are refused.
+func (s *Store) Install(src, version string, reserved ...string) (*koan.Pack, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create pack directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(s.dir, ".install-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pack directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if err := extract(src, staging); err != nil {
+		return nil, err
+	}
+	root, err := packRoot(staging)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+
+	// Name files after src in messages, not after the staging directory
+	lessons := koan.NewOverlay(koan.Layer{Path: src, FS: os.DirFS(root)})
+	manifest, err := koan.LoadPackFS(lessons)
+	if err != nil {
+		return nil, err
+	}
+	if err := manifest.CheckManifest(); err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+	for _, name := range reserved {
+		if manifest.Name == name {
+			return nil, fmt.Errorf("%s: pack name %q is reserved", src, name)
+		}
+	}
+	if err := manifest.CheckCompatible(version); err != nil {
+		return nil, err
+	}
+	if _, err := koan.LoadAllLessonsFS(lessons); err != nil {
+		return nil, fmt.Errorf("pack %s has problems, run cronkoans validate --lessons on it: %w", manifest.Name, err)
+	}
+
+	dest := s.Path(manifest.Name)
+	if err := os.RemoveAll(dest); err != nil {
+		return nil, fmt.Errorf("failed to replace installed pack: %w", err)
+	}
+	if err := os.Rename(root, dest); err != nil {
+		return nil, fmt.Errorf("failed to install pack: %w", err)
+	}
+	return manifest, nil
+}
+
+// Remove deletes an installed pack
+func (s *Store) Remove(name string) error {
+	if _, err := s.Get(name); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(s.Path(name)); err != nil {
+		return fmt.Errorf("failed to remove pack: %w", err)
+	}
+	return nil
+}
+
+// packRoot finds the manifest in an unpacked pack: at the top, or inside the
+// single directory that archives are often made of
+func packRoot(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, koan.PackFile)); err == nil {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		sub := filepath.Join(dir, entries[0].Name())
+		if _, err := os.Stat(filepath.Join(sub, koan.PackFile)); err == nil {
+			return sub, nil
+		}
+	}
+	return "", fmt.Errorf("no %s manifest found", koan.PackFile)
+}
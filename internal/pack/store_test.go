@@ -0,0 +1,70 @@
+package pack
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePack writes files into a pack directory of the store
+func writePack(t *testing.T, s *Store, name string, files map[string]string) {
+	t.Helper()
+	for file, content := range files {
+		path := filepath.Join(s.Path(name), file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestListReportsBrokenPacks(t *testing.T) {
+	s := &Store{dir: t.TempDir()}
+	writePack(t, s, "zeta", map[string]string{"pack.yaml": "name: zeta\nversion: 1.0.0\n"})
+	writePack(t, s, "acme", map[string]string{"pack.yaml": "name: acme\nversion: 1.0.0\n"})
+	writePack(t, s, "broken", map[string]string{"pack.yaml": "name: [broken\n"})
+	writePack(t, s, "missing", map[string]string{"01_basics.yaml": "title: Basics\n"})
+	writePack(t, s, ".install-123", map[string]string{"pack.yaml": "name: [broken\n"})
+
+	packs, broken, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, p := range packs {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, ","); got != "acme,zeta" {
+		t.Errorf("packs = %s, want acme,zeta", got)
+	}
+
+	tests := []struct {
+		name string
+		err  string
+	}{
+		{"broken", "pack.yaml"},
+		{"missing", "not installed"},
+	}
+	if len(broken) != len(tests) {
+		t.Fatalf("got %d broken packs, want %d: %v", len(broken), len(tests), broken)
+	}
+	for i, tt := range tests {
+		if broken[i].Name != tt.name {
+			t.Errorf("broken[%d] = %s, want %s", i, broken[i].Name, tt.name)
+		} else if !strings.Contains(broken[i].Err.Error(), tt.err) {
+			t.Errorf("%s: error %q does not mention %q", tt.name, broken[i].Err, tt.err)
+		}
+	}
+}
+
+func TestListWithoutStore(t *testing.T) {
+	s := &Store{dir: filepath.Join(t.TempDir(), "none")}
+	packs, broken, err := s.List()
+	if err != nil || packs != nil || broken != nil {
+		t.Errorf("List = %v, %v, %v; want nothing", packs, broken, err)
+	}
+}
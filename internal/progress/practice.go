@@ -0,0 +1,162 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	practiceFileName = ".cronkoans_practice.json"
+
+	// MinDifficulty and MaxDifficulty bound the practice difficulty levels
+	MinDifficulty = 1
+	MaxDifficulty = 5
+
+	// recentWindow is how many recent answers decide the next difficulty
+	recentWindow = 5
+)
+
+// ConceptStats are the practice results for one concept
+type ConceptStats struct {
+	Attempted  int    `json:"attempted" yaml:"attempted"`               // koans presented
+	Correct    int    `json:"correct" yaml:"correct"`                   // solved at the first try without hints
+	Solved     int    `json:"solved" yaml:"solved"`                     // solved at all
+	Difficulty int    `json:"difficulty" yaml:"difficulty"`             // level to continue at
+	Recent     []bool `json:"recent,omitempty" yaml:"recent,omitempty"` // first-try results, oldest first
+}
+
+// Accuracy is the share of recent koans solved at the first try, from 0 to 1
+func (cs *ConceptStats) Accuracy() float64 {
+	if len(cs.Recent) == 0 {
+		return 0
+	}
+	correct := 0
+	for _, ok := range cs.Recent {
+		if ok {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(cs.Recent))
+}
+
+// PracticeStats are kept apart from lesson progress, since practice koans are generated
+type PracticeStats struct {
+	Concepts  map[string]*ConceptStats `json:"concepts"`
+	UpdatedAt time.Time                `json:"updated_at"`
+}
+
+// PracticeTracker manages practice stats persistence
+type PracticeTracker struct {
+	filePath string
+	stats    *PracticeStats
+}
+
+// NewPracticeTracker creates a practice tracker backed by a file in the home directory
+func NewPracticeTracker() (*PracticeTracker, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	t := &PracticeTracker{
+		filePath: filepath.Join(homeDir, practiceFileName),
+		stats:    &PracticeStats{Concepts: make(map[string]*ConceptStats)},
+	}
+
+	data, err := os.ReadFile(t.filePath)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, t.stats); err != nil {
+		return nil, fmt.Errorf("failed to parse practice file: %w", err)
+	}
+	if t.stats.Concepts == nil {
+		t.stats.Concepts = make(map[string]*ConceptStats)
+	}
+
+	return t, nil
+}
+
+// Save saves practice stats to the JSON file
+func (t *PracticeTracker) Save() error {
+	t.stats.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(t.stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal practice stats: %w", err)
+	}
+
+	if err := os.WriteFile(t.filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write practice file: %w", err)
+	}
+
+	return nil
+}
+
+// Concept returns the stats for a concept, starting new concepts at the easiest level
+func (t *PracticeTracker) Concept(concept string) *ConceptStats {
+	cs, ok := t.stats.Concepts[concept]
+	if !ok {
+		cs = &ConceptStats{Difficulty: MinDifficulty}
+		t.stats.Concepts[concept] = cs
+	}
+	if cs.Difficulty < MinDifficulty || cs.Difficulty > MaxDifficulty {
+		cs.Difficulty = MinDifficulty
+	}
+	return cs
+}
+
+// SetDifficulty starts a concept at the given level and forgets the recent results
+func (t *PracticeTracker) SetDifficulty(concept string, difficulty int) {
+	cs := t.Concept(concept)
+	cs.Difficulty = max(MinDifficulty, min(MaxDifficulty, difficulty))
+	cs.Recent = nil
+}
+
+// Record records the result of one practice koan and adapts the difficulty:
+// four of the last five at the first try moves up a level, two or fewer moves down.
+// It returns the new difficulty.
+func (t *PracticeTracker) Record(concept string, firstTry, solved bool) (int, error) {
+	cs := t.Concept(concept)
+	cs.Attempted++
+	if firstTry {
+		cs.Correct++
+	}
+	if solved {
+		cs.Solved++
+	}
+
+	cs.Recent = append(cs.Recent, firstTry)
+	if len(cs.Recent) > recentWindow {
+		cs.Recent = cs.Recent[len(cs.Recent)-recentWindow:]
+	}
+
+	if len(cs.Recent) == recentWindow {
+		switch accuracy := cs.Accuracy(); {
+		case accuracy >= 0.8 && cs.Difficulty < MaxDifficulty:
+			cs.Difficulty++
+			cs.Recent = nil
+		case accuracy <= 0.4 && cs.Difficulty > MinDifficulty:
+			cs.Difficulty--
+			cs.Recent = nil
+		}
+	}
+
+	return cs.Difficulty, t.Save()
+}
+
+// Stats returns the practice stats for every concept practised so far
+func (t *PracticeTracker) Stats() *PracticeStats {
+	return t.stats
+}
+
+// GetFilePath returns the path to the practice file
+func (t *PracticeTracker) GetFilePath() string {
+	return t.filePath
+}
@@ -0,0 +1,77 @@
+package progress
+
+import (
+	"fmt"
+	"testing"
+)
+
+// newTestPracticeTracker opens a practice tracker in an empty home directory
+func newTestPracticeTracker(t *testing.T) *PracticeTracker {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	tracker, err := NewPracticeTracker()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tracker
+}
+
+func TestRecordAdaptsDifficulty(t *testing.T) {
+	const y, n = true, false
+	tests := []struct {
+		name    string
+		start   int
+		results []bool
+		want    []int // difficulty after each result
+	}{
+		{"four of five moves up", 1, []bool{y, y, n, y, y}, []int{1, 1, 1, 1, 2}},
+		{"five of five moves up", 2, []bool{y, y, y, y, y}, []int{2, 2, 2, 2, 3}},
+		{"three of five stays", 2, []bool{y, n, y, n, y}, []int{2, 2, 2, 2, 2}},
+		{"two of five moves down", 3, []bool{n, y, n, y, n}, []int{3, 3, 3, 3, 2}},
+		{"none of five moves down", 3, []bool{n, n, n, n, n}, []int{3, 3, 3, 3, 2}},
+		{"not before five answers", 3, []bool{n, n, n, n}, []int{3, 3, 3, 3}},
+		{"only the last five count", 2, []bool{n, n, y, y, y, y, y}, []int{2, 2, 2, 2, 2, 3, 3}},
+		{"five more after a change", 1, []bool{y, y, y, y, y, y, y, y, y, y}, []int{1, 1, 1, 1, 2, 2, 2, 2, 2, 3}},
+		{"no higher than the top", MaxDifficulty, []bool{y, y, y, y, y}, []int{5, 5, 5, 5, 5}},
+		{"no lower than the bottom", MinDifficulty, []bool{n, n, n, n, n}, []int{1, 1, 1, 1, 1}},
+	}
+	for _, tt := range tests {
+		tracker := newTestPracticeTracker(t)
+		tracker.SetDifficulty("steps", tt.start)
+		var got []int
+		for _, firstTry := range tt.results {
+			difficulty, err := tracker.Record("steps", firstTry, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, difficulty)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: difficulties %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecordIsSaved(t *testing.T) {
+	tracker := newTestPracticeTracker(t)
+	for _, firstTry := range []bool{true, true, false, true, true} {
+		if _, err := tracker.Record("ranges", firstTry, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tracker.Record("ranges", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewPracticeTracker()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := reopened.Concept("ranges")
+	if cs.Attempted != 6 || cs.Correct != 4 || cs.Solved != 5 || cs.Difficulty != 2 {
+		t.Errorf("ranges: %+v, want 6 attempted, 4 correct, 5 solved at difficulty 2", cs)
+	}
+	if len(cs.Recent) != 1 || cs.Recent[0] {
+		t.Errorf("ranges: recent %v, want only the answer after the level change", cs.Recent)
+	}
+}
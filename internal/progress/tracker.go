@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -38,12 +39,27 @@ type Tracker struct {
 
 // NewTracker creates a new progress tracker
 func NewTracker() (*Tracker, error) {
+	return NewPackTracker("")
+}
+
+// NewPackTracker creates a progress tracker for a lesson pack. Each pack keeps
+// its progress in its own file, so koan IDs only need to be unique within a
+// pack; the bundled lessons use the empty name and the original file.
+func NewPackTracker(pack string) (*Tracker, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 
-	filePath := filepath.Join(homeDir, progressFileName)
+	fileName := progressFileName
+	if pack != "" {
+		fileName = strings.TrimSuffix(progressFileName, ".json") + "." + pack + ".json"
+	}
+	return NewTrackerAt(filepath.Join(homeDir, fileName))
+}
+
+// NewTrackerAt creates a progress tracker that keeps its progress in filePath
+func NewTrackerAt(filePath string) (*Tracker, error) {
 	tracker := &Tracker{
 		filePath: filePath,
 	}
@@ -234,14 +250,14 @@ func (t *Tracker) GetStats(totalKoans int) Stats {
 
 // Stats represents progress statistics
 type Stats struct {
-	TotalKoans      int
-	CompletedKoans  int
-	RemainingKoans  int
-	PercentComplete float64
-	TotalAttempts   int
-	TotalHintsUsed  int
-	StartedAt       time.Time
-	UpdatedAt       time.Time
+	TotalKoans      int       `json:"total_koans" yaml:"total_koans"`
+	CompletedKoans  int       `json:"completed_koans" yaml:"completed_koans"`
+	RemainingKoans  int       `json:"remaining_koans" yaml:"remaining_koans"`
+	PercentComplete float64   `json:"percent_complete" yaml:"percent_complete"`
+	TotalAttempts   int       `json:"total_attempts" yaml:"total_attempts"`
+	TotalHintsUsed  int       `json:"total_hints_used" yaml:"total_hints_used"`
+	StartedAt       time.Time `json:"started_at" yaml:"started_at"`
+	UpdatedAt       time.Time `json:"updated_at" yaml:"updated_at"`
 }
 
 // GetFilePath returns the path to the progress file
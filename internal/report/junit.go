@@ -0,0 +1,87 @@
+package report
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/dwildt/cronkoans/internal/ui"
+)
+
+// JUnitTestSuites is the root of a JUnit XML report, as read by CI systems
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite groups the results for one lesson file
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is the result for one koan, or for the lesson file itself
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+}
+
+// JUnitFailure describes why a test case failed
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// NewJUnitReport converts validation results into a JUnit report with one suite per lesson file
+func NewJUnitReport(results []ui.ValidationResult) JUnitTestSuites {
+	report := JUnitTestSuites{Name: "cronkoans validate"}
+	suiteIndex := make(map[string]int)
+
+	for _, r := range results {
+		idx, ok := suiteIndex[r.File]
+		if !ok {
+			idx = len(report.Suites)
+			suiteIndex[r.File] = idx
+			report.Suites = append(report.Suites, JUnitTestSuite{Name: r.File})
+		}
+		suite := &report.Suites[idx]
+
+		tc := JUnitTestCase{
+			Name:      r.Name(),
+			ClassName: r.File,
+			File:      r.File,
+			Line:      r.Line,
+		}
+		if !r.Passed {
+			var details []string
+			failureType := "validation"
+			for _, p := range r.Problems {
+				details = append(details, p.String())
+			}
+			if len(r.Problems) > 0 {
+				failureType = r.Problems[0].Check
+			}
+			tc.Failure = &JUnitFailure{
+				Message: r.Error,
+				Type:    failureType,
+				Text:    strings.Join(details, "\n"),
+			}
+			suite.Failures++
+			report.Failures++
+		}
+
+		suite.Tests++
+		report.Tests++
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	return report
+}
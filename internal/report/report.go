@@ -0,0 +1,405 @@
+package report
+
+import (
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/internal/progress"
+	"github.com/dwildt/cronkoans/internal/ui"
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+	"github.com/dwildt/cronkoans/pkg/crontab"
+)
+
+// SchemaVersion is bumped whenever a document changes incompatibly.
+// Adding fields does not change the version. See docs/output-schema.md.
+const SchemaVersion = 1
+
+// Document kinds
+const (
+	KindStatus     = "status"
+	KindLessons    = "lessons"
+	KindValidation = "validation"
+	KindNormalize  = "normalize"
+	KindDiff       = "diff"
+	KindLoad       = "load"
+	KindLint       = "lint"
+	KindLintRules  = "lint-rules"
+	KindConvert    = "convert"
+)
+
+// StatusDocument is the structured output of `cronkoans status`
+type StatusDocument struct {
+	SchemaVersion int            `json:"schema_version" yaml:"schema_version"`
+	Kind          string         `json:"kind" yaml:"kind"`
+	Stats         progress.Stats `json:"stats" yaml:"stats"`
+	ProgressFile  string         `json:"progress_file" yaml:"progress_file"`
+	HasProgress   bool           `json:"has_progress" yaml:"has_progress"`
+}
+
+// LessonsDocument is the structured output of `cronkoans list`
+type LessonsDocument struct {
+	SchemaVersion int             `json:"schema_version" yaml:"schema_version"`
+	Kind          string          `json:"kind" yaml:"kind"`
+	Lessons       []LessonSummary `json:"lessons" yaml:"lessons"`
+}
+
+// LessonSummary describes one lesson and the learner's completion of it
+type LessonSummary struct {
+	Number           int           `json:"number" yaml:"number"`
+	Name             string        `json:"name" yaml:"name"`
+	Title            string        `json:"title" yaml:"title"`
+	Description      string        `json:"description" yaml:"description"`
+	File             string        `json:"file" yaml:"file"`
+	Difficulty       int           `json:"difficulty,omitempty" yaml:"difficulty,omitempty"`
+	EstimatedMinutes int           `json:"estimated_minutes,omitempty" yaml:"estimated_minutes,omitempty"`
+	Tags             []string      `json:"tags" yaml:"tags"`
+	Prerequisites    []string      `json:"prerequisites" yaml:"prerequisites"`
+	Unlocked         bool          `json:"unlocked" yaml:"unlocked"`
+	Next             bool          `json:"next" yaml:"next"`
+	TotalKoans       int           `json:"total_koans" yaml:"total_koans"`
+	CompletedKoans   int           `json:"completed_koans" yaml:"completed_koans"`
+	PercentComplete  float64       `json:"percent_complete" yaml:"percent_complete"`
+	Koans            []KoanSummary `json:"koans" yaml:"koans"`
+}
+
+// KoanSummary describes one koan and the learner's progress on it
+type KoanSummary struct {
+	ID          string   `json:"id" yaml:"id"`
+	Description string   `json:"description" yaml:"description"`
+	Difficulty  int      `json:"difficulty,omitempty" yaml:"difficulty,omitempty"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Completed   bool     `json:"completed" yaml:"completed"`
+	Attempts    int      `json:"attempts" yaml:"attempts"`
+	HintsUsed   int      `json:"hints_used" yaml:"hints_used"`
+}
+
+// ValidationDocument is the structured output of `cronkoans validate`
+type ValidationDocument struct {
+	SchemaVersion int                   `json:"schema_version" yaml:"schema_version"`
+	Kind          string                `json:"kind" yaml:"kind"`
+	Total         int                   `json:"total" yaml:"total"`
+	Passed        int                   `json:"passed" yaml:"passed"`
+	Failed        int                   `json:"failed" yaml:"failed"`
+	Results       []ui.ValidationResult `json:"results" yaml:"results"`
+}
+
+// NormalizeDocument is the structured output of `cronkoans normalize`
+type NormalizeDocument struct {
+	SchemaVersion int                    `json:"schema_version" yaml:"schema_version"`
+	Kind          string                 `json:"kind" yaml:"kind"`
+	Expressions   []NormalizedExpression `json:"expressions" yaml:"expressions"`
+}
+
+// NormalizedExpression is one expression and its canonical form
+type NormalizedExpression struct {
+	Expression string `json:"expression" yaml:"expression"`
+	Normalized string `json:"normalized" yaml:"normalized"`
+	Changed    bool   `json:"changed" yaml:"changed"`
+}
+
+// DiffDocument is the structured output of `cronkoans diff`
+type DiffDocument struct {
+	SchemaVersion int         `json:"schema_version" yaml:"schema_version"`
+	Kind          string      `json:"kind" yaml:"kind"`
+	Before        string      `json:"before" yaml:"before"`
+	After         string      `json:"after" yaml:"after"`
+	Start         time.Time   `json:"start" yaml:"start"`
+	End           time.Time   `json:"end" yaml:"end"`
+	Equivalent    bool        `json:"equivalent" yaml:"equivalent"`
+	Unchanged     int         `json:"unchanged" yaml:"unchanged"`
+	Summary       []string    `json:"summary" yaml:"summary"`
+	Added         []time.Time `json:"added" yaml:"added"`
+	Removed       []time.Time `json:"removed" yaml:"removed"`
+}
+
+// LoadDocument is the structured output of `cronkoans analyze`
+type LoadDocument struct {
+	SchemaVersion int          `json:"schema_version" yaml:"schema_version"`
+	Kind          string       `json:"kind" yaml:"kind"`
+	File          string       `json:"file" yaml:"file"`
+	Start         time.Time    `json:"start" yaml:"start"`
+	End           time.Time    `json:"end" yaml:"end"`
+	Jobs          int          `json:"jobs" yaml:"jobs"`
+	Starts        int          `json:"starts" yaml:"starts"`
+	Peaks         []int        `json:"peaks" yaml:"peaks"`
+	Hotspots      []Hotspot    `json:"hotspots" yaml:"hotspots"`
+	Overlaps      []Overlap    `json:"overlaps" yaml:"overlaps"`
+	Suggestions   []Suggestion `json:"suggestions" yaml:"suggestions"`
+}
+
+// CrontabJob identifies a line of a crontab
+type CrontabJob struct {
+	Line       int    `json:"line" yaml:"line"`
+	Expression string `json:"expression" yaml:"expression"`
+	Command    string `json:"command" yaml:"command"`
+}
+
+// Hotspot is a set of jobs that start in the same minute
+type Hotspot struct {
+	Message string       `json:"message" yaml:"message"`
+	Times   []string     `json:"times" yaml:"times"`
+	Days    int          `json:"days" yaml:"days"`
+	Jobs    []CrontabJob `json:"jobs" yaml:"jobs"`
+}
+
+// Overlap is a job that can start while its previous run is still going
+type Overlap struct {
+	CrontabJob  `yaml:",inline"`
+	Duration    string `json:"duration" yaml:"duration"`
+	MinGap      string `json:"min_gap" yaml:"min_gap"`
+	Runs        int    `json:"runs" yaml:"runs"`
+	Overlapping int    `json:"overlapping" yaml:"overlapping"`
+}
+
+// Suggestion is a quieter schedule for a job that starts in a hotspot
+type Suggestion struct {
+	CrontabJob `yaml:",inline"`
+	Suggested  string `json:"suggested" yaml:"suggested"`
+}
+
+// LintDocument is the structured output of `cronkoans lint`
+type LintDocument struct {
+	SchemaVersion int           `json:"schema_version" yaml:"schema_version"`
+	Kind          string        `json:"kind" yaml:"kind"`
+	Errors        int           `json:"errors" yaml:"errors"`
+	Warnings      int           `json:"warnings" yaml:"warnings"`
+	Infos         int           `json:"infos" yaml:"infos"`
+	Findings      []LintFinding `json:"findings" yaml:"findings"`
+}
+
+// LintFinding is one problem found by a lint rule
+type LintFinding struct {
+	Source      string `json:"source" yaml:"source"`
+	Line        int    `json:"line,omitempty" yaml:"line,omitempty"`
+	Rule        string `json:"rule" yaml:"rule"`
+	Severity    string `json:"severity" yaml:"severity"`
+	Message     string `json:"message" yaml:"message"`
+	Explanation string `json:"explanation" yaml:"explanation"`
+	Koan        string `json:"koan,omitempty" yaml:"koan,omitempty"`
+}
+
+// LintRulesDocument is the structured output of `cronkoans lint --rules`
+type LintRulesDocument struct {
+	SchemaVersion int        `json:"schema_version" yaml:"schema_version"`
+	Kind          string     `json:"kind" yaml:"kind"`
+	Rules         []LintRule `json:"rules" yaml:"rules"`
+}
+
+// LintRule describes a lint rule
+type LintRule struct {
+	Name        string `json:"name" yaml:"name"`
+	Severity    string `json:"severity" yaml:"severity"`
+	Explanation string `json:"explanation" yaml:"explanation"`
+	Koan        string `json:"koan,omitempty" yaml:"koan,omitempty"`
+}
+
+// ConvertDocument is the structured output of `cronkoans convert`
+type ConvertDocument struct {
+	SchemaVersion int          `json:"schema_version" yaml:"schema_version"`
+	Kind          string       `json:"kind" yaml:"kind"`
+	From          string       `json:"from" yaml:"from"`
+	To            string       `json:"to" yaml:"to"`
+	Conversions   []Conversion `json:"conversions" yaml:"conversions"`
+}
+
+// Conversion is one schedule and what it becomes in the other syntax
+type Conversion struct {
+	Source   string   `json:"source" yaml:"source"`
+	Result   []string `json:"result" yaml:"result"`
+	Warnings []string `json:"warnings" yaml:"warnings"`
+}
+
+// NewStatusDocument builds the status document from the tracker
+func NewStatusDocument(tracker *progress.Tracker, totalKoans int) StatusDocument {
+	return StatusDocument{
+		SchemaVersion: SchemaVersion,
+		Kind:          KindStatus,
+		Stats:         tracker.GetStats(totalKoans),
+		ProgressFile:  tracker.GetFilePath(),
+		HasProgress:   tracker.Exists(),
+	}
+}
+
+// NewLessonsDocument builds the lesson list document with per-lesson completion
+func NewLessonsDocument(lessons []*koan.Lesson, tracker *progress.Tracker) LessonsDocument {
+	doc := LessonsDocument{
+		SchemaVersion: SchemaVersion,
+		Kind:          KindLessons,
+		Lessons:       []LessonSummary{},
+	}
+
+	next := koan.NextLesson(lessons, tracker.IsCompleted)
+	for i, lesson := range lessons {
+		summary := LessonSummary{
+			Number:           i + 1,
+			Name:             lesson.Name(),
+			Title:            lesson.Title,
+			Description:      lesson.Description,
+			File:             lesson.Filename,
+			Difficulty:       lesson.Difficulty,
+			EstimatedMinutes: lesson.EstimatedMinutes(),
+			Tags:             lesson.AllTags(),
+			Prerequisites:    lesson.Prerequisites,
+			Unlocked:         koan.Unlocked(lessons, lesson, tracker.IsCompleted),
+			Next:             lesson == next,
+			TotalKoans:       len(lesson.Koans),
+			Koans:            []KoanSummary{},
+		}
+		if summary.Tags == nil {
+			summary.Tags = []string{}
+		}
+		if summary.Prerequisites == nil {
+			summary.Prerequisites = []string{}
+		}
+
+		for j := range lesson.Koans {
+			k := &lesson.Koans[j]
+			ks := KoanSummary{
+				ID:          k.ID,
+				Description: k.Description,
+				Difficulty:  lesson.KoanDifficulty(k),
+				Tags:        k.Tags,
+				Completed:   tracker.IsCompleted(k.ID),
+			}
+			if kp := tracker.GetProgress(k.ID); kp != nil {
+				ks.Attempts = kp.Attempts
+				ks.HintsUsed = kp.HintsUsed
+			}
+			if ks.Completed {
+				summary.CompletedKoans++
+			}
+			summary.Koans = append(summary.Koans, ks)
+		}
+
+		if summary.TotalKoans > 0 {
+			summary.PercentComplete = float64(summary.CompletedKoans) / float64(summary.TotalKoans) * 100
+		}
+		doc.Lessons = append(doc.Lessons, summary)
+	}
+
+	return doc
+}
+
+// NewValidationDocument builds the validation document from individual results
+func NewValidationDocument(results []ui.ValidationResult) ValidationDocument {
+	doc := ValidationDocument{
+		SchemaVersion: SchemaVersion,
+		Kind:          KindValidation,
+		Total:         len(results),
+		Results:       results,
+	}
+	if doc.Results == nil {
+		doc.Results = []ui.ValidationResult{}
+	}
+
+	for _, r := range results {
+		if r.Passed {
+			doc.Passed++
+		} else {
+			doc.Failed++
+		}
+	}
+	return doc
+}
+
+// NewDiffDocument builds the diff document for two expressions and their difference
+func NewDiffDocument(before, after string, diff *cronexpr.Difference) DiffDocument {
+	doc := DiffDocument{
+		SchemaVersion: SchemaVersion,
+		Kind:          KindDiff,
+		Before:        before,
+		After:         after,
+		Start:         diff.Start,
+		End:           diff.End,
+		Equivalent:    diff.Empty(),
+		Unchanged:     diff.Shared,
+		Summary:       diff.Summary(),
+		Added:         diff.Added,
+		Removed:       diff.Removed,
+	}
+	if doc.Added == nil {
+		doc.Added = []time.Time{}
+	}
+	if doc.Removed == nil {
+		doc.Removed = []time.Time{}
+	}
+	return doc
+}
+
+// NewLoadDocument builds the load document for a crontab file and its analysis
+func NewLoadDocument(file string, tab *crontab.Crontab, load *crontab.Load) LoadDocument {
+	doc := LoadDocument{
+		SchemaVersion: SchemaVersion,
+		Kind:          KindLoad,
+		File:          file,
+		Start:         load.Start,
+		End:           load.End,
+		Jobs:          len(tab.Jobs),
+		Starts:        load.Starts,
+		Peaks:         load.Peaks[:],
+		Hotspots:      []Hotspot{},
+		Overlaps:      []Overlap{},
+		Suggestions:   []Suggestion{},
+	}
+	for _, h := range load.Hotspots {
+		hotspot := Hotspot{Message: h.String(), Times: h.Times, Days: h.Days}
+		for _, job := range h.Jobs {
+			hotspot.Jobs = append(hotspot.Jobs, newCrontabJob(job))
+		}
+		doc.Hotspots = append(doc.Hotspots, hotspot)
+	}
+	for _, o := range load.Overlaps {
+		doc.Overlaps = append(doc.Overlaps, Overlap{
+			CrontabJob:  newCrontabJob(o.Job),
+			Duration:    o.Job.Duration.String(),
+			MinGap:      o.Gap.String(),
+			Runs:        o.Runs,
+			Overlapping: o.Overlapping,
+		})
+	}
+	for _, s := range load.Suggestions {
+		doc.Suggestions = append(doc.Suggestions, Suggestion{CrontabJob: newCrontabJob(s.Job), Suggested: s.Expression})
+	}
+	return doc
+}
+
+// newCrontabJob identifies a crontab job in a document
+func newCrontabJob(job *crontab.Job) CrontabJob {
+	return CrontabJob{Line: job.Line, Expression: job.Expression, Command: job.Command}
+}
+
+// NewLintDocument builds the lint document from the findings for each file or expression
+func NewLintDocument(results []ui.LintResult) LintDocument {
+	doc := LintDocument{SchemaVersion: SchemaVersion, Kind: KindLint, Findings: []LintFinding{}}
+	for _, r := range results {
+		for _, f := range r.Findings {
+			switch f.Rule.Severity {
+			case crontab.SeverityError:
+				doc.Errors++
+			case crontab.SeverityWarning:
+				doc.Warnings++
+			default:
+				doc.Infos++
+			}
+			doc.Findings = append(doc.Findings, LintFinding{
+				Source:      r.Source,
+				Line:        f.Line,
+				Rule:        f.Rule.Name,
+				Severity:    string(f.Rule.Severity),
+				Message:     f.Message,
+				Explanation: f.Rule.Explanation,
+				Koan:        f.Rule.Koan,
+			})
+		}
+	}
+	return doc
+}
+
+// NewLintRulesDocument builds the document listing the lint rules
+func NewLintRulesDocument(rules []*crontab.Rule) LintRulesDocument {
+	doc := LintRulesDocument{SchemaVersion: SchemaVersion, Kind: KindLintRules}
+	for _, r := range rules {
+		doc.Rules = append(doc.Rules, LintRule{Name: r.Name, Severity: string(r.Severity), Explanation: r.Explanation, Koan: r.Koan})
+	}
+	return doc
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/internal/progress"
+	"github.com/dwildt/cronkoans/internal/report"
+)
+
+// KindStats is the kind of the stats document
+const KindStats = "stats"
+
+// How learners sign in, as reported by /api/info
+const (
+	authHeader = "header" // name yourself in UserHeader
+	authToken  = "token"  // send a bearer token
+)
+
+// koanDocument is a koan as the learner sees it, without its answer or hints
+type koanDocument struct {
+	ID          string    `json:"id"`
+	Lesson      lessonRef `json:"lesson"`
+	Number      int       `json:"number"`
+	Total       int       `json:"total"`
+	Description string    `json:"description"`
+	Question    string    `json:"question"`
+	Incomplete  string    `json:"incomplete"`
+	Difficulty  int       `json:"difficulty,omitempty"`
+	Tags        []string  `json:"tags"`
+	Hints       int       `json:"hints"`
+	HintsUsed   int       `json:"hints_used"`
+	Revealed    []string  `json:"revealed_hints"`
+	Attempts    int       `json:"attempts"`
+	Completed   bool      `json:"completed"`
+	Unlocked    bool      `json:"unlocked"`
+	Explanation string    `json:"explanation,omitempty"` // only once the koan is complete
+}
+
+// lessonRef names the lesson a koan belongs to
+type lessonRef struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+}
+
+// answerDocument is the result of an answer
+type answerDocument struct {
+	KoanID      string            `json:"koan_id"`
+	Answer      string            `json:"answer"`
+	Correct     bool              `json:"correct"`
+	Completed   bool              `json:"completed"`
+	Attempts    int               `json:"attempts"`
+	Expression  string            `json:"expression,omitempty"`
+	Explanation string            `json:"explanation,omitempty"`
+	Feedback    *feedbackDocument `json:"feedback,omitempty"`
+}
+
+// feedbackDocument explains why an answer was not accepted
+type feedbackDocument struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// hintDocument is one revealed hint
+type hintDocument struct {
+	KoanID    string `json:"koan_id"`
+	Level     int    `json:"level"`
+	Hint      string `json:"hint"`
+	Remaining int    `json:"remaining"`
+}
+
+// statsDocument is the learner's overall progress
+type statsDocument struct {
+	SchemaVersion int            `json:"schema_version"`
+	Kind          string         `json:"kind"`
+	Stats         progress.Stats `json:"stats"`
+}
+
+// previewDocument describes what an answer in progress would schedule
+type previewDocument struct {
+	Expression  string   `json:"expression"`
+	Valid       bool     `json:"valid"`
+	Error       string   `json:"error,omitempty"`
+	Description string   `json:"description,omitempty"`
+	NextRuns    []string `json:"next_runs"`
+}
+
+// explainDocument describes a cron expression
+type explainDocument struct {
+	Expression  string   `json:"expression"`
+	Valid       bool     `json:"valid"`
+	Error       string   `json:"error,omitempty"`
+	Description string   `json:"description,omitempty"`
+	TimeZone    string   `json:"time_zone"`
+	NextRuns    []string `json:"next_runs"`
+}
+
+// infoDocument describes the server to clients before they sign in
+type infoDocument struct {
+	Pack string `json:"pack"`
+	Auth string `json:"auth"`
+}
+
+// errorDocument is the body of every error response
+type errorDocument struct {
+	Error string `json:"error"`
+}
+
+// newStatsDocument wraps the learner's stats
+func newStatsDocument(stats progress.Stats) statsDocument {
+	return statsDocument{SchemaVersion: report.SchemaVersion, Kind: KindStats, Stats: stats}
+}
+
+// newPreviewDocument formats a preview with RFC 3339 fire times
+func newPreviewDocument(p koan.Preview) previewDocument {
+	doc := previewDocument{
+		Expression:  p.Expression,
+		Valid:       p.Valid,
+		Error:       p.Error,
+		Description: p.Description,
+		NextRuns:    []string{},
+	}
+	for _, run := range p.NextRuns {
+		doc.NextRuns = append(doc.NextRuns, run.Format(time.RFC3339))
+	}
+	return doc
+}
+
+// newKoanDocument describes a koan and the learner's progress on it
+func (s *Server) newKoanDocument(lesson *koan.Lesson, k *koan.Koan, tracker *progress.Tracker) koanDocument {
+	number := 0
+	for _, l := range s.lessons {
+		if l == lesson {
+			for i := range l.Koans {
+				if l.Koans[i].ID == k.ID {
+					number += i + 1
+					break
+				}
+			}
+			break
+		}
+		number += len(l.Koans)
+	}
+
+	doc := koanDocument{
+		ID:          k.ID,
+		Lesson:      lessonRef{Name: lesson.Name(), Title: lesson.Title},
+		Number:      number,
+		Total:       s.totalKoans(),
+		Description: k.Description,
+		Question:    k.Question,
+		Incomplete:  k.Incomplete,
+		Difficulty:  lesson.KoanDifficulty(k),
+		Tags:        k.Tags,
+		Hints:       len(k.Hints),
+		Revealed:    []string{},
+		Completed:   tracker.IsCompleted(k.ID),
+		Unlocked:    koan.Unlocked(s.lessons, lesson, tracker.IsCompleted),
+	}
+	if doc.Tags == nil {
+		doc.Tags = []string{}
+	}
+	if kp := tracker.GetProgress(k.ID); kp != nil {
+		doc.HintsUsed = kp.HintsUsed
+		for level := 0; level < kp.HintsUsed && level < len(k.Hints); level++ {
+			doc.Revealed = append(doc.Revealed, k.GetHint(level))
+		}
+		doc.Attempts = kp.Attempts
+	}
+	if doc.Completed {
+		doc.Explanation = k.Explanation
+	}
+	return doc
+}
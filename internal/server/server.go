@@ -0,0 +1,425 @@
+// Package server serves lessons over HTTP: a JSON API, and a web interface
+// built on it. Every learner has their own progress, chosen by a header or,
+// when tokens are configured, by the bearer token they send.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/internal/progress"
+	"github.com/dwildt/cronkoans/internal/report"
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+	"gopkg.in/yaml.v3"
+)
+
+// UserHeader names the learner when the server runs without tokens
+const UserHeader = "X-Cronkoans-User"
+
+// Fire times listed by /api/cron/explain, by default and at most
+const (
+	defaultExplainRuns = 5
+	maxExplainRuns     = 50
+)
+
+// maxAnswerBytes limits the body of an answer, which holds one short expression
+const maxAnswerBytes = 4 << 10
+
+// userPattern keeps user names safe to use as file names
+var userPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@-]{0,63}$`)
+
+// Options configure a server
+type Options struct {
+	DataDir string            // directory for progress files, one per user
+	Tokens  map[string]string // bearer token to user; when set, every request needs a token
+}
+
+// Server is an http.Handler for the koan API
+type Server struct {
+	lessons []*koan.Lesson
+	pack    string
+	opts    Options
+	mux     *http.ServeMux
+
+	mu       sync.Mutex // guards learners
+	learners map[string]*learner
+}
+
+// learner is the progress of one user. Trackers are not safe for concurrent
+// use, so requests of the same user take turns while other users go ahead.
+type learner struct {
+	mu      sync.Mutex
+	tracker *progress.Tracker
+}
+
+// New creates a server for the lessons of a pack
+func New(lessons []*koan.Lesson, pack string, opts Options) *Server {
+	s := &Server{
+		lessons:  lessons,
+		pack:     pack,
+		opts:     opts,
+		mux:      http.NewServeMux(),
+		learners: make(map[string]*learner),
+	}
+	s.mux.HandleFunc("GET /api/lessons", s.withUser(s.handleLessons))
+	s.mux.HandleFunc("GET /api/koans/next", s.withUser(s.handleNext))
+	s.mux.HandleFunc("GET /api/koans/{id}", s.withUser(s.handleKoan))
+	s.mux.HandleFunc("POST /api/koans/{id}/answer", s.withUser(s.handleAnswer))
+	s.mux.HandleFunc("POST /api/koans/{id}/hint", s.withUser(s.handleHint))
+	s.mux.HandleFunc("GET /api/koans/{id}/preview", s.withUser(s.handlePreview))
+	s.mux.HandleFunc("GET /api/stats", s.withUser(s.handleStats))
+	s.mux.HandleFunc("GET /api/info", s.handleInfo)
+	s.mux.HandleFunc("GET /api/cron/explain", s.handleExplain)
+	s.mux.HandleFunc("GET /api/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such endpoint: %s", r.URL.Path))
+	})
+	s.mux.Handle("GET /", http.FileServerFS(webFS))
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// LoadTokens reads a YAML file that maps user names to their tokens
+func LoadTokens(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens: %w", err)
+	}
+	var users map[string]string
+	if err := yaml.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	tokens := make(map[string]string, len(users))
+	for user, token := range users {
+		if !userPattern.MatchString(user) {
+			return nil, fmt.Errorf("%s: invalid user name %q", path, user)
+		}
+		if token == "" {
+			return nil, fmt.Errorf("%s: user %s has no token", path, user)
+		}
+		if other, ok := tokens[token]; ok {
+			return nil, fmt.Errorf("%s: users %s and %s have the same token", path, other, user)
+		}
+		tokens[token] = user
+	}
+	return tokens, nil
+}
+
+// userHandler handles a request on behalf of a learner
+type userHandler func(w http.ResponseWriter, r *http.Request, tracker *progress.Tracker)
+
+// withUser finds the learner behind a request and loads their progress
+func (s *Server) withUser(h userHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := s.user(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		l, err := s.learner(user)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		h(w, r, l.tracker)
+	}
+}
+
+// user names the learner from the bearer token, or from UserHeader when the
+// server has no tokens
+func (s *Server) user(r *http.Request) (string, error) {
+	if len(s.opts.Tokens) > 0 {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			return "", errors.New("missing bearer token")
+		}
+		user, ok := s.opts.Tokens[token]
+		if !ok {
+			return "", errors.New("unknown token")
+		}
+		return user, nil
+	}
+
+	user := r.Header.Get(UserHeader)
+	if user == "" {
+		return "", fmt.Errorf("missing %s header", UserHeader)
+	}
+	if !userPattern.MatchString(user) {
+		return "", fmt.Errorf("invalid user name %q", user)
+	}
+	return user, nil
+}
+
+// learner returns the progress of a user, loading it the first time
+func (s *Server) learner(user string) (*learner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.learners[user]; ok {
+		return l, nil
+	}
+	dir := filepath.Join(s.opts.DataDir, s.pack)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create progress directory: %w", err)
+	}
+	t, err := progress.NewTrackerAt(filepath.Join(dir, user+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load progress of %s: %w", user, err)
+	}
+	l := &learner{tracker: t}
+	s.learners[user] = l
+	return l, nil
+}
+
+// find returns a koan and the lesson it belongs to
+func (s *Server) find(id string) (*koan.Lesson, *koan.Koan, bool) {
+	for _, lesson := range s.lessons {
+		for i := range lesson.Koans {
+			if lesson.Koans[i].ID == id {
+				return lesson, &lesson.Koans[i], true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// findOr404 finds the koan named in the request path, answering 404 when there is none
+func (s *Server) findOr404(w http.ResponseWriter, r *http.Request) (*koan.Lesson, *koan.Koan, bool) {
+	lesson, k, ok := s.find(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("koan %q not found", r.PathValue("id")))
+	}
+	return lesson, k, ok
+}
+
+func (s *Server) handleLessons(w http.ResponseWriter, r *http.Request, tracker *progress.Tracker) {
+	writeJSON(w, http.StatusOK, report.NewLessonsDocument(s.lessons, tracker))
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request, tracker *progress.Tracker) {
+	writeJSON(w, http.StatusOK, newStatsDocument(tracker.GetStats(s.totalKoans())))
+}
+
+func (s *Server) handleKoan(w http.ResponseWriter, r *http.Request, tracker *progress.Tracker) {
+	if lesson, k, ok := s.findOr404(w, r); ok {
+		writeJSON(w, http.StatusOK, s.newKoanDocument(lesson, k, tracker))
+	}
+}
+
+// handleNext answers with the first unfinished koan of the suggested lesson,
+// or 404 when every koan is complete
+func (s *Server) handleNext(w http.ResponseWriter, r *http.Request, tracker *progress.Tracker) {
+	lesson := koan.NextLesson(s.lessons, tracker.IsCompleted)
+	if lesson == nil {
+		// Every unfinished lesson is locked; take the first one anyway
+		for _, l := range s.lessons {
+			if !koan.LessonComplete(l, tracker.IsCompleted) {
+				lesson = l
+				break
+			}
+		}
+	}
+	if lesson == nil {
+		writeError(w, http.StatusNotFound, errors.New("every koan is complete"))
+		return
+	}
+	for i := range lesson.Koans {
+		if !tracker.IsCompleted(lesson.Koans[i].ID) {
+			writeJSON(w, http.StatusOK, s.newKoanDocument(lesson, &lesson.Koans[i], tracker))
+			return
+		}
+	}
+}
+
+// answerRequest is the body of an answer
+type answerRequest struct {
+	Answer string `json:"answer"`
+}
+
+func (s *Server) handleAnswer(w http.ResponseWriter, r *http.Request, tracker *progress.Tracker) {
+	_, k, ok := s.findOr404(w, r)
+	if !ok {
+		return
+	}
+	var req answerRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAnswerBytes)).Decode(&req); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body is larger than %d bytes", maxErr.Limit))
+			return
+		}
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	answer := strings.TrimSpace(req.Answer)
+	if answer == "" {
+		writeError(w, http.StatusBadRequest, errors.New("answer is required"))
+		return
+	}
+
+	// A koan that is already complete can be answered again without
+	// changing its progress
+	completed := tracker.IsCompleted(k.ID)
+	if !completed {
+		if err := tracker.RecordAttempt(k.ID); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to save progress: %w", err))
+			return
+		}
+	}
+
+	result := answerDocument{KoanID: k.ID, Answer: answer}
+	if k.CheckAnswer(answer) {
+		result.Correct = true
+		result.Expression = k.CompleteCronExpression()
+		result.Explanation = k.Explanation
+		if !completed {
+			kp := tracker.GetProgress(k.ID)
+			if err := tracker.MarkCompleted(k.ID, kp.Attempts, kp.HintsUsed); err != nil {
+				writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to save progress: %w", err))
+				return
+			}
+		}
+	} else {
+		feedback := k.AnalyzeAnswer(answer)
+		result.Feedback = &feedbackDocument{Kind: string(feedback.Kind), Message: feedback.Message}
+	}
+	if kp := tracker.GetProgress(k.ID); kp != nil {
+		result.Attempts = kp.Attempts
+	}
+	result.Completed = tracker.IsCompleted(k.ID)
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleHint reveals the next hint of a koan. Hints already shown are
+// counted in the progress, so the next request reveals the one after.
+func (s *Server) handleHint(w http.ResponseWriter, r *http.Request, tracker *progress.Tracker) {
+	_, k, ok := s.findOr404(w, r)
+	if !ok {
+		return
+	}
+	level := 0
+	if kp := tracker.GetProgress(k.ID); kp != nil {
+		level = kp.HintsUsed
+	}
+	if level >= len(k.Hints) {
+		writeError(w, http.StatusConflict, errors.New("no more hints available for this koan"))
+		return
+	}
+	if err := tracker.RecordHint(k.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to save progress: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, hintDocument{
+		KoanID:    k.ID,
+		Level:     level + 1,
+		Hint:      k.GetHint(level),
+		Remaining: len(k.Hints) - level - 1,
+	})
+}
+
+// handlePreview shows what an answer in progress would schedule, for the
+// live tester of the web interface
+func (s *Server) handlePreview(w http.ResponseWriter, r *http.Request, tracker *progress.Tracker) {
+	_, k, ok := s.findOr404(w, r)
+	if !ok {
+		return
+	}
+	if !k.PreviewEnabled() {
+		writeError(w, http.StatusForbidden, errors.New("preview is disabled for this koan"))
+		return
+	}
+	writeJSON(w, http.StatusOK, newPreviewDocument(k.PreviewAnswer(r.URL.Query().Get("answer"))))
+}
+
+// handleInfo tells clients how to sign in; it is the only endpoint that
+// needs no learner
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	doc := infoDocument{Pack: s.pack, Auth: authHeader}
+	if len(s.opts.Tokens) > 0 {
+		doc.Auth = authToken
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// handleExplain validates and describes any cron expression and lists when
+// it fires next. It needs no learner.
+func (s *Server) handleExplain(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	expr := strings.TrimSpace(query.Get("expression"))
+	if expr == "" {
+		writeError(w, http.StatusBadRequest, errors.New("expression is required"))
+		return
+	}
+
+	count := defaultExplainRuns
+	if value := query.Get("count"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > maxExplainRuns {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("count must be a number from 0 to %d", maxExplainRuns))
+			return
+		}
+		count = n
+	}
+
+	loc := time.Local
+	if name := query.Get("tz"); name != "" {
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("unknown time zone %q", name))
+			return
+		}
+	}
+
+	doc := explainDocument{Expression: expr, TimeZone: loc.String(), NextRuns: []string{}}
+	schedule, err := cronexpr.Parse(expr)
+	if err != nil {
+		doc.Error = err.Error()
+		writeJSON(w, http.StatusOK, doc)
+		return
+	}
+	doc.Valid = true
+	doc.Description = cronexpr.Describe(expr)
+	for _, run := range schedule.NextN(time.Now().In(loc), count) {
+		doc.NextRuns = append(doc.NextRuns, run.Format(time.RFC3339))
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// totalKoans counts the koans in every lesson
+func (s *Server) totalKoans() int {
+	total := 0
+	for _, lesson := range s.lessons {
+		total += len(lesson.Koans)
+	}
+	return total
+}
+
+// writeJSON answers with a JSON document
+func writeJSON(w http.ResponseWriter, status int, doc any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(doc)
+}
+
+// writeError answers with a JSON error document
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorDocument{Error: err.Error()})
+}
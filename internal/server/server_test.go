@@ -0,0 +1,435 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+)
+
+// testLessons are two small lessons; the second builds on the first
+var testLessons = fstest.MapFS{
+	"01_minutes.yaml": {Data: []byte(`title: "Minutes"
+description: "Minutes"
+koans:
+  - id: "minutes_1"
+    description: "Every minute"
+    question: "Every minute of every day"
+    incomplete: "__ * * * *"
+    answer: "*"
+    hints: ["The asterisk means every value", "Put * in the minute field", "Answer: *"]
+    explanation: "* in every field runs the job every minute."
+  - id: "minutes_2"
+    description: "Every 5 minutes"
+    question: "Run every 5 minutes"
+    incomplete: "__ * * * *"
+    answer: "*/5"
+    hints: ["Use the step operator", "Steps look like */n", "Answer: */5"]
+    explanation: "*/5 runs at minutes 0, 5, 10 and so on."
+`)},
+	"02_hours.yaml": {Data: []byte(`title: "Hours"
+description: "Hours"
+prerequisites: [minutes]
+koans:
+  - id: "hours_1"
+    description: "Business hours"
+    question: "At minute 0 from 9 AM to 5 PM"
+    incomplete: "0 __ * * *"
+    answer: "9-17"
+    tags: [ranges]
+    hints: ["Use a range of hours", "Ranges use a dash", "Answer: 9-17"]
+    explanation: "9-17 covers every hour from 9 through 17 inclusive."
+`)},
+}
+
+// newTestServer serves the test lessons with progress in a temporary directory
+func newTestServer(t *testing.T, tokens map[string]string) (*httptest.Server, string) {
+	t.Helper()
+	lessons, err := koan.LoadAllLessonsFS(testLessons)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataDir := t.TempDir()
+	ts := httptest.NewServer(New(lessons, "test", Options{DataDir: dataDir, Tokens: tokens}))
+	t.Cleanup(ts.Close)
+	return ts, dataDir
+}
+
+// call sends a request as user and decodes the JSON response into doc
+func call(t *testing.T, ts *httptest.Server, method, path, user, body string, doc any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user != "" {
+		req.Header.Set(UserHeader, user)
+	}
+	return do(t, req, doc)
+}
+
+// do sends a request and decodes the JSON response into doc
+func do(t *testing.T, req *http.Request, doc any) int {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "application/json" {
+		t.Fatalf("%s %s: Content-Type %q, want application/json", req.Method, req.URL.Path, got)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(doc); err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	return resp.StatusCode
+}
+
+func TestKoanAndAnswer(t *testing.T) {
+	ts, dataDir := newTestServer(t, nil)
+
+	var k koanDocument
+	if status := call(t, ts, "GET", "/api/koans/minutes_2", "alice", "", &k); status != http.StatusOK {
+		t.Fatalf("get koan: status %d", status)
+	}
+	if k.Number != 2 || k.Total != 3 || k.Question != "Run every 5 minutes" || k.Hints != 3 || k.Completed {
+		t.Errorf("get koan: unexpected %+v", k)
+	}
+	if k.Explanation != "" {
+		t.Errorf("get koan: explanation shown before the koan is complete")
+	}
+
+	var wrong answerDocument
+	call(t, ts, "POST", "/api/koans/minutes_2/answer", "alice", `{"answer": "5"}`, &wrong)
+	if wrong.Correct || wrong.Completed || wrong.Feedback == nil || wrong.Feedback.Message == "" || wrong.Attempts != 1 {
+		t.Errorf("wrong answer: unexpected %+v", wrong)
+	}
+
+	var right answerDocument
+	call(t, ts, "POST", "/api/koans/minutes_2/answer", "alice", `{"answer": " */5 "}`, &right)
+	if !right.Correct || !right.Completed || right.Expression != "*/5 * * * *" || right.Feedback != nil || right.Attempts != 2 {
+		t.Errorf("right answer: unexpected %+v", right)
+	}
+	if right.Explanation == "" {
+		t.Errorf("right answer: no explanation")
+	}
+
+	// Answering a complete koan again does not count as an attempt
+	var again answerDocument
+	call(t, ts, "POST", "/api/koans/minutes_2/answer", "alice", `{"answer": "*/5"}`, &again)
+	if again.Attempts != 2 {
+		t.Errorf("answer again: attempts %d, want 2", again.Attempts)
+	}
+
+	if _, err := os.Stat(filepath.Join(dataDir, "test", "alice.json")); err != nil {
+		t.Errorf("progress file: %v", err)
+	}
+}
+
+func TestHints(t *testing.T) {
+	ts, _ := newTestServer(t, nil)
+
+	for level, want := range []string{"The asterisk means every value", "Put * in the minute field", "Answer: *"} {
+		var h hintDocument
+		if status := call(t, ts, "POST", "/api/koans/minutes_1/hint", "alice", "", &h); status != http.StatusOK {
+			t.Fatalf("hint %d: status %d", level+1, status)
+		}
+		if h.Level != level+1 || h.Hint != want || h.Remaining != 2-level {
+			t.Errorf("hint %d: unexpected %+v", level+1, h)
+		}
+	}
+
+	var e errorDocument
+	if status := call(t, ts, "POST", "/api/koans/minutes_1/hint", "alice", "", &e); status != http.StatusConflict {
+		t.Errorf("fourth hint: status %d, want %d", status, http.StatusConflict)
+	}
+
+	var k koanDocument
+	call(t, ts, "GET", "/api/koans/minutes_1", "alice", "", &k)
+	if k.HintsUsed != 3 || len(k.Revealed) != 3 || k.Revealed[2] != "Answer: *" {
+		t.Errorf("after three hints: unexpected %+v", k)
+	}
+}
+
+func TestProgressIsPerUser(t *testing.T) {
+	ts, _ := newTestServer(t, nil)
+
+	var a answerDocument
+	call(t, ts, "POST", "/api/koans/minutes_1/answer", "alice", `{"answer": "*"}`, &a)
+	call(t, ts, "POST", "/api/koans/minutes_2/answer", "alice", `{"answer": "*/5"}`, &a)
+
+	var alice, bob statsDocument
+	call(t, ts, "GET", "/api/stats", "alice", "", &alice)
+	call(t, ts, "GET", "/api/stats", "bob", "", &bob)
+	if alice.Kind != KindStats || alice.Stats.CompletedKoans != 2 || alice.Stats.TotalKoans != 3 {
+		t.Errorf("alice: unexpected %+v", alice)
+	}
+	if bob.Stats.CompletedKoans != 0 {
+		t.Errorf("bob: completed %d, want 0", bob.Stats.CompletedKoans)
+	}
+
+	// Alice has unlocked the second lesson, so it is next
+	var next koanDocument
+	call(t, ts, "GET", "/api/koans/next", "alice", "", &next)
+	if next.ID != "hours_1" || !next.Unlocked || next.Lesson.Name != "hours" {
+		t.Errorf("alice next: unexpected %+v", next)
+	}
+	call(t, ts, "GET", "/api/koans/next", "bob", "", &next)
+	if next.ID != "minutes_1" {
+		t.Errorf("bob next: %s, want minutes_1", next.ID)
+	}
+
+	var lessons struct {
+		Lessons []struct {
+			Name           string `json:"name"`
+			Unlocked       bool   `json:"unlocked"`
+			CompletedKoans int    `json:"completed_koans"`
+		} `json:"lessons"`
+	}
+	call(t, ts, "GET", "/api/lessons", "bob", "", &lessons)
+	if len(lessons.Lessons) != 2 || lessons.Lessons[0].CompletedKoans != 0 || lessons.Lessons[1].Unlocked {
+		t.Errorf("bob lessons: unexpected %+v", lessons)
+	}
+}
+
+// TestSlowUserDoesNotBlockOthers sends an answer whose body never finishes
+// and checks that another learner is still served meanwhile
+func TestSlowUserDoesNotBlockOthers(t *testing.T) {
+	ts, _ := newTestServer(t, nil)
+
+	body, typed := io.Pipe()
+	defer typed.Close()
+	req, err := http.NewRequest("POST", ts.URL+"/api/koans/minutes_1/answer", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(UserHeader, "alice")
+	go func() {
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	if _, err := io.WriteString(typed, `{"answer": `); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan int, 1)
+	go func() {
+		var stats statsDocument
+		done <- call(t, ts, "GET", "/api/stats", "bob", "", &stats)
+	}()
+	select {
+	case status := <-done:
+		if status != http.StatusOK {
+			t.Errorf("bob: status %d, want %d", status, http.StatusOK)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("bob waited for alice's request")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ts, _ := newTestServer(t, nil)
+
+	tests := []struct {
+		name, method, path, user, body string
+		status                         int
+		message                        string
+	}{
+		{"no user", "GET", "/api/stats", "", "", http.StatusUnauthorized, "missing X-Cronkoans-User header"},
+		{"bad user", "GET", "/api/stats", "../etc", "", http.StatusUnauthorized, "invalid user name"},
+		{"unknown koan", "GET", "/api/koans/nope", "alice", "", http.StatusNotFound, `koan "nope" not found`},
+		{"bad body", "POST", "/api/koans/minutes_1/answer", "alice", "answer", http.StatusBadRequest, "invalid request body"},
+		{"unknown endpoint", "GET", "/api/nope", "alice", "", http.StatusNotFound, "no such endpoint: /api/nope"},
+		{"empty answer", "POST", "/api/koans/minutes_1/answer", "alice", `{"answer": ""}`, http.StatusBadRequest, "answer is required"},
+		{"large body", "POST", "/api/koans/minutes_1/answer", "alice", `{"answer": "` + strings.Repeat("*", maxAnswerBytes) + `"}`, http.StatusRequestEntityTooLarge, "request body is larger than 4096 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e errorDocument
+			if status := call(t, ts, tt.method, tt.path, tt.user, tt.body, &e); status != tt.status {
+				t.Errorf("status %d, want %d", status, tt.status)
+			}
+			if !strings.Contains(e.Error, tt.message) {
+				t.Errorf("error %q, want it to contain %q", e.Error, tt.message)
+			}
+		})
+	}
+}
+
+func TestTokens(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tokens.yaml")
+	if err := os.WriteFile(file, []byte("alice: secret-a\nbob: secret-b\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := LoadTokens(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, _ := newTestServer(t, tokens)
+
+	request := func(token, user string) *http.Request {
+		req, err := http.NewRequest("POST", ts.URL+"/api/koans/minutes_1/answer", strings.NewReader(`{"answer": "*"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.Header.Set(UserHeader, user)
+		return req
+	}
+
+	var e errorDocument
+	if status := do(t, request("", "alice"), &e); status != http.StatusUnauthorized || e.Error != "missing bearer token" {
+		t.Errorf("header without token: status %d, error %q", status, e.Error)
+	}
+	if status := do(t, request("guess", "alice"), &e); status != http.StatusUnauthorized || e.Error != "unknown token" {
+		t.Errorf("unknown token: status %d, error %q", status, e.Error)
+	}
+
+	// The token decides the user, whatever the header says
+	var a answerDocument
+	do(t, request("secret-b", "alice"), &a)
+	req := mustRequest(t, "GET", ts.URL+"/api/stats")
+	req.Header.Set("Authorization", "Bearer secret-a")
+	var stats statsDocument
+	do(t, req, &stats)
+	if stats.Stats.CompletedKoans != 0 {
+		t.Errorf("alice completed %d koans, want 0", stats.Stats.CompletedKoans)
+	}
+
+	if err := os.WriteFile(file, []byte("alice: same\nbob: same\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTokens(file); err == nil || !strings.Contains(err.Error(), "have the same token") {
+		t.Errorf("duplicate tokens: got %v", err)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	ts, _ := newTestServer(t, nil)
+
+	var p previewDocument
+	if status := call(t, ts, "GET", "/api/koans/hours_1/preview?answer=9-17", "alice", "", &p); status != http.StatusOK {
+		t.Fatalf("preview: status %d", status)
+	}
+	if !p.Valid || p.Expression != "0 9-17 * * *" || p.Description == "" || len(p.NextRuns) != 3 {
+		t.Errorf("preview: unexpected %+v", p)
+	}
+
+	call(t, ts, "GET", "/api/koans/hours_1/preview?answer=25", "alice", "", &p)
+	if p.Valid || p.Error == "" || len(p.NextRuns) != 0 {
+		t.Errorf("invalid preview: unexpected %+v", p)
+	}
+
+	// Previews are not attempts
+	var k koanDocument
+	call(t, ts, "GET", "/api/koans/hours_1", "alice", "", &k)
+	if k.Attempts != 0 {
+		t.Errorf("attempts %d after previews, want 0", k.Attempts)
+	}
+}
+
+func TestWebInterface(t *testing.T) {
+	ts, _ := newTestServer(t, map[string]string{"secret": "alice"})
+
+	// The interface asks how to sign in before anyone has
+	var info infoDocument
+	if status := do(t, mustRequest(t, "GET", ts.URL+"/api/info"), &info); status != http.StatusOK {
+		t.Fatalf("info: status %d", status)
+	}
+	if info.Pack != "test" || info.Auth != authToken {
+		t.Errorf("info: unexpected %+v", info)
+	}
+
+	for _, tt := range []struct{ path, contentType, want string }{
+		{"/", "text/html", `<script src="app.js">`},
+		{"/app.js", "text/javascript", "/api/koans/next"},
+		{"/style.css", "text/css", "prefers-color-scheme"},
+	} {
+		resp, err := http.Get(ts.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), tt.contentType) {
+			t.Errorf("%s: status %d, Content-Type %q", tt.path, resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+		if !strings.Contains(string(body), tt.want) {
+			t.Errorf("%s: does not contain %q", tt.path, tt.want)
+		}
+	}
+}
+
+// TestWebInterfaceIsSelfContained checks that the web interface loads nothing
+// from other hosts, so that it works offline
+func TestWebInterfaceIsSelfContained(t *testing.T) {
+	external := regexp.MustCompile(`(?i)(src|href)\s*=\s*["']?(https?:)?//|@import|url\(\s*["']?(https?:)?//`)
+	err := fs.WalkDir(webFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(webFS, path)
+		if err != nil {
+			return err
+		}
+		if match := external.Find(data); match != nil {
+			t.Errorf("%s loads from another host: %s", path, match)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mustRequest creates a request without a body
+func mustRequest(t *testing.T, method, url string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestExplain(t *testing.T) {
+	ts, _ := newTestServer(t, map[string]string{"secret": "alice"})
+
+	// Explaining needs no learner, even when the server has tokens
+	var e explainDocument
+	if status := do(t, mustRequest(t, "GET", ts.URL+"/api/cron/explain?expression=*/15+9-17+*+*+1-5&count=2&tz=UTC"), &e); status != http.StatusOK {
+		t.Fatalf("explain: status %d", status)
+	}
+	if !e.Valid || e.Description != "at minute every 15, hour 9-17, on weekday 1-5" || e.TimeZone != "UTC" || len(e.NextRuns) != 2 {
+		t.Errorf("explain: unexpected %+v", e)
+	}
+
+	do(t, mustRequest(t, "GET", ts.URL+"/api/cron/explain?expression=61+*+*+*+*"), &e)
+	if e.Valid || !strings.Contains(e.Error, "out of bounds") || len(e.NextRuns) != 0 {
+		t.Errorf("invalid expression: unexpected %+v", e)
+	}
+
+	for _, query := range []string{"", "expression=*+*+*+*+*&count=500", "expression=*+*+*+*+*&tz=Mars/Olympus"} {
+		var errDoc errorDocument
+		if status := do(t, mustRequest(t, "GET", ts.URL+"/api/cron/explain?"+query), &errDoc); status != http.StatusBadRequest {
+			t.Errorf("explain?%s: status %d, want %d", query, status, http.StatusBadRequest)
+		}
+	}
+}
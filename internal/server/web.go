@@ -0,0 +1,13 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed web
+var webFiles embed.FS
+
+// webFS is the web interface: static files that talk to the API, with no
+// dependencies outside the binary so that it works offline
+var webFS, _ = fs.Sub(webFiles, "web")
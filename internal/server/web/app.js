@@ -0,0 +1,312 @@
+// The cronkoans web interface. It talks only to the JSON API of the server
+// it is served from, so it needs no network access beyond that.
+"use strict";
+
+const storageKey = "cronkoans.identity";
+
+const state = {
+  auth: "header", // "header" or "token", from /api/info
+  identity: localStorage.getItem(storageKey) || "",
+  lessons: [],
+  koan: null,
+  previewTimer: 0,
+};
+
+const $ = (id) => document.getElementById(id);
+
+// api calls an endpoint as the signed-in learner and returns the decoded JSON.
+// Errors carry the HTTP status and the message from the server.
+async function api(method, path, body) {
+  const headers = {};
+  if (state.auth === "token") {
+    headers["Authorization"] = "Bearer " + state.identity;
+  } else {
+    headers["X-Cronkoans-User"] = state.identity;
+  }
+  if (body !== undefined) {
+    headers["Content-Type"] = "application/json";
+  }
+  const resp = await fetch(path, {
+    method,
+    headers,
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+  const doc = await resp.json();
+  if (!resp.ok) {
+    const err = new Error(doc.error || resp.statusText);
+    err.status = resp.status;
+    throw err;
+  }
+  return doc;
+}
+
+// element creates an element with a class and text
+function element(tag, className, text) {
+  const el = document.createElement(tag);
+  if (className) {
+    el.className = className;
+  }
+  if (text !== undefined) {
+    el.textContent = text;
+  }
+  return el;
+}
+
+function showSignIn(message) {
+  $("app").hidden = true;
+  $("account").hidden = true;
+  $("sign-in").hidden = false;
+  $("identity-label").textContent = state.auth === "token" ? "Your token" : "Your name";
+  $("identity").type = state.auth === "token" ? "password" : "text";
+  $("sign-in-error").textContent = message || "";
+  $("identity").focus();
+}
+
+async function signIn(identity) {
+  state.identity = identity;
+  try {
+    await refresh();
+  } catch (err) {
+    state.identity = "";
+    showSignIn(err.message);
+    return;
+  }
+  localStorage.setItem(storageKey, identity);
+  $("sign-in").hidden = true;
+  $("app").hidden = false;
+  $("account").hidden = false;
+  $("who").textContent = state.auth === "token" ? "Signed in" : "Learning as " + identity;
+  await openNext();
+}
+
+function signOut() {
+  localStorage.removeItem(storageKey);
+  state.identity = "";
+  state.koan = null;
+  showSignIn();
+}
+
+// refresh reloads the lesson list and the learner's stats
+async function refresh() {
+  const [lessons, stats] = await Promise.all([api("GET", "/api/lessons"), api("GET", "/api/stats")]);
+  state.lessons = lessons.lessons;
+  const s = stats.stats;
+  $("stats").textContent = `${s.completed_koans} of ${s.total_koans} koans (${Math.round(s.percent_complete)}%)`;
+  renderLessons();
+}
+
+function renderLessons() {
+  const list = $("lessons");
+  list.replaceChildren();
+  for (const lesson of state.lessons) {
+    const item = element("li");
+    if (state.koan && state.koan.lesson.name === lesson.name) {
+      item.classList.add("current");
+    }
+    const button = element("button");
+    button.type = "button";
+    button.append(element("span", "title", `${lesson.number}. ${lesson.title}`));
+
+    let status = `${lesson.completed_koans}/${lesson.total_koans} koans`;
+    if (!lesson.unlocked) {
+      status += " · locked";
+    } else if (lesson.next) {
+      status += " · next";
+    }
+    button.append(element("span", "status", status));
+
+    const bar = element("progress");
+    bar.max = lesson.total_koans;
+    bar.value = lesson.completed_koans;
+    bar.setAttribute("aria-label", `${lesson.title}: ${Math.round(lesson.percent_complete)}% complete`);
+    button.append(bar);
+
+    button.addEventListener("click", () => openLesson(lesson));
+    item.append(button);
+    list.append(item);
+  }
+}
+
+// openLesson opens the first unfinished koan of a lesson, or its first koan
+function openLesson(lesson) {
+  const koan = lesson.koans.find((k) => !k.completed) || lesson.koans[0];
+  if (koan) {
+    openKoan(koan.id);
+  }
+}
+
+async function openKoan(id) {
+  try {
+    showKoan(await api("GET", "/api/koans/" + encodeURIComponent(id)));
+  } catch (err) {
+    showError(err);
+  }
+}
+
+async function openNext() {
+  try {
+    showKoan(await api("GET", "/api/koans/next"));
+  } catch (err) {
+    if (err.status === 404) {
+      state.koan = null;
+      $("card").hidden = true;
+      $("all-done").hidden = false;
+      renderLessons();
+      return;
+    }
+    showError(err);
+  }
+}
+
+// openFollowing opens the next unfinished koan after the current one in the
+// curriculum, or the suggested one when there is none
+function openFollowing() {
+  const koans = state.lessons.flatMap((lesson) => lesson.koans);
+  const index = koans.findIndex((k) => k.id === state.koan.id);
+  const next = koans.slice(index + 1).find((k) => !k.completed);
+  if (next) {
+    openKoan(next.id);
+  } else {
+    openNext();
+  }
+}
+
+function showKoan(koan) {
+  state.koan = koan;
+  $("all-done").hidden = true;
+  $("card").hidden = false;
+  $("lesson-title").textContent = koan.lesson.title;
+  $("number").textContent = koan.number;
+  $("total").textContent = koan.total;
+  $("locked").hidden = koan.unlocked;
+  $("description").textContent = koan.description;
+  $("question").textContent = koan.question;
+  $("incomplete").textContent = koan.incomplete;
+
+  $("hints").replaceChildren(...koan.revealed_hints.map((hint) => element("li", "", hint)));
+  $("hint").disabled = koan.hints_used >= koan.hints;
+  $("feedback").hidden = true;
+  $("tester").replaceChildren();
+  $("answer").value = "";
+
+  $("solved").hidden = !koan.completed;
+  $("expression").textContent = "";
+  $("explanation").textContent = koan.explanation || "";
+  $("explanation").hidden = true;
+  $("reveal").hidden = !koan.explanation;
+
+  renderLessons();
+  if (!koan.completed) {
+    $("answer").focus();
+  }
+}
+
+function showError(err) {
+  if (err.status === 401) {
+    signOut();
+    $("sign-in-error").textContent = err.message;
+    return;
+  }
+  $("feedback").textContent = err.message;
+  $("feedback").hidden = false;
+}
+
+async function submitAnswer(event) {
+  event.preventDefault();
+  const answer = $("answer").value.trim();
+  if (!answer || !state.koan) {
+    return;
+  }
+  try {
+    const result = await api("POST", `/api/koans/${encodeURIComponent(state.koan.id)}/answer`, { answer });
+    if (!result.correct) {
+      $("feedback").textContent = "Not quite. " + result.feedback.message;
+      $("feedback").hidden = false;
+      return;
+    }
+    $("feedback").hidden = true;
+    $("solved").hidden = false;
+    $("expression").textContent = result.expression;
+    $("explanation").textContent = result.explanation || "";
+    $("explanation").hidden = true;
+    $("reveal").hidden = !result.explanation;
+    $("reveal").focus();
+    await refresh();
+  } catch (err) {
+    showError(err);
+  }
+}
+
+async function showHint() {
+  try {
+    const result = await api("POST", `/api/koans/${encodeURIComponent(state.koan.id)}/hint`);
+    $("hints").append(element("li", "", result.hint));
+    $("hint").disabled = result.remaining === 0;
+  } catch (err) {
+    showError(err);
+  }
+}
+
+// preview runs the live expression tester for the answer being typed
+async function preview() {
+  const tester = $("tester");
+  const answer = $("answer").value.trim();
+  if (!answer || !state.koan) {
+    tester.replaceChildren();
+    return;
+  }
+  let result;
+  try {
+    result = await api("GET", `/api/koans/${encodeURIComponent(state.koan.id)}/preview?answer=${encodeURIComponent(answer)}`);
+  } catch (err) {
+    tester.replaceChildren(element("p", "", err.status === 403 ? "Preview is disabled for this koan." : err.message));
+    return;
+  }
+  if (answer !== $("answer").value.trim()) {
+    return; // a newer preview is on its way
+  }
+  if (!result.valid) {
+    tester.replaceChildren(element("p", "invalid", `✗ ${result.expression} — ${result.error}`));
+    return;
+  }
+
+  const summary = element("p");
+  summary.append(element("span", "valid", `✓ ${result.expression}`), ` — ${result.description}`);
+  const runs = element("ul");
+  if (result.next_runs.length === 0) {
+    runs.append(element("li", "", "Never fires"));
+  }
+  for (const run of result.next_runs) {
+    runs.append(element("li", "", "Next: " + new Date(run).toLocaleString()));
+  }
+  tester.replaceChildren(summary, runs);
+}
+
+async function start() {
+  $("sign-in").addEventListener("submit", (event) => {
+    event.preventDefault();
+    signIn($("identity").value.trim());
+  });
+  $("sign-out").addEventListener("click", signOut);
+  $("answer-form").addEventListener("submit", submitAnswer);
+  $("hint").addEventListener("click", showHint);
+  $("answer").addEventListener("input", () => {
+    clearTimeout(state.previewTimer);
+    state.previewTimer = setTimeout(preview, 150);
+  });
+  $("reveal").addEventListener("click", () => {
+    $("explanation").hidden = false;
+    $("reveal").hidden = true;
+  });
+  $("next").addEventListener("click", openFollowing);
+
+  const info = await (await fetch("/api/info")).json();
+  state.auth = info.auth;
+  if (state.identity) {
+    signIn(state.identity);
+  } else {
+    showSignIn();
+  }
+}
+
+start();
@@ -0,0 +1,221 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ansiPattern matches the escape sequences used for colors
+var ansiPattern = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// render redraws the whole screen from the current state
+func (a *App) render() {
+	t := a.theme
+	mainWidth := a.width - sidebarWidth - 3
+	if mainWidth < 20 {
+		mainWidth = 20
+	}
+	bodyHeight := a.height - 1
+
+	sidebar := a.sidebarLines()
+	main, cursorRow, cursorCol := a.mainLines(mainWidth)
+
+	var sb strings.Builder
+	sb.WriteString("\033[H\033[2J")
+	for row := 0; row < bodyHeight; row++ {
+		left, right := "", ""
+		if row < len(sidebar) {
+			left = sidebar[row]
+		}
+		if row < len(main) {
+			right = main[row]
+		}
+		sb.WriteString(a.pad(left, sidebarWidth))
+		sb.WriteString(t.Muted + " " + t.Glyphs.Divider + " " + t.Reset)
+		sb.WriteString(a.truncate(right, mainWidth))
+		sb.WriteString("\r\n")
+	}
+
+	// Status bar
+	stats := a.tracker.GetStats(len(a.koans))
+	keyHelp := strings.Join(keyBindings, " "+t.Glyphs.Separator+" ")
+	status := fmt.Sprintf(" %s   %d/%d complete ", keyHelp, stats.CompletedKoans, stats.TotalKoans)
+	if t.Reset != "" {
+		sb.WriteString("\033[7m" + a.pad(status, a.width) + t.Reset)
+	} else {
+		sb.WriteString(a.pad(status, a.width))
+	}
+
+	// Park the cursor on the input line
+	if cursorRow >= 0 && cursorRow < bodyHeight {
+		sb.WriteString(fmt.Sprintf("\033[%d;%dH\033[?25h", cursorRow+1, sidebarWidth+3+cursorCol+1))
+	} else {
+		sb.WriteString("\033[?25l")
+	}
+
+	fmt.Fprint(a.out, sb.String())
+}
+
+// sidebarLines lists the lessons with their completion counts
+func (a *App) sidebarLines() []string {
+	t := a.theme
+	lines := []string{t.Bold + " Lessons" + t.Reset, ""}
+	currentLesson := a.owner[a.current]
+
+	for i, lesson := range a.lessons {
+		completed := 0
+		for _, k := range lesson.Koans {
+			if a.tracker.IsCompleted(k.ID) {
+				completed++
+			}
+		}
+		count := fmt.Sprintf("%d/%d", completed, len(lesson.Koans))
+
+		marker := "  "
+		if i == currentLesson && a.state != stateFinished {
+			marker = t.Glyphs.Pointer + " "
+		}
+
+		color := t.Muted
+		if completed == len(lesson.Koans) {
+			color = t.Success
+		} else if completed > 0 {
+			color = t.Hint
+		}
+
+		titleWidth := sidebarWidth - visibleLen(marker) - len(count) - 1
+		title := a.truncate(fmt.Sprintf("%d. %s", i+1, lesson.Title), titleWidth-1)
+		line := marker + a.pad(title, titleWidth) + " " + color + count + t.Reset
+		if i == currentLesson && a.state != stateFinished {
+			line = t.Bold + line + t.Reset
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// mainLines builds the koan pane and reports where the input cursor belongs
+func (a *App) mainLines(width int) ([]string, int, int) {
+	t := a.theme
+	if a.state == stateFinished {
+		return []string{
+			"",
+			t.Success + t.Bold + "Congratulations! You have completed all Cron Koans." + t.Reset,
+			"",
+			"Press Esc to quit.",
+		}, -1, 0
+	}
+
+	k := &a.koans[a.current]
+	lesson := a.lessons[a.owner[a.current]]
+
+	var lines []string
+	lines = append(lines, t.Bold+fmt.Sprintf("Koan %d/%d", a.current+1, len(a.koans))+t.Reset+
+		t.Muted+" "+t.Glyphs.Separator+" "+lesson.Title+t.Reset)
+	lines = append(lines, t.Info+k.Description+t.Reset, "")
+	lines = append(lines, wrap("Question: "+k.Question, width)...)
+	lines = append(lines, "", t.Hint+"Expression: "+t.Bold+k.Incomplete+t.Reset, "")
+
+	prompt := "Answer: "
+	cursorRow := len(lines)
+	cursorCol := utf8.RuneCountInString(prompt) + len(a.input)
+	lines = append(lines, t.Bold+prompt+t.Reset+string(a.input), "")
+
+	if a.state == stateAnswering {
+		lines = append(lines, t.PreviewLines(k, string(a.input))...)
+		lines = append(lines, "")
+	} else {
+		cursorRow = -1
+	}
+
+	for i, hint := range a.hints {
+		for _, l := range wrap(fmt.Sprintf("Hint %d: %s", i+1, hint), width) {
+			lines = append(lines, t.Hint+l+t.Reset)
+		}
+	}
+	if len(a.hints) > 0 {
+		lines = append(lines, "")
+	}
+
+	if a.message != "" {
+		color := t.Success
+		if a.isError {
+			color = t.Error
+		}
+		for _, l := range wrap(a.message, width) {
+			lines = append(lines, color+l+t.Reset)
+		}
+		if a.state == stateSolved {
+			lines = append(lines, "", t.Muted+"Press Enter for the next koan."+t.Reset)
+		}
+	}
+
+	return lines, cursorRow, cursorCol
+}
+
+// visibleLen returns the display width of s, ignoring color codes
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(ansiPattern.ReplaceAllString(s, ""))
+}
+
+// pad right-pads s with spaces to the given display width
+func (a *App) pad(s string, width int) string {
+	if n := visibleLen(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return a.truncate(s, width)
+}
+
+// truncate shortens s to the given display width, keeping color codes intact
+// and marking the cut with the theme's ellipsis
+func (a *App) truncate(s string, width int) string {
+	if visibleLen(s) <= width {
+		return s
+	}
+	ellipsis := a.theme.Glyphs.Ellipsis
+	keep := width - utf8.RuneCountInString(ellipsis)
+
+	var sb strings.Builder
+	visible := 0
+	for i := 0; i < len(s); {
+		if loc := ansiPattern.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+			sb.WriteString(s[i : i+loc[1]])
+			i += loc[1]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if visible >= keep {
+			sb.WriteString(ellipsis)
+			break
+		}
+		sb.WriteRune(r)
+		visible++
+		i += size
+	}
+	if strings.Contains(s, "\033") {
+		sb.WriteString("\033[0m")
+	}
+	return sb.String()
+}
+
+// wrap splits text into lines no wider than width, breaking on spaces
+func wrap(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	line := words[0]
+	for _, w := range words[1:] {
+		if utf8.RuneCountInString(line)+1+utf8.RuneCountInString(w) > width {
+			lines = append(lines, line)
+			line = w
+			continue
+		}
+		line += " " + w
+	}
+	return append(lines, line)
+}
@@ -0,0 +1,257 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/internal/progress"
+	"github.com/dwildt/cronkoans/internal/ui"
+)
+
+// Key codes read from the terminal in raw mode
+const (
+	keyCtrlC     = 0x03
+	keyTab       = 0x09
+	keyEnter     = 0x0d
+	keyCtrlN     = 0x0e
+	keyBackspace = 0x7f
+	keyCtrlH     = 0x08
+)
+
+// keyBindings are shown in the status bar
+var keyBindings = []string{"Enter submit", "Tab hint", "Ctrl-N skip", "Esc quit"}
+
+// sidebarWidth is the number of columns used by the lesson sidebar
+const sidebarWidth = 32
+
+// state is the phase of the current koan
+type state int
+
+const (
+	stateAnswering state = iota
+	stateSolved
+	stateFinished
+)
+
+// App is a full-screen terminal interface for working through koans
+type App struct {
+	in      io.Reader
+	out     io.Writer
+	lessons []*koan.Lesson
+	tracker *progress.Tracker
+	theme   *ui.Theme
+	koans   []koan.Koan
+	owner   []int // index of the lesson each koan belongs to
+
+	current   int
+	input     []rune
+	hints     []string
+	attempts  int
+	hintsUsed int
+	message   string
+	isError   bool
+	state     state
+
+	width  int
+	height int
+}
+
+// New creates a TUI application over the loaded lessons that reads keys from
+// in and draws on out. Run needs both to be a terminal.
+func New(in io.Reader, out io.Writer, lessons []*koan.Lesson, tracker *progress.Tracker, theme *ui.Theme) *App {
+	app := &App{
+		in:      in,
+		out:     out,
+		lessons: lessons,
+		tracker: tracker,
+		theme:   theme,
+	}
+	for i, lesson := range lessons {
+		for _, k := range lesson.Koans {
+			app.koans = append(app.koans, k)
+			app.owner = append(app.owner, i)
+		}
+	}
+	return app
+}
+
+// Run takes over the terminal until the learner quits or finishes all koans
+func (a *App) Run() error {
+	in, inFile := a.in.(*os.File)
+	out, outFile := a.out.(*os.File)
+	if !inFile || !outFile || !term.IsTerminal(int(in.Fd())) || !term.IsTerminal(int(out.Fd())) {
+		return fmt.Errorf("--tui requires an interactive terminal")
+	}
+	if len(a.koans) == 0 {
+		return fmt.Errorf("no koans found")
+	}
+
+	fd := int(in.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to switch terminal to raw mode: %w", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	// Use the alternate screen so the learner's scrollback is left intact
+	fmt.Fprint(a.out, "\033[?1049h")
+	defer fmt.Fprint(a.out, "\033[?1049l")
+
+	return a.loop(func() (int, int) {
+		width, height, err := term.GetSize(int(out.Fd()))
+		if err != nil || width <= 0 || height <= 0 {
+			return 80, 24
+		}
+		return width, height
+	})
+}
+
+// loop redraws the screen at the size reported by size and handles each read
+// from the input, until the learner quits or the input ends
+func (a *App) loop(size func() (int, int)) error {
+	a.current = a.firstIncomplete()
+	if a.current < 0 {
+		a.state = stateFinished
+		a.current = len(a.koans) - 1
+	}
+
+	buf := make([]byte, 64)
+	for {
+		a.width, a.height = size()
+		a.render()
+
+		n, err := a.in.Read(buf)
+		if n > 0 && a.handleInput(buf[:n]) {
+			return nil
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// firstIncomplete returns the index of the first koan not yet completed, or -1
+func (a *App) firstIncomplete() int {
+	for i, k := range a.koans {
+		if !a.tracker.IsCompleted(k.ID) {
+			return i
+		}
+	}
+	return -1
+}
+
+// handleInput processes one read from the terminal and reports whether to quit
+func (a *App) handleInput(data []byte) bool {
+	// Arrow keys and friends send escape sequences, which are skipped, while
+	// Escape on its own quits once the keys before it are handled
+	keys, escape := ui.StripEscapes(data)
+	for _, r := range string(keys) {
+		switch r {
+		case keyCtrlC:
+			return true
+		case keyEnter, '\n':
+			a.submit()
+		case keyTab:
+			a.showHint()
+		case keyCtrlN:
+			a.skip()
+		case keyBackspace, keyCtrlH:
+			if len(a.input) > 0 {
+				a.input = a.input[:len(a.input)-1]
+			}
+		default:
+			if a.state == stateAnswering && r >= ' ' {
+				a.input = append(a.input, r)
+			}
+		}
+	}
+	return escape
+}
+
+// submit checks the typed answer, or moves on after a solved koan
+func (a *App) submit() {
+	switch a.state {
+	case stateSolved:
+		a.advance()
+		return
+	case stateFinished:
+		return
+	}
+
+	k := &a.koans[a.current]
+	answer := strings.TrimSpace(string(a.input))
+	if answer == "" {
+		return
+	}
+
+	a.attempts++
+	a.tracker.RecordAttempt(k.ID)
+
+	if k.CheckAnswer(answer) {
+		if err := a.tracker.MarkCompleted(k.ID, a.attempts, a.hintsUsed); err != nil {
+			a.message, a.isError = fmt.Sprintf("Failed to save progress: %v", err), true
+			return
+		}
+		a.state = stateSolved
+		a.message, a.isError = a.theme.Glyphs.Check+" Correct! "+k.Explanation, false
+		return
+	}
+
+	a.message, a.isError = a.theme.Glyphs.Cross+" "+k.AnalyzeAnswer(answer).Message, true
+}
+
+// showHint reveals the next hint for the current koan
+func (a *App) showHint() {
+	if a.state != stateAnswering {
+		return
+	}
+	k := &a.koans[a.current]
+	hint := k.GetHint(len(a.hints))
+	if hint == "" {
+		a.message, a.isError = "No more hints available for this koan.", false
+		return
+	}
+	a.hints = append(a.hints, hint)
+	a.hintsUsed++
+	a.tracker.RecordHint(k.ID)
+}
+
+// skip moves to the next koan without completing the current one
+func (a *App) skip() {
+	if a.state == stateAnswering {
+		a.advance()
+	}
+}
+
+// advance moves to the next incomplete koan after the current one
+func (a *App) advance() {
+	a.input = nil
+	a.hints = nil
+	a.attempts = 0
+	a.hintsUsed = 0
+	a.message = ""
+	a.state = stateAnswering
+
+	for i := a.current + 1; i < len(a.koans); i++ {
+		if !a.tracker.IsCompleted(a.koans[i].ID) {
+			a.current = i
+			return
+		}
+	}
+
+	// Wrap around to koans that were skipped earlier
+	if next := a.firstIncomplete(); next >= 0 {
+		a.current = next
+		return
+	}
+	a.state = stateFinished
+}
@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/internal/progress"
+	"github.com/dwildt/cronkoans/internal/ui"
+)
+
+// testLessons are two small lessons with one koan each
+var testLessons = []*koan.Lesson{
+	{Title: "Minutes", Koans: []koan.Koan{{
+		ID: "minutes_1", Description: "Every minute", Question: "Every minute of every day",
+		Incomplete: "__ * * * *", Answer: "*", Hints: []string{"The asterisk means every value"},
+		Explanation: "* in every field runs the job every minute.",
+	}}},
+	{Title: "Hours", Koans: []koan.Koan{{
+		ID: "hours_1", Description: "Business hours", Question: "At minute 0 from 9 AM to 5 PM",
+		Incomplete: "0 __ * * *", Answer: "9-17", Hints: []string{"Use a range of hours"},
+		Explanation: "9-17 covers every hour from 9 through 17 inclusive.",
+	}}},
+}
+
+// keys hands out one read from the terminal per call to Read
+type keys []string
+
+func (k *keys) Read(p []byte) (int, error) {
+	if len(*k) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, (*k)[0])
+	*k = (*k)[1:]
+	return n, nil
+}
+
+// runApp runs the TUI over the test lessons with the given reads and returns
+// every screen it drew, and the progress it saved
+func runApp(t *testing.T, reads ...string) ([]string, *progress.Tracker) {
+	t.Helper()
+	tracker, err := progress.NewTrackerAt(filepath.Join(t.TempDir(), "progress.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := keys(reads)
+	var out strings.Builder
+	app := New(&in, &out, testLessons, tracker, ui.PlainTheme(ui.ASCIIGlyphs))
+	if err := app.loop(func() (int, int) { return 100, 30 }); err != nil {
+		t.Fatal(err)
+	}
+
+	screens := strings.Split(out.String(), "\033[H\033[2J")
+	return screens[1:], tracker
+}
+
+func TestAppSession(t *testing.T) {
+	screens, tracker := runApp(t, "\t", "5", "\r", "\x7f*", "\r", "\r", "9-17\r", "\r", "\x1b")
+
+	tests := []struct {
+		screen int
+		want   string
+	}{
+		{0, "Koan 1/2"},
+		{1, "Hint 1: The asterisk means every value"},
+		{2, "Answer: 5"},
+		{3, "describes a different schedule"},
+		{4, "Answer: *"},
+		{5, "Correct! * in every field runs the job every minute."},
+		{5, "Press Enter for the next koan."},
+		{6, "Koan 2/2"},
+		{7, "Correct! 9-17 covers every hour"},
+		{8, "Congratulations! You have completed all Cron Koans."},
+	}
+	for _, tt := range tests {
+		if tt.screen >= len(screens) || !strings.Contains(screens[tt.screen], tt.want) {
+			t.Errorf("screen %d does not show %q", tt.screen, tt.want)
+		}
+	}
+	if len(screens) != 9 {
+		t.Errorf("drew %d screens, want 9", len(screens))
+	}
+
+	for _, id := range []string{"minutes_1", "hours_1"} {
+		if !tracker.IsCompleted(id) {
+			t.Errorf("%s is not completed", id)
+		}
+	}
+}
+
+func TestAppSkip(t *testing.T) {
+	screens, tracker := runApp(t, "\x0e", "\x0e", "\x1b")
+	for i, want := range []string{"Koan 1/2", "Koan 2/2", "Koan 1/2"} {
+		if !strings.Contains(screens[i], want) {
+			t.Errorf("screen %d does not show %q", i, want)
+		}
+	}
+	if tracker.IsCompleted("minutes_1") {
+		t.Error("skipping completed minutes_1")
+	}
+}
+
+func TestAppQuits(t *testing.T) {
+	tests := []struct {
+		name    string
+		reads   []string
+		screens int
+	}{
+		{"Esc", []string{"\x1b"}, 1},
+		{"Ctrl-C", []string{"9\x03"}, 1},
+		{"end of the input", nil, 1},
+		{"Esc after a skip", []string{"\x0e", "\x1b"}, 2},
+		{"Esc after solving", []string{"*\r", "\x1b"}, 2},
+	}
+	for _, tt := range tests {
+		if screens, _ := runApp(t, tt.reads...); len(screens) != tt.screens {
+			t.Errorf("%s: drew %d screens, want %d", tt.name, len(screens), tt.screens)
+		}
+	}
+}
+
+func TestSidebarShowsProgress(t *testing.T) {
+	screens, _ := runApp(t, "*\r", "\r", "\x1b")
+	last := screens[len(screens)-1]
+	for _, want := range []string{"Lessons", "1. Minutes", "1/1", "> 2. Hours", "0/1", "1/2 complete"} {
+		if !strings.Contains(last, want) {
+			t.Errorf("sidebar does not show %q:\n%s", want, last)
+		}
+	}
+}
+
+func TestASCIIScreens(t *testing.T) {
+	screens, _ := runApp(t, "\t", "5\r", "\x1b")
+	for i, screen := range screens {
+		for _, r := range screen {
+			if r > unicode.MaxASCII {
+				t.Errorf("screen %d has %q, which is not ASCII", i, r)
+				break
+			}
+		}
+	}
+	if !strings.Contains(screens[0], "Enter submit - Tab hint - Ctrl-N skip - Esc quit") {
+		t.Error("the status bar does not separate key bindings with -")
+	}
+
+	tests := []struct {
+		glyphs ui.Glyphs
+		want   string
+	}{
+		{ui.ASCIIGlyphs, "Every m..."},
+		{ui.UnicodeGlyphs, "Every min…"},
+	}
+	for _, tt := range tests {
+		app := New(nil, nil, testLessons, nil, ui.PlainTheme(tt.glyphs))
+		if got := app.truncate("Every minute of every day", 10); got != tt.want {
+			t.Errorf("truncate = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestEscapeSequences(t *testing.T) {
+	tests := []struct {
+		name  string
+		reads []string
+		want  string // on the last screen
+	}{
+		{"arrow key then a typed key", []string{"\x1b[D*", "\x1b"}, "Answer: *"},
+		{"keys pasted around arrow keys", []string{"9\x1b[A-1\x1bOB7", "\x1b"}, "Answer: 9-17"},
+		{"a control sequence with parameters", []string{"*\x1b[1;5C\r", "\x1b"}, "Correct!"},
+		{"Alt with a key", []string{"\x1bx*", "\x1b"}, "Answer: *"},
+	}
+	for _, tt := range tests {
+		screens, _ := runApp(t, tt.reads...)
+		if len(screens) != len(tt.reads) {
+			t.Errorf("%s: drew %d screens, want %d", tt.name, len(screens), len(tt.reads))
+			continue
+		}
+		if last := screens[len(screens)-1]; !strings.Contains(last, tt.want) {
+			t.Errorf("%s: the screen does not show %q", tt.name, tt.want)
+		}
+	}
+
+	// Escape at the end of a read quits after the keys before it
+	screens, tracker := runApp(t, "*\r\x1b")
+	if len(screens) != 1 || !tracker.IsCompleted("minutes_1") {
+		t.Errorf("*, Enter and Escape in one read: drew %d screens, completed %v", len(screens), tracker.IsCompleted("minutes_1"))
+	}
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+)
+
+// calendarCell is the width of one day in a month grid
+const calendarCell = 5
+
+// MonthGrid renders the month that first falls in as a calendar with weeks
+// starting on Monday. Under each day is its number of runs, from runs[0] for
+// the 1st onwards, or a dot when the schedule does not fire that day.
+func MonthGrid(t *Theme, first time.Time, runs []int) []string {
+	width := 7 * calendarCell
+	title := first.Format("January 2006")
+	lines := []string{t.Bold + strings.Repeat(" ", (width-len(title))/2) + title + t.Reset}
+
+	header := ""
+	for i := 1; i <= 7; i++ {
+		header += fmt.Sprintf("%*s", calendarCell, time.Weekday(i % 7).String()[:3])
+	}
+	lines = append(lines, t.Muted+header+t.Reset)
+
+	// Each week is a line of dates and a line of run counts
+	offset := (int(first.Weekday()) + 6) % 7
+	var dates, counts strings.Builder
+	dates.WriteString(strings.Repeat(" ", offset*calendarCell))
+	counts.WriteString(strings.Repeat(" ", offset*calendarCell))
+	for i, n := range runs {
+		if n > 0 {
+			fmt.Fprintf(&dates, "%s%*d%s", t.Bold, calendarCell, i+1, t.Reset)
+			fmt.Fprintf(&counts, "%s%*d%s", t.Success, calendarCell, n, t.Reset)
+		} else {
+			fmt.Fprintf(&dates, "%*d", calendarCell, i+1)
+			fmt.Fprintf(&counts, "%s%*s%s", t.Muted, calendarCell, t.Glyphs.Empty, t.Reset)
+		}
+		if (offset+i+1)%7 == 0 || i == len(runs)-1 {
+			lines = append(lines, dates.String(), counts.String())
+			dates.Reset()
+			counts.Reset()
+		}
+	}
+	return lines
+}
+
+// DayHeatmap renders the minutes of a day as a grid with one line per hour
+// and one column per minute, filling the minutes marked in day
+func DayHeatmap(t *Theme, day *cronexpr.DayMinutes) []string {
+	header := "   "
+	for m := 0; m < 60; m += 10 {
+		header += fmt.Sprintf("%-10d", m)
+	}
+	lines := []string{t.Muted + strings.TrimRight(header, " ") + t.Reset}
+
+	for h := range day {
+		var row strings.Builder
+		fmt.Fprintf(&row, "%s%02d%s ", t.Muted, h, t.Reset)
+		for _, fires := range day[h] {
+			if fires {
+				row.WriteString(t.Success + t.Glyphs.Filled + t.Reset)
+			} else {
+				row.WriteString(t.Muted + t.Glyphs.Empty + t.Reset)
+			}
+		}
+		lines = append(lines, row.String())
+	}
+	return lines
+}
+
+// DisplayMonthCalendar shows on which days of a month expr fires, and how often
+func (c *Console) DisplayMonthCalendar(expr string, first time.Time, runs []int) {
+	total := 0
+	for _, n := range runs {
+		total += n
+	}
+
+	fmt.Fprintln(c.out)
+	for _, line := range MonthGrid(c.theme, first, runs) {
+		fmt.Fprintln(c.out, line)
+	}
+	fmt.Fprintf(c.out, "\n%s%s fires %d times in %s%s\n\n", c.theme.Muted, expr, total, first.Format("January 2006"), c.theme.Reset)
+}
+
+// DisplayDayHeatmap shows the minutes of one day at which expr fires
+func (c *Console) DisplayDayHeatmap(expr string, date time.Time, day *cronexpr.DayMinutes) {
+	fmt.Fprintln(c.out, c.theme.Bold+"\n"+date.Format("Monday 2 January 2006")+c.theme.Reset)
+	for _, line := range DayHeatmap(c.theme, day) {
+		fmt.Fprintln(c.out, line)
+	}
+	fmt.Fprintf(c.out, "\n%s%s fires %d times on %s%s\n\n", c.theme.Muted, expr, day.Count(), date.Format("Mon 2 Jan 2006"), c.theme.Reset)
+}
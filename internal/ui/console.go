@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+	"github.com/dwildt/cronkoans/internal/progress"
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+	"github.com/dwildt/cronkoans/pkg/crontab"
+)
+
+// UI is everything the runner needs to talk to the learner
+type UI interface {
+	DisplayWelcome()
+	DisplayKoan(k *koan.Koan, number, total int)
+	DisplayHint(hint string, level int)
+	DisplayCorrect(k *koan.Koan)
+	DisplayIncorrect(feedback koan.Feedback)
+	DisplayProgress(stats progress.Stats)
+	DisplayCompletion(stats progress.Stats)
+	DisplayLessonList(lessons []*koan.Lesson, tracker *progress.Tracker)
+	DisplayPackList(packs []PackSummary)
+	DisplayPackInfo(pack PackSummary)
+	DisplayValidationResults(results []ValidationResult, totalKoans int)
+	DisplayScheduleDiff(before, after string, diff *cronexpr.Difference)
+	DisplayMonthCalendar(expr string, first time.Time, runs []int)
+	DisplayDayHeatmap(expr string, date time.Time, day *cronexpr.DayMinutes)
+	DisplayCrontabLoad(file string, tab *crontab.Crontab, load *crontab.Load, hotspot int)
+	DisplayLintResults(results []LintResult)
+	DisplayLintRules(rules []*crontab.Rule)
+	DisplayError(err error)
+	DisplayInfo(message string)
+	DisplaySuccess(message string)
+	DisplayWarning(message string)
+	DisplayHelp()
+	PromptForAnswer() string
+	DisplayAnswerPrompt()
+	ReadAnswer() (string, bool)
+	PromptForAnswerWithPreview(k *koan.Koan) string
+	PromptYesNo(question string) bool
+	PromptString(question, defaultValue string) string
+	PressEnterToContinue()
+	DisplayDocument(format OutputFormat, doc any) error
+	Theme() *Theme
+	Streams() (io.Reader, io.Writer)
+}
+
+// Console is a line-based UI that reads from and writes to arbitrary streams
+type Console struct {
+	in       io.Reader
+	reader   *bufio.Reader
+	out      io.Writer
+	err      io.Writer
+	theme    *Theme
+	errTheme *Theme
+
+	showCalendar bool // show the month's runs after each solved koan
+}
+
+// NewConsole creates a console over the given streams using the default theme.
+// A single buffered reader is shared by every prompt so piped input is never lost.
+func NewConsole(in io.Reader, out, errOut io.Writer) *Console {
+	return &Console{
+		in:       in,
+		reader:   bufio.NewReader(in),
+		out:      out,
+		err:      errOut,
+		theme:    DefaultTheme(),
+		errTheme: DefaultTheme(),
+	}
+}
+
+// SetTheme changes the theme used for output and, separately, for errors.
+// The two differ when only one of stdout and stderr is a terminal.
+func (c *Console) SetTheme(theme, errTheme *Theme) {
+	c.theme = theme
+	c.errTheme = errTheme
+}
+
+// SetShowCalendar turns on a calendar of the current month's runs after
+// each solved koan, so learners see the schedule they just wrote
+func (c *Console) SetShowCalendar(show bool) {
+	c.showCalendar = show
+}
+
+// Theme returns the theme used for regular output
+func (c *Console) Theme() *Theme {
+	return c.theme
+}
+
+// Streams returns the console's input and output, for interfaces such as the
+// TUI that take over the terminal
+func (c *Console) Streams() (io.Reader, io.Writer) {
+	return c.in, c.out
+}
+
+// IsTerminal reports whether w is attached to a terminal
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && isTerminal(f)
+}
+
+// Terminals reports whether the console's output and error streams are terminals
+func (c *Console) Terminals() (out, errOut bool) {
+	return IsTerminal(c.out), IsTerminal(c.err)
+}
+
+// NewStdConsole creates a console attached to the process's standard streams.
+// Colors are used only for streams that are terminals, and never when NO_COLOR is set.
+func NewStdConsole() *Console {
+	c := NewConsole(os.Stdin, os.Stdout, os.Stderr)
+
+	mode, _ := ResolveColorMode("", false, false, "")
+	outTerminal, errTerminal := c.Terminals()
+	theme, _ := NewTheme(ThemeOptions{Mode: mode, IsTerminal: outTerminal})
+	errTheme, _ := NewTheme(ThemeOptions{Mode: mode, IsTerminal: errTerminal})
+	c.SetTheme(theme, errTheme)
+
+	return c
+}
+
+// readLine reads one line of input without the trailing newline
+func (c *Console) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	return strings.TrimSpace(line), err
+}
+
+// terminalFiles returns the console's input and output as files when both are terminals
+func (c *Console) terminalFiles() (*os.File, *os.File, bool) {
+	in, ok := c.in.(*os.File)
+	if !ok {
+		return nil, nil, false
+	}
+	out, ok := c.out.(*os.File)
+	if !ok {
+		return nil, nil, false
+	}
+	if !isTerminal(in) || !isTerminal(out) || c.reader.Buffered() > 0 {
+		return nil, nil, false
+	}
+	return in, out, true
+}
+
+// Console must satisfy the UI interface
+var _ UI = (*Console)(nil)
@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/dwildt/cronkoans/pkg/crontab"
+)
+
+// peakBarWidth is the length of the bar for the busiest minute of the hour
+const peakBarWidth = 40
+
+// DisplayCrontabLoad shows how the jobs of a crontab start over a window:
+// the busiest minutes of the hour, the hotspots where at least hotspot jobs
+// start together, overlapping runs and staggered schedules
+func (c *Console) DisplayCrontabLoad(file string, tab *crontab.Crontab, load *crontab.Load, hotspot int) {
+	t := c.theme
+	const layout = "Mon 2 Jan 2006 15:04"
+	fmt.Fprintln(c.out, t.Bold+"\n"+t.Glyphs.Search+"Crontab Load: "+file+t.Reset)
+	fmt.Fprintln(c.out, t.Rule(60))
+	fmt.Fprintf(c.out, "%s%-8s%s %d\n", t.Muted, "Jobs:", t.Reset, len(tab.Jobs))
+	fmt.Fprintf(c.out, "%s%-8s%s %s to %s %s\n", t.Muted, "Window:", t.Reset,
+		load.Start.Format(layout), load.End.Format(layout), load.Start.Format("MST"))
+	fmt.Fprintf(c.out, "%s%-8s%s %d\n", t.Muted, "Starts:", t.Reset, load.Starts)
+	fmt.Fprintln(c.out, t.Rule(60))
+
+	busiest := 0
+	for _, n := range load.Peaks {
+		busiest = max(busiest, n)
+	}
+	if busiest > 0 {
+		fmt.Fprintln(c.out, t.Bold+"\nMost jobs starting together, by minute of the hour:"+t.Reset)
+		for m, n := range load.Peaks {
+			if n == 0 {
+				continue
+			}
+			color := t.Success
+			if n >= hotspot {
+				color = t.Warning
+			}
+			bar := strings.Repeat(t.Glyphs.Filled, max(1, n*peakBarWidth/busiest))
+			fmt.Fprintf(c.out, "  %s:%02d%s %s%s%s %d\n", t.Muted, m, t.Reset, color, bar, t.Reset, n)
+		}
+	}
+
+	fmt.Fprintln(c.out, t.Bold+"\nHotspots:"+t.Reset)
+	if len(load.Hotspots) == 0 {
+		fmt.Fprintf(c.out, "%s%s%s No minute has %d or more jobs starting together\n", t.Success, t.Glyphs.Check, t.Reset, hotspot)
+	}
+	days := int(math.Ceil(load.End.Sub(load.Start).Hours() / 24))
+	for _, h := range load.Hotspots {
+		fmt.Fprintf(c.out, "%s%s %s%s %s(on %d of %d days)%s\n", t.Warning, t.Glyphs.Warning, h, t.Reset, t.Muted, h.Days, days, t.Reset)
+		for _, job := range h.Jobs {
+			fmt.Fprintf(c.out, "    %s\n", jobLine(t, job))
+		}
+	}
+
+	if len(load.Overlaps) > 0 {
+		fmt.Fprintln(c.out, t.Bold+"\nOverlapping runs:"+t.Reset)
+		for _, o := range load.Overlaps {
+			fmt.Fprintf(c.out, "%s%s %s%s\n", t.Warning, t.Glyphs.Warning, jobLine(t, o.Job), t.Reset)
+			fmt.Fprintf(c.out, "    %s\n", o)
+		}
+	}
+
+	if len(load.Suggestions) > 0 {
+		fmt.Fprintln(c.out, t.Bold+"\nSuggested offsets:"+t.Reset)
+		for _, s := range load.Suggestions {
+			fmt.Fprintf(c.out, "  %sline %d:%s %s  ->  %s%s%s  %s%s%s\n", t.Muted, s.Job.Line, t.Reset,
+				s.Job.Expression, t.Success, s.Expression, t.Reset, t.Muted, s.Job.Command, t.Reset)
+		}
+	}
+	fmt.Fprintln(c.out)
+}
+
+// jobLine describes a crontab job as its line number, schedule and command
+func jobLine(t *Theme, job *crontab.Job) string {
+	return fmt.Sprintf("%sline %d:%s %s %s%s%s", t.Muted, job.Line, t.Reset, job.Expression, t.Muted, job.Command, t.Reset)
+}
+
+// LintResult holds the lint findings for one crontab file or expression
+type LintResult struct {
+	Source     string // file name, or the expression
+	Expression bool   // whether Source is an expression rather than a file
+	Findings   []crontab.Finding
+}
+
+// DisplayLintResults shows lint findings with their explanations and the
+// koans that teach how to avoid them
+func (c *Console) DisplayLintResults(results []LintResult) {
+	t := c.theme
+	fmt.Fprintln(c.out, t.Bold+"\n"+t.Glyphs.Search+"Lint Results"+t.Reset)
+	fmt.Fprintln(c.out, t.Rule(60))
+
+	counts := make(map[crontab.Severity]int)
+	for _, r := range results {
+		source := r.Source
+		if r.Expression {
+			source = fmt.Sprintf("%q", r.Source)
+		}
+		if len(r.Findings) == 0 {
+			fmt.Fprintf(c.out, "%s%s%s %s\n", t.Success, t.Glyphs.Check, t.Reset, source)
+			continue
+		}
+
+		fmt.Fprintln(c.out, t.Bold+source+t.Reset)
+		for _, f := range r.Findings {
+			counts[f.Rule.Severity]++
+			color, glyph := t.Info, t.Glyphs.Info
+			switch f.Rule.Severity {
+			case crontab.SeverityError:
+				color, glyph = t.Error, t.Glyphs.Cross
+			case crontab.SeverityWarning:
+				color, glyph = t.Warning, t.Glyphs.Warning
+			}
+			position := ""
+			if f.Line > 0 {
+				position = fmt.Sprintf("line %d: ", f.Line)
+			}
+			fmt.Fprintf(c.out, "  %s%s %s%s:%s %s %s[%s]%s\n", color, glyph, position, f.Rule.Severity, t.Reset,
+				f.Message, t.Muted, f.Rule.Name, t.Reset)
+			fmt.Fprintf(c.out, "      %s%s%s\n", t.Muted, f.Rule.Explanation, t.Reset)
+			if f.Rule.Koan != "" {
+				fmt.Fprintf(c.out, "      %sPractise it: cronkoans start --koan %s%s\n", t.Accent, f.Rule.Koan, t.Reset)
+			}
+		}
+	}
+
+	fmt.Fprintln(c.out, t.Rule(60))
+	fmt.Fprintf(c.out, "\nErrors:   %s%d%s\n", t.Error, counts[crontab.SeverityError], t.Reset)
+	fmt.Fprintf(c.out, "Warnings: %s%d%s\n", t.Warning, counts[crontab.SeverityWarning], t.Reset)
+	fmt.Fprintf(c.out, "Info:     %s%d%s\n", t.Info, counts[crontab.SeverityInfo], t.Reset)
+	fmt.Fprintln(c.out)
+}
+
+// DisplayLintRules lists the lint rules
+func (c *Console) DisplayLintRules(rules []*crontab.Rule) {
+	t := c.theme
+	fmt.Fprintln(c.out, t.Bold+"\n"+t.Glyphs.Search+"Lint Rules"+t.Reset)
+	fmt.Fprintln(c.out, t.Rule(60))
+	for _, r := range rules {
+		fmt.Fprintf(c.out, "%s %s(%s)%s\n", t.Bold+r.Name+t.Reset, t.Muted, r.Severity, t.Reset)
+		fmt.Fprintf(c.out, "   %s\n", r.Explanation)
+		if r.Koan != "" {
+			fmt.Fprintf(c.out, "   %sKoan: %s%s\n", t.Accent, r.Koan, t.Reset)
+		}
+	}
+	fmt.Fprintln(c.out, t.Rule(60))
+	fmt.Fprintln(c.out, t.Muted+"Disable rules with --disable <name>,<name>, or in the crontab with\n# cronkoans:disable <name> or # cronkoans:disable-next-line <name>"+t.Reset)
+	fmt.Fprintln(c.out)
+}
@@ -1,13 +1,14 @@
 package ui
 
 import (
-	"bufio"
 	"fmt"
-	"os"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/dwildt/cronkoans/internal/koan"
 	"github.com/dwildt/cronkoans/internal/progress"
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
 )
 
 // Color codes for terminal output
@@ -24,89 +25,135 @@ const (
 )
 
 // DisplayWelcome shows the welcome message
-func DisplayWelcome() {
-	fmt.Println(ColorBold + ColorCyan + "╔═══════════════════════════════════════════════════════╗" + ColorReset)
-	fmt.Println(ColorBold + ColorCyan + "║            Welcome to Cron Koans!                    ║" + ColorReset)
-	fmt.Println(ColorBold + ColorCyan + "║      Learn Crontab through practice and wisdom       ║" + ColorReset)
-	fmt.Println(ColorBold + ColorCyan + "╚═══════════════════════════════════════════════════════╝" + ColorReset)
-	fmt.Println()
+func (c *Console) DisplayWelcome() {
+	t := c.theme
+	for _, line := range t.Box([]string{
+		"Welcome to Cron Koans!",
+		"Learn Crontab through practice and wisdom",
+	}, 55) {
+		fmt.Fprintln(c.out, t.Title+line+t.Reset)
+	}
+	fmt.Fprintln(c.out)
 }
 
 // DisplayKoan displays a koan to the user
-func DisplayKoan(k *koan.Koan, number, total int) {
-	fmt.Println(ColorBold + fmt.Sprintf("\n[Koan %d/%d]", number, total) + ColorReset)
-	fmt.Println(ColorBlue + k.Description + ColorReset)
-	fmt.Println()
-	fmt.Println(ColorGray + "Question: " + ColorReset + k.Question)
-	fmt.Println()
-	fmt.Println(ColorYellow + "Incomplete expression: " + ColorBold + k.Incomplete + ColorReset)
-	fmt.Println()
+func (c *Console) DisplayKoan(k *koan.Koan, number, total int) {
+	t := c.theme
+	header := fmt.Sprintf("\n[Koan %d/%d]", number, total)
+	if total <= 0 {
+		// Practice koans are endless
+		header = fmt.Sprintf("\n[Koan %d]", number)
+	}
+	fmt.Fprintln(c.out, t.Bold+header+t.Reset)
+	fmt.Fprintln(c.out, t.Info+k.Description+t.Reset)
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, t.Muted+"Question: "+t.Reset+k.Question)
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, t.Hint+"Incomplete expression: "+t.Bold+k.Incomplete+t.Reset)
+	fmt.Fprintln(c.out)
 }
 
 // DisplayHint displays a hint
-func DisplayHint(hint string, level int) {
-	fmt.Println(ColorYellow + fmt.Sprintf("\n💡 Hint %d: ", level+1) + hint + ColorReset)
-	fmt.Println()
+func (c *Console) DisplayHint(hint string, level int) {
+	t := c.theme
+	fmt.Fprintln(c.out, t.Hint+fmt.Sprintf("\n%sHint %d: ", t.Glyphs.Hint, level+1)+hint+t.Reset)
+	fmt.Fprintln(c.out)
 }
 
 // DisplayCorrect shows success message
-func DisplayCorrect(k *koan.Koan) {
-	fmt.Println(ColorGreen + "\n✓ Correct!" + ColorReset)
-	fmt.Println(ColorGreen + "Complete expression: " + ColorBold + k.CompleteCronExpression() + ColorReset)
+func (c *Console) DisplayCorrect(k *koan.Koan) {
+	t := c.theme
+	fmt.Fprintln(c.out, t.Success+"\n"+t.Glyphs.Check+" Correct!"+t.Reset)
+	fmt.Fprintln(c.out, t.Success+"Complete expression: "+t.Bold+k.CompleteCronExpression()+t.Reset)
 	if k.Explanation != "" {
-		fmt.Println()
-		fmt.Println(ColorCyan + "📚 " + k.Explanation + ColorReset)
+		fmt.Fprintln(c.out)
+		fmt.Fprintln(c.out, t.Accent+t.Glyphs.Lessons+k.Explanation+t.Reset)
+	}
+	if c.showCalendar {
+		c.displayKoanCalendar(k)
 	}
-	fmt.Println()
+	fmt.Fprintln(c.out)
 }
 
-// DisplayIncorrect shows incorrect message
-func DisplayIncorrect() {
-	fmt.Println(ColorRed + "\n✗ Incorrect. Try again!" + ColorReset)
-	fmt.Println()
+// displayKoanCalendar shows when a solved koan's cron expression fires this month
+func (c *Console) displayKoanCalendar(k *koan.Koan) {
+	if k.Dialect == koan.DialectSystemd {
+		return
+	}
+	s, err := cronexpr.Parse(k.CompleteCronExpression())
+	if err != nil || s.Reboot {
+		return
+	}
+	now := time.Now()
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	fmt.Fprintln(c.out)
+	for _, line := range MonthGrid(c.theme, first, s.MonthRuns(first.Year(), first.Month(), first.Location())) {
+		fmt.Fprintln(c.out, line)
+	}
+}
+
+// DisplayIncorrect shows incorrect message along with feedback on the mistake
+func (c *Console) DisplayIncorrect(feedback koan.Feedback) {
+	t := c.theme
+	fmt.Fprintln(c.out, t.Error+"\n"+t.Glyphs.Cross+" Incorrect. Try again!"+t.Reset)
+	if feedback.Message != "" {
+		fmt.Fprintln(c.out, t.Hint+feedback.Message+t.Reset)
+	}
+	fmt.Fprintln(c.out)
 }
 
 // DisplayProgress shows progress statistics
-func DisplayProgress(stats progress.Stats) {
+func (c *Console) DisplayProgress(stats progress.Stats) {
+	t := c.theme
 	percentage := fmt.Sprintf("%.1f%%", stats.PercentComplete)
 
-	fmt.Println(ColorBold + "\n📊 Your Progress" + ColorReset)
-	fmt.Println(strings.Repeat("─", 50))
-	fmt.Printf("Completed: %s%d/%d%s koans (%s%s%s)\n",
-		ColorGreen, stats.CompletedKoans, stats.TotalKoans, ColorReset,
-		ColorBold, percentage, ColorReset)
-	fmt.Printf("Remaining: %s%d%s koans\n",
-		ColorYellow, stats.RemainingKoans, ColorReset)
-	fmt.Printf("Total attempts: %d\n", stats.TotalAttempts)
-	fmt.Printf("Hints used: %d\n", stats.TotalHintsUsed)
-	fmt.Println(strings.Repeat("─", 50))
-	fmt.Println()
+	fmt.Fprintln(c.out, t.Bold+"\n"+t.Glyphs.Stats+"Your Progress"+t.Reset)
+	fmt.Fprintln(c.out, t.Rule(50))
+	fmt.Fprintf(c.out, "Completed: %s%d/%d%s koans (%s%s%s)\n",
+		t.Success, stats.CompletedKoans, stats.TotalKoans, t.Reset,
+		t.Bold, percentage, t.Reset)
+	fmt.Fprintf(c.out, "Remaining: %s%d%s koans\n",
+		t.Warning, stats.RemainingKoans, t.Reset)
+	fmt.Fprintf(c.out, "Total attempts: %d\n", stats.TotalAttempts)
+	fmt.Fprintf(c.out, "Hints used: %d\n", stats.TotalHintsUsed)
+	fmt.Fprintln(c.out, t.Rule(50))
+	fmt.Fprintln(c.out)
 }
 
 // DisplayCompletion shows completion message
-func DisplayCompletion(stats progress.Stats) {
-	fmt.Println(ColorGreen + ColorBold)
-	fmt.Println("\n╔═══════════════════════════════════════════════════════╗")
-	fmt.Println("║          🎉 Congratulations! 🎉                      ║")
-	fmt.Println("║                                                       ║")
-	fmt.Println("║      You have completed all Cron Koans!             ║")
-	fmt.Println("║                                                       ║")
-	fmt.Println("║      You are now a Crontab master!                  ║")
-	fmt.Println("╚═══════════════════════════════════════════════════════╝")
-	fmt.Println(ColorReset)
-
-	fmt.Printf("Total attempts: %d\n", stats.TotalAttempts)
-	fmt.Printf("Hints used: %d\n", stats.TotalHintsUsed)
-	fmt.Printf("Started: %s\n", stats.StartedAt.Format("2006-01-02 15:04:05"))
-	fmt.Printf("Completed: %s\n", stats.UpdatedAt.Format("2006-01-02 15:04:05"))
-	fmt.Println()
+func (c *Console) DisplayCompletion(stats progress.Stats) {
+	t := c.theme
+	title := "Congratulations!"
+	if t.Glyphs.Party != "" {
+		title = t.Glyphs.Party + title + " " + strings.TrimSpace(t.Glyphs.Party)
+	}
+
+	fmt.Fprintln(c.out)
+	for _, line := range t.Box([]string{
+		title,
+		"",
+		"You have completed all Cron Koans!",
+		"",
+		"You are now a Crontab master!",
+	}, 55) {
+		fmt.Fprintln(c.out, t.Success+t.Bold+line+t.Reset)
+	}
+	fmt.Fprintln(c.out)
+
+	fmt.Fprintf(c.out, "Total attempts: %d\n", stats.TotalAttempts)
+	fmt.Fprintf(c.out, "Hints used: %d\n", stats.TotalHintsUsed)
+	fmt.Fprintf(c.out, "Started: %s\n", stats.StartedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(c.out, "Completed: %s\n", stats.UpdatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintln(c.out)
 }
 
 // DisplayLessonList shows all available lessons
-func DisplayLessonList(lessons []*koan.Lesson, tracker *progress.Tracker) {
-	fmt.Println(ColorBold + "\n📚 Available Lessons" + ColorReset)
-	fmt.Println(strings.Repeat("─", 60))
+func (c *Console) DisplayLessonList(lessons []*koan.Lesson, tracker *progress.Tracker) {
+	t := c.theme
+	fmt.Fprintln(c.out, t.Bold+"\n"+t.Glyphs.Lessons+"Available Lessons"+t.Reset)
+	fmt.Fprintln(c.out, t.Rule(60))
 
+	next := koan.NextLesson(lessons, tracker.IsCompleted)
 	for i, lesson := range lessons {
 		completed := 0
 		for _, k := range lesson.Koans {
@@ -120,23 +167,126 @@ func DisplayLessonList(lessons []*koan.Lesson, tracker *progress.Tracker) {
 
 		var statusColor string
 		if completed == total {
-			statusColor = ColorGreen
+			statusColor = t.Success
 		} else if completed > 0 {
-			statusColor = ColorYellow
+			statusColor = t.Warning
 		} else {
-			statusColor = ColorGray
+			statusColor = t.Muted
+		}
+
+		marker := ""
+		if lesson == next {
+			marker = " " + t.Accent + t.Glyphs.Pointer + " next" + t.Reset
+		}
+		fmt.Fprintf(c.out, "%d. %s (%s%s%s)%s\n", i+1, lesson.Title, statusColor, status, t.Reset, marker)
+		fmt.Fprintf(c.out, "   %s\n", t.Muted+lesson.Description+t.Reset)
+		if details := lessonDetails(lesson); details != "" {
+			fmt.Fprintf(c.out, "   %s\n", t.Muted+details+t.Reset)
+		}
+
+		// The curriculum graph: each lesson points back to the lessons it builds on
+		if len(lesson.Prerequisites) > 0 {
+			var needs []string
+			for _, name := range lesson.Prerequisites {
+				label := name
+				for j, other := range lessons {
+					if other.Name() == name {
+						label = fmt.Sprintf("%d. %s", j+1, name)
+					}
+				}
+				needs = append(needs, label)
+			}
+			line := "   builds on " + strings.Join(needs, ", ")
+			if missing := koan.MissingPrerequisites(lessons, lesson, tracker.IsCompleted); len(missing) > 0 {
+				fmt.Fprintf(c.out, "%s%s (locked)%s\n", t.Warning, line, t.Reset)
+			} else {
+				fmt.Fprintf(c.out, "%s%s%s\n", t.Muted, line, t.Reset)
+			}
+		}
+	}
+
+	fmt.Fprintln(c.out, t.Rule(60))
+	fmt.Fprintln(c.out)
+}
+
+// lessonDetails summarises a lesson's difficulty, estimated time and tags
+func lessonDetails(lesson *koan.Lesson) string {
+	var details []string
+	if lesson.Difficulty > 0 {
+		details = append(details, fmt.Sprintf("difficulty %d/%d", lesson.Difficulty, koan.MaxDifficulty))
+	}
+	if minutes := lesson.EstimatedMinutes(); minutes > 0 {
+		details = append(details, fmt.Sprintf("about %d min", minutes))
+	}
+	if tags := lesson.AllTags(); len(tags) > 0 {
+		details = append(details, "tags: "+strings.Join(tags, ", "))
+	}
+	return strings.Join(details, ", ")
+}
+
+// PackSummary describes a lesson pack for pack list and pack info
+type PackSummary struct {
+	Pack      *koan.Pack
+	Location  string // where the lessons are read from
+	Builtin   bool
+	Lessons   int
+	Koans     int
+	Completed int
+	Err       error // why the pack does not load; the rest is empty when set
+}
+
+// DisplayPackList shows the bundled and installed lesson packs with progress
+func (c *Console) DisplayPackList(packs []PackSummary) {
+	t := c.theme
+	fmt.Fprintln(c.out, t.Bold+"\n"+t.Glyphs.Pack+"Lesson Packs"+t.Reset)
+	fmt.Fprintln(c.out, t.Rule(60))
+
+	for _, p := range packs {
+		if p.Err != nil {
+			fmt.Fprintf(c.out, "%s %s\n", t.Bold+p.Pack.Name+t.Reset, t.Error+"(broken)"+t.Reset)
+			fmt.Fprintf(c.out, "   %s\n", t.Muted+p.Err.Error()+t.Reset)
+			continue
+		}
+		label := ""
+		if p.Builtin {
+			label = " " + t.Muted + "(built-in)" + t.Reset
+		}
+		fmt.Fprintf(c.out, "%s %s%s - %d lessons, %d/%d koans\n", t.Bold+p.Pack.Name+t.Reset, p.Pack.Version, label, p.Lessons, p.Completed, p.Koans)
+		if p.Pack.Description != "" {
+			fmt.Fprintf(c.out, "   %s\n", t.Muted+p.Pack.Description+t.Reset)
 		}
+	}
+
+	fmt.Fprintln(c.out, t.Rule(60))
+	fmt.Fprintln(c.out)
+}
 
-		fmt.Printf("%d. %s (%s%s%s)\n", i+1, lesson.Title, statusColor, status, ColorReset)
-		fmt.Printf("   %s\n", ColorGray+lesson.Description+ColorReset)
+// DisplayPackInfo shows the manifest of a lesson pack
+func (c *Console) DisplayPackInfo(p PackSummary) {
+	t := c.theme
+	fmt.Fprintln(c.out, t.Bold+"\n"+t.Glyphs.Pack+p.Pack.Name+" "+p.Pack.Version+t.Reset)
+	fmt.Fprintln(c.out, t.Rule(60))
+	if p.Pack.Description != "" {
+		fmt.Fprintln(c.out, p.Pack.Description)
 	}
 
-	fmt.Println(strings.Repeat("─", 60))
-	fmt.Println()
+	fields := []struct{ name, value string }{
+		{"Author", p.Pack.Author},
+		{"Dialect", string(p.Pack.Dialect)},
+		{"Needs cronkoans", p.Pack.MinVersion},
+		{"Location", p.Location},
+	}
+	for _, f := range fields {
+		if f.value != "" {
+			fmt.Fprintf(c.out, "%s%-16s%s %s\n", t.Muted, f.name+":", t.Reset, f.value)
+		}
+	}
+	fmt.Fprintf(c.out, "%s%-16s%s %d of %d koans in %d lessons\n", t.Muted, "Progress:", t.Reset, p.Completed, p.Koans, p.Lessons)
 }
 
 // DisplayValidationResults shows the results of validation mode
-func DisplayValidationResults(results []ValidationResult, totalKoans int) {
+func (c *Console) DisplayValidationResults(results []ValidationResult, totalKoans int) {
+	t := c.theme
 	passed := 0
 	for _, r := range results {
 		if r.Passed {
@@ -144,99 +294,233 @@ func DisplayValidationResults(results []ValidationResult, totalKoans int) {
 		}
 	}
 
-	fmt.Println(ColorBold + "\n🔍 Validation Results" + ColorReset)
-	fmt.Println(strings.Repeat("─", 60))
+	fmt.Fprintln(c.out, t.Bold+"\n"+t.Glyphs.Search+"Validation Results"+t.Reset)
+	fmt.Fprintln(c.out, t.Rule(60))
 
 	for _, r := range results {
 		if r.Passed {
-			fmt.Printf("%s✓%s %s\n", ColorGreen, ColorReset, r.KoanID)
-		} else {
-			fmt.Printf("%s✗%s %s - %s\n", ColorRed, ColorReset, r.KoanID, r.Error)
+			fmt.Fprintf(c.out, "%s%s%s %s\n", t.Success, t.Glyphs.Check, t.Reset, r.Name())
+			continue
+		}
+		if len(r.Problems) == 0 {
+			fmt.Fprintf(c.out, "%s%s%s %s - %s\n", t.Error, t.Glyphs.Cross, t.Reset, r.Name(), r.Error)
+			continue
+		}
+		fmt.Fprintf(c.out, "%s%s%s %s\n", t.Error, t.Glyphs.Cross, t.Reset, r.Name())
+		for _, p := range r.Problems {
+			fmt.Fprintf(c.out, "    %s%s:%s %s %s[%s]%s\n", t.Muted, p.Position(), t.Reset, p.Message, t.Muted, p.Check, t.Reset)
 		}
 	}
 
-	fmt.Println(strings.Repeat("─", 60))
-	fmt.Printf("\nPassed: %s%d/%d%s\n", ColorGreen, passed, totalKoans, ColorReset)
-	fmt.Printf("Failed: %s%d%s\n", ColorRed, totalKoans-passed, ColorReset)
-	fmt.Println()
+	fmt.Fprintln(c.out, t.Rule(60))
+	fmt.Fprintf(c.out, "\nPassed: %s%d/%d%s\n", t.Success, passed, totalKoans, t.Reset)
+	fmt.Fprintf(c.out, "Failed: %s%d%s\n", t.Error, totalKoans-passed, t.Reset)
+	fmt.Fprintln(c.out)
+}
+
+// maxListedRuns is how many added or removed runs a schedule diff lists
+// before only counting the rest
+const maxListedRuns = 20
+
+// DisplayScheduleDiff shows how the runs of the after expression differ from
+// the before expression's within the compared window
+func (c *Console) DisplayScheduleDiff(before, after string, diff *cronexpr.Difference) {
+	t := c.theme
+	const layout = "Mon 2 Jan 2006 15:04"
+	fmt.Fprintln(c.out, t.Bold+"\n"+t.Glyphs.Search+"Schedule Diff"+t.Reset)
+	fmt.Fprintln(c.out, t.Rule(60))
+	fmt.Fprintf(c.out, "%s%-8s%s %s\n", t.Muted, "Before:", t.Reset, before)
+	fmt.Fprintf(c.out, "%s%-8s%s %s\n", t.Muted, "After:", t.Reset, after)
+	fmt.Fprintf(c.out, "%s%-8s%s %s to %s %s\n", t.Muted, "Window:", t.Reset,
+		diff.Start.Format(layout), diff.End.Format(layout), diff.Start.Format("MST"))
+	fmt.Fprintln(c.out, t.Rule(60))
+
+	if diff.Empty() {
+		fmt.Fprintf(c.out, "%s%s%s Both fire at the same %d times\n\n", t.Success, t.Glyphs.Check, t.Reset, diff.Shared)
+		return
+	}
+	for _, line := range diff.Summary() {
+		fmt.Fprintf(c.out, "%s%s%s %s\n", t.Warning, t.Glyphs.Pointer, t.Reset, line)
+	}
+
+	runs := []struct {
+		title, sign, color string
+		times              []time.Time
+	}{
+		{"Runs added", "+", t.Success, diff.Added},
+		{"Runs removed", "-", t.Error, diff.Removed},
+	}
+	for _, r := range runs {
+		if len(r.times) == 0 {
+			continue
+		}
+		fmt.Fprintf(c.out, "\n%s (%d):\n", t.Bold+r.title+t.Reset, len(r.times))
+		for i, run := range r.times {
+			if i == maxListedRuns {
+				fmt.Fprintf(c.out, "  %s... and %d more%s\n", t.Muted, len(r.times)-i, t.Reset)
+				break
+			}
+			fmt.Fprintf(c.out, "  %s%s %s%s\n", r.color, r.sign, run.Format(layout), t.Reset)
+		}
+	}
+	fmt.Fprintf(c.out, "\n%sRuns unchanged: %d%s\n\n", t.Muted, diff.Shared, t.Reset)
 }
 
 // ValidationResult represents the result of validating a koan
+// A result without a koan ID covers problems with the lesson file as a whole.
 type ValidationResult struct {
-	KoanID string
-	Passed bool
-	Error  string
+	KoanID   string         `json:"koan_id" yaml:"koan_id"`
+	File     string         `json:"file" yaml:"file"`
+	Line     int            `json:"line,omitempty" yaml:"line,omitempty"`
+	Passed   bool           `json:"passed" yaml:"passed"`
+	Error    string         `json:"error,omitempty" yaml:"error,omitempty"`
+	Problems []koan.Problem `json:"problems,omitempty" yaml:"problems,omitempty"`
 }
 
-// PromptForAnswer prompts the user for their answer
-func PromptForAnswer() string {
-	fmt.Print(ColorBold + "Your answer: " + ColorReset)
-	reader := bufio.NewReader(os.Stdin)
-	answer, _ := reader.ReadString('\n')
-	return strings.TrimSpace(answer)
+// Name returns the koan ID, or the file name for file-level results
+func (r ValidationResult) Name() string {
+	if r.KoanID != "" {
+		return r.KoanID
+	}
+	return r.File
+}
+
+// PromptForAnswer prompts the user for their answer.
+// It returns "quit" once the input is exhausted so scripted sessions end cleanly.
+func (c *Console) PromptForAnswer() string {
+	c.DisplayAnswerPrompt()
+	answer, ok := c.ReadAnswer()
+	if !ok {
+		fmt.Fprintln(c.out)
+		return "quit"
+	}
+	return answer
+}
+
+// DisplayAnswerPrompt shows the prompt of PromptForAnswer without reading
+func (c *Console) DisplayAnswerPrompt() {
+	fmt.Fprint(c.out, c.theme.Bold+"Your answer: "+c.theme.Reset)
+}
+
+// ReadAnswer reads an answer without prompting or writing anything, so it can
+// run alongside output. It reports false once the input is exhausted.
+func (c *Console) ReadAnswer() (string, bool) {
+	answer, err := c.readLine()
+	if err == io.EOF && answer == "" {
+		return "", false
+	}
+	return answer, true
 }
 
 // PromptYesNo prompts for a yes/no answer
-func PromptYesNo(question string) bool {
-	fmt.Print(ColorBold + question + " (y/n): " + ColorReset)
-	reader := bufio.NewReader(os.Stdin)
-	answer, _ := reader.ReadString('\n')
-	answer = strings.ToLower(strings.TrimSpace(answer))
+func (c *Console) PromptYesNo(question string) bool {
+	fmt.Fprint(c.out, c.theme.Bold+question+" (y/n): "+c.theme.Reset)
+	answer, _ := c.readLine()
+	answer = strings.ToLower(answer)
 	return answer == "y" || answer == "yes"
 }
 
+// PromptString asks for a line of text, returning defaultValue when the answer is empty
+func (c *Console) PromptString(question, defaultValue string) string {
+	prompt := question + ": "
+	if defaultValue != "" {
+		prompt = question + " [" + defaultValue + "]: "
+	}
+	fmt.Fprint(c.out, c.theme.Bold+prompt+c.theme.Reset)
+	answer, _ := c.readLine()
+	if answer == "" {
+		return defaultValue
+	}
+	return answer
+}
+
 // DisplayError shows an error message
-func DisplayError(err error) {
-	fmt.Fprintf(os.Stderr, ColorRed+"Error: %v\n"+ColorReset, err)
+func (c *Console) DisplayError(err error) {
+	fmt.Fprintf(c.err, c.errTheme.Error+"Error: %v\n"+c.errTheme.Reset, err)
 }
 
 // DisplayInfo shows an informational message
-func DisplayInfo(message string) {
-	fmt.Println(ColorBlue + "ℹ " + message + ColorReset)
+func (c *Console) DisplayInfo(message string) {
+	fmt.Fprintln(c.out, c.theme.Info+c.theme.Glyphs.Info+" "+message+c.theme.Reset)
 }
 
 // DisplaySuccess shows a success message
-func DisplaySuccess(message string) {
-	fmt.Println(ColorGreen + "✓ " + message + ColorReset)
+func (c *Console) DisplaySuccess(message string) {
+	fmt.Fprintln(c.out, c.theme.Success+c.theme.Glyphs.Check+" "+message+c.theme.Reset)
 }
 
 // DisplayWarning shows a warning message
-func DisplayWarning(message string) {
-	fmt.Println(ColorYellow + "⚠ " + message + ColorReset)
+func (c *Console) DisplayWarning(message string) {
+	fmt.Fprintln(c.out, c.theme.Warning+c.theme.Glyphs.Warning+" "+message+c.theme.Reset)
 }
 
 // ClearScreen clears the terminal screen
-func ClearScreen() {
-	fmt.Print("\033[2J\033[H")
+func (c *Console) ClearScreen() {
+	fmt.Fprint(c.out, "\033[2J\033[H")
 }
 
 // PressEnterToContinue waits for the user to press enter
-func PressEnterToContinue() {
-	fmt.Print(ColorGray + "\nPress Enter to continue..." + ColorReset)
-	bufio.NewReader(os.Stdin).ReadString('\n')
+func (c *Console) PressEnterToContinue() {
+	fmt.Fprint(c.out, c.theme.Muted+"\nPress Enter to continue..."+c.theme.Reset)
+	c.readLine()
+}
+
+// DisplayVersion shows the version of cronkoans
+func (c *Console) DisplayVersion(version string) {
+	fmt.Fprintf(c.out, "Cron Koans v%s\n", version)
 }
 
 // DisplayHelp shows help information
-func DisplayHelp() {
-	fmt.Println(ColorBold + "Cron Koans - Help" + ColorReset)
-	fmt.Println(strings.Repeat("─", 60))
-	fmt.Println()
-	fmt.Println("Commands:")
-	fmt.Println("  cronkoans              Start interactive mode")
-	fmt.Println("  cronkoans start        Start from beginning")
-	fmt.Println("  cronkoans reset        Reset all progress")
-	fmt.Println("  cronkoans list         List all lessons")
-	fmt.Println("  cronkoans status       Show progress statistics")
-	fmt.Println("  cronkoans validate     Validate all koans")
-	fmt.Println("  cronkoans help         Show this help message")
-	fmt.Println()
-	fmt.Println("During interactive mode:")
-	fmt.Println("  Type your answer and press Enter")
-	fmt.Println("  Type 'hint' to get a hint")
-	fmt.Println("  Type 'skip' to skip the current koan")
-	fmt.Println("  Type 'quit' or 'exit' to quit")
-	fmt.Println()
-	fmt.Println("Learn more about cron:")
-	fmt.Println("  https://crontab.guru")
-	fmt.Println()
+func (c *Console) DisplayHelp() {
+	fmt.Fprintln(c.out, c.theme.Bold+"Cron Koans - Help"+c.theme.Reset)
+	fmt.Fprintln(c.out, c.theme.Rule(60))
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "Commands:")
+	fmt.Fprintln(c.out, "  cronkoans              Start interactive mode")
+	fmt.Fprintln(c.out, "  cronkoans start        Start from beginning")
+	fmt.Fprintln(c.out, "  cronkoans start --tag <tag>  Learn only the koans with a tag")
+	fmt.Fprintln(c.out, "  cronkoans start --koan <id>  Learn a single koan")
+	fmt.Fprintln(c.out, "  cronkoans reset        Reset all progress")
+	fmt.Fprintln(c.out, "  cronkoans list         List all lessons")
+	fmt.Fprintln(c.out, "  cronkoans status       Show progress statistics")
+	fmt.Fprintln(c.out, "  cronkoans validate     Check all lesson files for problems\n                         (--reach for answers that never fire, --min-runs <n> for rare ones)")
+	fmt.Fprintln(c.out, "  cronkoans new lesson   Create the next lesson file interactively")
+	fmt.Fprintln(c.out, "  cronkoans new koan     Add a koan to the latest (or a given) lesson file")
+	fmt.Fprintln(c.out, "  cronkoans generate     Generate a draft lesson from expressions or a concept")
+	fmt.Fprintln(c.out, "  cronkoans dev --lessons <dir>  Try lessons while editing them; changes reload live")
+	fmt.Fprintln(c.out, "  cronkoans practice     Practise with endless generated koans")
+	fmt.Fprintln(c.out, "  cronkoans pack         Install, list, inspect or remove lesson packs")
+	fmt.Fprintln(c.out, "  cronkoans serve        Serve a web interface and JSON API (--addr :8080)")
+	fmt.Fprintln(c.out, "  cronkoans normalize \"<expr>\"  Rewrite cron expressions in canonical form")
+	fmt.Fprintln(c.out, "  cronkoans diff \"<old>\" \"<new>\"  Show how a change to an expression changes its runs\n                         (--from 2026-11-01, --days 7)")
+	fmt.Fprintln(c.out, "  cronkoans calendar \"<expr>\"  Show the runs of an expression in a month\n                         (--month 2026-11), or by minute in a day (--day 2026-11-03)")
+	fmt.Fprintln(c.out, "  cronkoans analyze <crontab>  Find jobs that start together or overlap, and stagger them\n                         (--from 2026-11-01, --days 7, --hotspot 3)")
+	fmt.Fprintln(c.out, "  cronkoans lint <crontab|\"<expr>\">  Check for classic cron mistakes\n                         (--disable <rule>,..., --rules to list them)")
+	fmt.Fprintln(c.out, "  cronkoans convert --to systemd \"<expr>\"  Write a cron expression as a systemd timer's\n                         OnCalendar=, or back with --from systemd \"<OnCalendar>\"")
+	fmt.Fprintln(c.out, "  cronkoans help         Show this help message")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "Options:")
+	fmt.Fprintln(c.out, "  --tui                  Use the full-screen terminal interface")
+	fmt.Fprintln(c.out, "  --calendar             Show this month's runs after each solved koan")
+	fmt.Fprintln(c.out, "  --lessons <dir>        Add a lessons directory over the bundled lessons (repeatable);\n                         a file named like a bundled lesson replaces it")
+	fmt.Fprintln(c.out, "  --pack <name>          Learn from an installed lesson pack, or the bundled\n                         systemd pack on OnCalendar= expressions")
+	fmt.Fprintln(c.out, "  --no-builtin           Use only the --lessons directories")
+	fmt.Fprintln(c.out, "  --color <mode>         Use colors: auto, always or never")
+	fmt.Fprintln(c.out, "  --no-color             Same as --color=never")
+	fmt.Fprintln(c.out, "  --ascii                Use plain ASCII instead of symbols and emoji")
+	fmt.Fprintln(c.out, "  --config <file>        Path to the config file")
+	fmt.Fprintln(c.out, "  --output <format>      Output for status, list, validate, normalize, diff,\n                         analyze, lint and convert: text, json or yaml\n                         (validate also supports junit)")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "During interactive mode:")
+	fmt.Fprintln(c.out, "  Type your answer and press Enter")
+	fmt.Fprintln(c.out, "  Type 'hint' to get a hint")
+	fmt.Fprintln(c.out, "  Type 'skip' to skip the current koan")
+	fmt.Fprintln(c.out, "  Type 'quit' or 'exit' to quit")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "In the full-screen interface (--tui):")
+	fmt.Fprintln(c.out, "  Enter submit, Tab hint, Ctrl-N skip, Esc quit")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "Learn more about cron:")
+	fmt.Fprintln(c.out, "  https://crontab.guru")
+	fmt.Fprintln(c.out)
 }
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dwildt/cronkoans/internal/english"
+)
+
+// OutputFormat selects how commands print their results
+type OutputFormat string
+
+const (
+	OutputText  OutputFormat = "text"
+	OutputJSON  OutputFormat = "json"
+	OutputYAML  OutputFormat = "yaml"
+	OutputJUnit OutputFormat = "junit"
+)
+
+// ParseOutputFormat parses the value of --output
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch format := OutputFormat(strings.ToLower(strings.TrimSpace(s))); format {
+	case OutputText, OutputJSON, OutputYAML, OutputJUnit:
+		return format, nil
+	case "":
+		return OutputText, nil
+	}
+	return "", fmt.Errorf("invalid output format %q (want text, json, yaml or junit)", s)
+}
+
+// documentCommands are the commands that can print their results as a JSON
+// or YAML document
+var documentCommands = []string{"status", "list", "validate", "normalize", "diff", "analyze", "lint", "convert"}
+
+// ParseOutputFormatFor parses the value of --output for a command. Commands
+// without a document reject json and yaml, and only validate writes JUnit
+// reports, so every other command rejects junit.
+func ParseOutputFormatFor(command, s string) (OutputFormat, error) {
+	format, err := ParseOutputFormat(s)
+	if err != nil || format == OutputText {
+		return format, err
+	}
+	if format == OutputJUnit && command != "validate" {
+		return "", fmt.Errorf("--output=junit is only supported by validate")
+	}
+	for _, c := range documentCommands {
+		if c == command {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("--output=%s is only supported by %s", format, english.Join(documentCommands))
+}
+
+// DisplayDocument writes a structured document in the given machine-readable format
+func (c *Console) DisplayDocument(format OutputFormat, doc any) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	case OutputYAML:
+		enc := yaml.NewEncoder(c.out)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	case OutputJUnit:
+		data, err := xml.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(c.out, "%s%s\n", xml.Header, data)
+		return err
+	}
+	return fmt.Errorf("output format %q is not a structured format", format)
+}
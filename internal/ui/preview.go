@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/dwildt/cronkoans/internal/koan"
+)
+
+// PreviewLines formats a live preview of an in-progress answer
+func (t *Theme) PreviewLines(k *koan.Koan, input string) []string {
+	if !k.PreviewEnabled() {
+		return []string{t.Muted + "Preview is disabled for this koan." + t.Reset}
+	}
+
+	p := k.PreviewAnswer(input)
+	if strings.TrimSpace(input) == "" {
+		return []string{t.Muted + "Start typing to preview your answer." + t.Reset}
+	}
+	if !p.Valid {
+		return []string{t.Error + t.Glyphs.Cross + " " + p.Expression + " - " + p.Error + t.Reset}
+	}
+
+	lines := []string{
+		t.Success + t.Glyphs.Check + " " + p.Expression + t.Reset + t.Muted + " - " + p.Description + t.Reset,
+	}
+	if len(p.NextRuns) == 0 {
+		lines = append(lines, t.Muted+"  Never fires"+t.Reset)
+	}
+	for _, run := range p.NextRuns {
+		lines = append(lines, t.Muted+"  Next: "+run.Format("Mon 2006-01-02 15:04")+t.Reset)
+	}
+	return lines
+}
+
+// PromptForAnswerWithPreview prompts for an answer, showing a live preview below
+// the prompt that updates on every keystroke. It falls back to PromptForAnswer
+// when the console is not attached to a terminal or the koan has previews disabled.
+func (c *Console) PromptForAnswerWithPreview(k *koan.Koan) string {
+	in, out, ok := c.terminalFiles()
+	if !k.PreviewEnabled() || !ok {
+		return c.PromptForAnswer()
+	}
+
+	fd := int(in.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return c.PromptForAnswer()
+	}
+	defer term.Restore(fd, oldState)
+
+	width, _, err := term.GetSize(int(out.Fd()))
+	if err != nil || width <= 0 {
+		width = 80
+	}
+
+	prompt := "Your answer: "
+	var input []rune
+	buf := make([]byte, 64)
+
+	for {
+		c.drawPreviewPrompt(prompt, string(input), c.theme.PreviewLines(k, string(input)), width)
+
+		n, err := in.Read(buf)
+		if err != nil {
+			// Like PromptForAnswer, the end of the input with nothing typed quits
+			fmt.Fprint(c.out, "\r\033[J\r\n")
+			if len(input) == 0 {
+				return "quit"
+			}
+			return strings.TrimSpace(string(input))
+		}
+		// Ignore arrow keys and other escape sequences, but not the keys around them
+		keys, _ := StripEscapes(buf[:n])
+		for _, r := range string(keys) {
+			switch {
+			case r == '\r' || r == '\n':
+				// Clear the preview and leave the submitted line on screen
+				fmt.Fprint(c.out, "\r\033[J"+c.theme.Bold+prompt+c.theme.Reset+string(input)+"\r\n")
+				return strings.TrimSpace(string(input))
+			case r == 0x03 || (r == 0x04 && len(input) == 0):
+				fmt.Fprint(c.out, "\r\033[J\r\n")
+				return "quit"
+			case r == 0x7f || r == 0x08:
+				if len(input) > 0 {
+					input = input[:len(input)-1]
+				}
+			case r >= ' ':
+				input = append(input, r)
+			}
+		}
+	}
+}
+
+// drawPreviewPrompt redraws the prompt line and the preview lines beneath it,
+// leaving the cursor at the end of the input
+func (c *Console) drawPreviewPrompt(prompt, input string, preview []string, width int) {
+	var sb strings.Builder
+	sb.WriteString("\r\033[J")
+	sb.WriteString(c.theme.Bold + prompt + c.theme.Reset + input)
+	for _, line := range preview {
+		sb.WriteString("\r\n" + clipLine(line, width-1))
+	}
+	if len(preview) > 0 {
+		sb.WriteString(fmt.Sprintf("\033[%dA", len(preview)))
+	}
+	sb.WriteString(fmt.Sprintf("\r\033[%dC", utf8.RuneCountInString(prompt)+utf8.RuneCountInString(input)))
+	fmt.Fprint(c.out, sb.String())
+}
+
+// StripEscapes removes the escape sequences that keys such as the arrows send
+// from a read of raw terminal input, keeping any keys typed or pasted around
+// them. It also reports whether the read ends with the Escape key on its own.
+func StripEscapes(data []byte) (keys []byte, escape bool) {
+	for i := 0; i < len(data); i++ {
+		if data[i] != 0x1b {
+			keys = append(keys, data[i])
+			continue
+		}
+		if i == len(data)-1 {
+			return keys, true
+		}
+
+		switch data[i+1] {
+		case '[':
+			// A control sequence: parameters and intermediates, then a final byte
+			i += 2
+			for i < len(data) && (data[i] < 0x40 || data[i] > 0x7e) {
+				i++
+			}
+		case 'O':
+			// Arrow and function keys in application mode
+			i += 2
+		default:
+			// Alt with another key
+			i++
+		}
+	}
+	return keys, false
+}
+
+// isTerminal reports whether the file is attached to a terminal
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// clipLine shortens a colored line so it does not wrap in the terminal
+func clipLine(line string, width int) string {
+	visible := 0
+	inEscape := false
+	for i, r := range line {
+		switch {
+		case r == '\033':
+			inEscape = true
+		case inEscape:
+			if r == 'm' {
+				inEscape = false
+			}
+		default:
+			visible++
+			if visible > width {
+				if strings.Contains(line, "\033") {
+					return line[:i] + "\033[0m"
+				}
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
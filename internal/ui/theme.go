@@ -0,0 +1,286 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ColorMode controls when colors are written
+type ColorMode string
+
+const (
+	ColorAuto   ColorMode = "auto"
+	ColorAlways ColorMode = "always"
+	ColorNever  ColorMode = "never"
+)
+
+// ParseColorMode parses the value of --color
+func ParseColorMode(s string) (ColorMode, error) {
+	switch mode := ColorMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case ColorAuto, ColorAlways, ColorNever:
+		return mode, nil
+	case "":
+		return ColorAuto, nil
+	}
+	return "", fmt.Errorf("invalid color mode %q (want auto, always or never)", s)
+}
+
+// ResolveColorMode picks the color mode from, in order of precedence, an explicit
+// --color flag, --no-color, the NO_COLOR environment variable and the config file
+func ResolveColorMode(flagValue string, flagSet, noColor bool, configValue string) (ColorMode, error) {
+	if flagSet {
+		return ParseColorMode(flagValue)
+	}
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return ColorNever, nil
+	}
+	return ParseColorMode(configValue)
+}
+
+// Glyphs are the symbols used to decorate output
+type Glyphs struct {
+	Check   string
+	Cross   string
+	Hint    string
+	Stats   string
+	Lessons string
+	Pack    string
+	Search  string
+	Party   string
+	Info    string
+	Warning string
+	Pointer string
+	Rule    string
+	Divider string
+	Filled  string
+	Empty   string
+
+	Separator string // between items on one line, such as key bindings
+	Ellipsis  string // the end of text cut short
+
+	BoxTopLeft     string
+	BoxTopRight    string
+	BoxBottomLeft  string
+	BoxBottomRight string
+	BoxHorizontal  string
+	BoxVertical    string
+}
+
+// UnicodeGlyphs are the default symbols
+var UnicodeGlyphs = Glyphs{
+	Check: "✓", Cross: "✗", Hint: "💡 ", Stats: "📊 ", Lessons: "📚 ", Pack: "📦 ", Search: "🔍 ", Party: "🎉 ",
+	Info: "ℹ", Warning: "⚠", Pointer: "▸", Rule: "─", Divider: "│", Filled: "█", Empty: "·",
+	Separator: "·", Ellipsis: "…",
+	BoxTopLeft: "╔", BoxTopRight: "╗", BoxBottomLeft: "╚", BoxBottomRight: "╝",
+	BoxHorizontal: "═", BoxVertical: "║",
+}
+
+// ASCIIGlyphs replace every symbol with plain ASCII for limited terminals
+var ASCIIGlyphs = Glyphs{
+	Check: "+", Cross: "x", Hint: "", Stats: "", Lessons: "", Pack: "", Search: "", Party: "",
+	Info: "i", Warning: "!", Pointer: ">", Rule: "-", Divider: "|", Filled: "#", Empty: ".",
+	Separator: "-", Ellipsis: "...",
+	BoxTopLeft: "+", BoxTopRight: "+", BoxBottomLeft: "+", BoxBottomRight: "+",
+	BoxHorizontal: "=", BoxVertical: "|",
+}
+
+// Theme holds the escape sequences for each role in the output, plus the glyph set.
+// Every style is empty when colors are disabled.
+type Theme struct {
+	Reset   string
+	Bold    string
+	Title   string
+	Info    string
+	Muted   string
+	Hint    string
+	Warning string
+	Success string
+	Error   string
+	Accent  string
+
+	Glyphs Glyphs
+}
+
+// DefaultTheme is the colored, Unicode theme used when nothing else is configured
+func DefaultTheme() *Theme {
+	return &Theme{
+		Reset:   ColorReset,
+		Bold:    ColorBold,
+		Title:   ColorBold + ColorCyan,
+		Info:    ColorBlue,
+		Muted:   ColorGray,
+		Hint:    ColorYellow,
+		Warning: ColorYellow,
+		Success: ColorGreen,
+		Error:   ColorRed,
+		Accent:  ColorCyan,
+		Glyphs:  UnicodeGlyphs,
+	}
+}
+
+// PlainTheme has no colors at all
+func PlainTheme(glyphs Glyphs) *Theme {
+	return &Theme{Glyphs: glyphs}
+}
+
+// ThemeOptions describe the environment a theme is chosen for
+type ThemeOptions struct {
+	Mode       ColorMode
+	ASCII      bool
+	IsTerminal bool              // whether the output is a terminal
+	Colors     map[string]string // role name to color spec, from the config file
+}
+
+// NewTheme builds the theme for the given options
+func NewTheme(opts ThemeOptions) (*Theme, error) {
+	glyphs := UnicodeGlyphs
+	if opts.ASCII {
+		glyphs = ASCIIGlyphs
+	}
+
+	useColor := false
+	switch opts.Mode {
+	case ColorAlways:
+		useColor = true
+	case ColorAuto, "":
+		useColor = opts.IsTerminal && os.Getenv("TERM") != "dumb"
+	}
+	if !useColor {
+		return PlainTheme(glyphs), nil
+	}
+
+	theme := DefaultTheme()
+	theme.Glyphs = glyphs
+
+	// Apply user overrides in a stable order so errors are deterministic
+	roles := make([]string, 0, len(opts.Colors))
+	for role := range opts.Colors {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		code, err := ParseColorSpec(opts.Colors[role])
+		if err != nil {
+			return nil, fmt.Errorf("theme color for %q: %w", role, err)
+		}
+		style := theme.style(role)
+		if style == nil {
+			return nil, fmt.Errorf("unknown theme role %q", role)
+		}
+		*style = code
+	}
+
+	return theme, nil
+}
+
+// style returns a pointer to the style for a role name
+func (t *Theme) style(role string) *string {
+	switch strings.ToLower(role) {
+	case "bold":
+		return &t.Bold
+	case "title":
+		return &t.Title
+	case "info":
+		return &t.Info
+	case "muted":
+		return &t.Muted
+	case "hint":
+		return &t.Hint
+	case "warning":
+		return &t.Warning
+	case "success":
+		return &t.Success
+	case "error":
+		return &t.Error
+	case "accent":
+		return &t.Accent
+	}
+	return nil
+}
+
+// colorCodes maps color names to their SGR foreground codes
+var colorCodes = map[string]int{
+	"black": 30, "red": 31, "green": 32, "yellow": 33,
+	"blue": 34, "magenta": 35, "purple": 35, "cyan": 36, "white": 37,
+	"gray": 90, "grey": 90,
+}
+
+// attributeCodes maps text attributes to their SGR codes
+var attributeCodes = map[string]int{
+	"bold": 1, "dim": 2, "italic": 3, "underline": 4,
+}
+
+// ParseColorSpec turns a color specification such as "bold cyan", "bright-red"
+// or "208" (a 256-color index) into an escape sequence
+func ParseColorSpec(spec string) (string, error) {
+	var codes []string
+	for _, word := range strings.Fields(strings.ToLower(spec)) {
+		if code, ok := attributeCodes[word]; ok {
+			codes = append(codes, strconv.Itoa(code))
+			continue
+		}
+		if code, ok := colorCodes[word]; ok {
+			codes = append(codes, strconv.Itoa(code))
+			continue
+		}
+		if name, ok := strings.CutPrefix(word, "bright-"); ok {
+			if code, ok := colorCodes[name]; ok && code < 90 {
+				codes = append(codes, strconv.Itoa(code+60))
+				continue
+			}
+		}
+		if n, err := strconv.Atoi(word); err == nil && n >= 0 && n <= 255 {
+			codes = append(codes, "38;5;"+strconv.Itoa(n))
+			continue
+		}
+		if word == "none" || word == "default" {
+			continue
+		}
+		return "", fmt.Errorf("unknown color %q", word)
+	}
+
+	if len(codes) == 0 {
+		return "", nil
+	}
+	return "\033[" + strings.Join(codes, ";") + "m", nil
+}
+
+// Rule returns a horizontal line of the given width
+func (t *Theme) Rule(width int) string {
+	return strings.Repeat(t.Glyphs.Rule, width)
+}
+
+// Box draws lines centered inside a frame of the given inner width
+func (t *Theme) Box(lines []string, width int) []string {
+	g := t.Glyphs
+	framed := []string{g.BoxTopLeft + strings.Repeat(g.BoxHorizontal, width) + g.BoxTopRight}
+	for _, line := range lines {
+		n := displayWidth(line)
+		left := (width - n) / 2
+		if left < 0 {
+			left = 0
+		}
+		right := width - n - left
+		if right < 0 {
+			right = 0
+		}
+		framed = append(framed, g.BoxVertical+strings.Repeat(" ", left)+line+strings.Repeat(" ", right)+g.BoxVertical)
+	}
+	return append(framed, g.BoxBottomLeft+strings.Repeat(g.BoxHorizontal, width)+g.BoxBottomRight)
+}
+
+// displayWidth approximates the terminal width of s, counting emoji as two columns
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if r >= 0x1F300 {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
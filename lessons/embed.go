@@ -0,0 +1,10 @@
+// Package lessons holds the lessons bundled with cronkoans, so that the
+// binary works on its own wherever it is installed
+package lessons
+
+import "embed"
+
+// FS contains the bundled lesson files, the template and pack.yaml
+//
+//go:embed *.yaml
+var FS embed.FS
@@ -0,0 +1,10 @@
+// Package systemd holds the bundled lessons on systemd timers, whose koans
+// are OnCalendar= expressions instead of cron expressions
+package systemd
+
+import "embed"
+
+// FS contains the bundled systemd lesson files and pack.yaml
+//
+//go:embed *.yaml
+var FS embed.FS
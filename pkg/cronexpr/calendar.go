@@ -0,0 +1,47 @@
+package cronexpr
+
+import "time"
+
+// DayMinutes marks the minutes of a day: minutes[h][m] is the minute m of
+// hour h
+type DayMinutes [24][60]bool
+
+// Count returns how many minutes are marked
+func (d *DayMinutes) Count() int {
+	n := 0
+	for h := range d {
+		for m := range d[h] {
+			if d[h][m] {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// FiresOn marks the minutes the schedule fires at on the calendar day t
+// falls on, in t's location
+func (s *Schedule) FiresOn(t time.Time) *DayMinutes {
+	d := &DayMinutes{}
+	if s.Reboot || !s.matchesDay(t) {
+		return d
+	}
+	for h := 0; h < 24; h++ {
+		for m := 0; m < 60; m++ {
+			d[h][m] = hasBit(s.Minute, m) && hasBit(s.Hour, h)
+		}
+	}
+	return d
+}
+
+// MonthRuns counts the runs on each day of a month in loc: runs[0] is the
+// number of times the schedule fires on the 1st
+func (s *Schedule) MonthRuns(year int, month time.Month, loc *time.Location) []int {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	days := first.AddDate(0, 1, -1).Day()
+	runs := make([]int, days)
+	for i := range runs {
+		runs[i] = s.FiresOn(first.AddDate(0, 0, i)).Count()
+	}
+	return runs
+}
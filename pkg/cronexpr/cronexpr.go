@@ -0,0 +1,87 @@
+// Package cronexpr parses, validates and describes standard five-field cron
+// expressions, and works out when they fire.
+//
+// An expression has the fields minute, hour, day of month, month and day of
+// week. Fields can be *, a number, a range (1-5), a step (*/15 or 1-30/5) or a
+// comma-separated list of those; months and weekdays can also be written as
+// three-letter names. The shortcuts @yearly, @annually, @monthly, @weekly,
+// @daily, @midnight, @hourly and @reboot are accepted too.
+//
+// As in cron, when both day fields are restricted a schedule fires on days
+// that match either of them.
+package cronexpr
+
+import (
+	"errors"
+	"time"
+)
+
+// Field is the position of a field in a cron expression
+type Field int
+
+// The five fields of a cron expression, in order
+const (
+	Minute Field = iota
+	Hour
+	DayOfMonth
+	Month
+	DayOfWeek
+)
+
+// fieldInfo holds the name and allowed values of each field, in order
+var fieldInfo = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7}, // 0 and 7 are both Sunday
+}
+
+// String returns the field's name, such as "day of month"
+func (f Field) String() string {
+	return fieldInfo[f].name
+}
+
+// Min returns the smallest value the field allows
+func (f Field) Min() int {
+	return fieldInfo[f].min
+}
+
+// Max returns the largest value the field allows
+func (f Field) Max() int {
+	return fieldInfo[f].max
+}
+
+// ErrNeverFires is returned by Next for schedules that have no fire time,
+// such as @reboot or February 30th
+var ErrNeverFires = errors.New("schedule never fires")
+
+// Next returns the first time after the given time that expr fires
+func Next(expr string, after time.Time) (time.Time, error) {
+	s, err := Parse(expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next, ok := s.Next(after)
+	if !ok {
+		return time.Time{}, ErrNeverFires
+	}
+	return next, nil
+}
+
+// Equal reports whether two expressions fire at exactly the same times,
+// however they are written
+func Equal(a, b string) (bool, error) {
+	sa, err := Parse(a)
+	if err != nil {
+		return false, err
+	}
+	sb, err := Parse(b)
+	if err != nil {
+		return false, err
+	}
+	return sa.Equal(sb), nil
+}
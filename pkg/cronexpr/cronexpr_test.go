@@ -0,0 +1,340 @@
+package cronexpr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"* * * * *", ""},
+		{"*/15 9-17 * * 1-5", ""},
+		{"0 12 * JAN,jul SUN", ""},
+		{"0 0 * * 7", ""},
+		{"0-30/10 * * * *", ""},
+		{"@daily", ""},
+		{" @Reboot ", ""},
+		{"* * * *", "must have exactly 5 fields"},
+		{"61 * * * *", "field 1 (minute): value 61 out of bounds [0-59]"},
+		{"* 5-3 * * *", "field 2 (hour): range start 5 cannot be greater than end 3"},
+		{"* * 0 * *", "field 3 (day): value 0 out of bounds [1-31]"},
+		{"* * * FOO *", "field 4 (month): invalid value: FOO"},
+		{"*/0 * * * *", "step value must be positive"},
+		{"@sometimes", "must have exactly 5 fields"},
+	}
+	for _, tt := range tests {
+		err := cronexpr.Validate(tt.expr)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("Validate(%q): unexpected error %v", tt.expr, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("Validate(%q): got %v, want an error containing %q", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Saturday October 17th 2026
+	after := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 17, 12, 1, 0, 0, time.UTC)},
+		{"30 2 * * 1-5", time.Date(2026, 10, 19, 2, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either one matches
+		{"0 0 13 * 5", time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := cronexpr.Next(tt.expr, after)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, %v; want %v", tt.expr, got, err, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"0 0 30 2 *", "@reboot"} {
+		if _, err := cronexpr.Next(expr, after); !errors.Is(err, cronexpr.ErrNeverFires) {
+			t.Errorf("Next(%q): got %v, want ErrNeverFires", expr, err)
+		}
+	}
+	if _, err := cronexpr.Next("nope", after); err == nil || errors.Is(err, cronexpr.ErrNeverFires) {
+		t.Errorf("Next of an invalid expression: got %v", err)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"*/15 * * * *", "0,15,30,45 * * * *", true},
+		{"0 0 * * 0", "0 0 * * 7", true},
+		{"0 0 * * SUN", "@weekly", true},
+		{"0 9-17 * * *", "0 9-16 * * *", false},
+		{"0 0 * JAN *", "0 0 * 1 *", true},
+		{"@reboot", "@reboot", true},
+		{"@reboot", "@daily", false},
+	}
+	for _, tt := range tests {
+		got, err := cronexpr.Equal(tt.a, tt.b)
+		if err != nil || got != tt.want {
+			t.Errorf("Equal(%q, %q) = %v, %v; want %v", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+	if _, err := cronexpr.Equal("* * * * *", "* * *"); err == nil {
+		t.Error("Equal with an invalid expression: no error")
+	}
+}
+
+func TestFields(t *testing.T) {
+	if cronexpr.DayOfMonth.String() != "day of month" || cronexpr.DayOfMonth.Min() != 1 || cronexpr.DayOfMonth.Max() != 31 {
+		t.Errorf("DayOfMonth: %s %d-%d", cronexpr.DayOfMonth, cronexpr.DayOfMonth.Min(), cronexpr.DayOfMonth.Max())
+	}
+	set, err := cronexpr.ExpandField("1-10/3,20", cronexpr.Minute.Min(), cronexpr.Minute.Max())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(1<<1 | 1<<4 | 1<<7 | 1<<10 | 1<<20); set != want {
+		t.Errorf("ExpandField: got %b, want %b", set, want)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		expr, want string
+	}{
+		{"0,15,30,45 * * * *", "*/15 * * * *"},
+		{"0-59 * * * *", "* * * * *"},
+		{"0 0 * * 7", "0 0 * * 0"},
+		{"* * * * 1-5/1", "* * * * 1-5"},
+		{"0 12 * JAN,jul SUN", "0 12 * 1,7 0"},
+		{"5,10,15 9,10 * * *", "5-15/5 9,10 * * *"},
+		{"0,30 0,6,12,18 1,2,3,4 * *", "0,30 */6 1-4 * *"},
+		{"@daily", "0 0 * * *"},
+		{"@reboot", "@reboot"},
+		{"0 0 * * 0-7", "0 0 * * *"},
+		// Both day fields restricted: the OR has to survive normalization
+		{"0 0 1,15 * 1-5", "0 0 1,15 * 1-5"},
+		{"0 0 1-31/2 * MON", "0 0 1-31/2 * 1"},
+		{"0 0 */2 * MON", "0 0 */2 * 1"},
+		{"0 0 1-31 * 1-5", "0 0 * * *"},
+		// Only the star keeps these ANDed
+		{"0 0 */31 * */7", "0 0 */31 * 0"},
+	}
+	for _, tt := range tests {
+		got, err := cronexpr.Normalize(tt.expr)
+		if err != nil || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", tt.expr, got, err, tt.want)
+			continue
+		}
+		if same, err := cronexpr.Equal(tt.expr, got); err != nil || !same {
+			t.Errorf("Normalize(%q) = %q, which fires at different times", tt.expr, got)
+		}
+		if again, _ := cronexpr.Normalize(got); again != got {
+			t.Errorf("Normalize(%q) = %q, not canonical", got, again)
+		}
+	}
+	if _, err := cronexpr.Normalize("60 * * * *"); err == nil {
+		t.Error("Normalize of an invalid expression: no error")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	// Saturday October 17th 2026, for one week
+	start := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+
+	d, err := cronexpr.Diff("0 9 * * 1-5", "0 9 * * 1-6", start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Removed) != 0 || d.Shared != 5 || d.Empty() {
+		t.Errorf("Diff: got %+v", d)
+	}
+	if want := []time.Time{time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)}; len(d.Added) != 1 || !d.Added[0].Equal(want[0]) {
+		t.Errorf("Diff: added %v, want %v", d.Added, want)
+	}
+
+	d, err = cronexpr.Diff("*/15 * * * *", "0,15,30,45 * * * *", start, end)
+	if err != nil || !d.Empty() || d.Shared != 7*24*4 {
+		t.Errorf("Diff of equal schedules: got %+v, %v", d, err)
+	}
+	summaries := []struct {
+		a, b string
+		end  time.Time
+		want []string
+	}{
+		{"0 9 * * 1-5", "0 9 * * *", end, []string{"now also fires at weekends at 09:00"}},
+		{"30 2 * * *", "30 2 * * 1,3", end, []string{"no longer fires on Tuesdays, Thursdays, Fridays, Saturdays and Sundays at 02:30"}},
+		// A week shows a single 1st of the month, which is not every Sunday
+		{"0 0 1 * *", "0 0 2 * *", start.AddDate(0, 1, 0), []string{
+			"now also fires on Mon 2 Nov at 00:00",
+			"no longer fires on Sun 1 Nov at 00:00",
+		}},
+		{"*/15 * * * *", "*/5 * * * *", end, []string{
+			"now also fires every day at 00:05, 00:10, 00:20, 00:25 and 188 other times",
+		}},
+		// Three days have not seen every weekday, so the dates are listed
+		{"30 2 * * *", "30 2 * * 1,3", start.AddDate(0, 0, 3), []string{"no longer fires on Sat 17 Oct and Sun 18 Oct at 02:30"}},
+		{"*/15 * * * *", "*/5 * * * *", start.AddDate(0, 0, 1), []string{
+			"now also fires on Sat 17 Oct at 00:05, 00:10, 00:20, 00:25 and 188 other times",
+		}},
+	}
+	for _, tt := range summaries {
+		d, err := cronexpr.Diff(tt.a, tt.b, start, tt.end)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := d.Summary(); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("Diff(%q, %q).Summary() = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	if _, err := cronexpr.Diff("* * * * *", "nope", start, end); err == nil {
+		t.Error("Diff with an invalid expression: no error")
+	}
+}
+
+func TestMonthRuns(t *testing.T) {
+	s, err := cronexpr.Parse("*/15 9-17 * * 1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// February 2026 starts on a Sunday
+	runs := s.MonthRuns(2026, time.February, time.UTC)
+	if len(runs) != 28 || runs[0] != 0 || runs[1] != 36 || runs[6] != 0 {
+		t.Errorf("MonthRuns(February 2026) = %v", runs)
+	}
+
+	day := s.FiresOn(time.Date(2026, 11, 3, 23, 59, 0, 0, time.UTC))
+	if day.Count() != 36 || !day[9][0] || !day[17][45] || day[8][45] || day[9][1] {
+		t.Errorf("FiresOn(Tuesday November 3rd) marked %d minutes", day.Count())
+	}
+
+	// Both day fields restricted: the 1st and the 15th, and every Monday
+	s, _ = cronexpr.Parse("0 9 1,15 * MON")
+	total := 0
+	for _, n := range s.MonthRuns(2026, time.November, time.UTC) {
+		total += n
+	}
+	if total != 7 {
+		t.Errorf("0 9 1,15 * MON runs %d times in November 2026, want 7", total)
+	}
+
+	s, _ = cronexpr.Parse("@reboot")
+	if n := s.FiresOn(time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC)).Count(); n != 0 {
+		t.Errorf("@reboot fires %d times on a day", n)
+	}
+}
+
+func TestCheckReach(t *testing.T) {
+	from := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	for _, expr := range []string{"0 0 30 2 *", "0 0 31 4,6,9,11 *"} {
+		if err := cronexpr.CheckReach(expr, 0, from); !errors.Is(err, cronexpr.ErrNeverFires) {
+			t.Errorf("CheckReach(%q) = %v, want ErrNeverFires", expr, err)
+		}
+	}
+
+	err := cronexpr.CheckReach("0 0 29 2 *", 1, from)
+	var rare *cronexpr.RareError
+	if !errors.As(err, &rare) {
+		t.Fatalf("CheckReach(0 0 29 2 *) = %v, want a RareError", err)
+	}
+	if want := "fires 0.25 times a year on average, fewer than 1; next in 2028, 2032 and 2036"; err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+
+	// Cron ORs the day fields, so February 30th or any Monday runs weekly
+	for _, expr := range []string{"0 0 29 2 *", "0 0 30 2 1", "@yearly", "@reboot", "*/5 * * * *"} {
+		if err := cronexpr.CheckReach(expr, 0, from); err != nil {
+			t.Errorf("CheckReach(%q, 0) = %v", expr, err)
+		}
+	}
+	if err := cronexpr.CheckReach("@yearly", 2, from); err == nil {
+		t.Error("CheckReach(@yearly, 2) passed")
+	}
+
+	// 7305 weekdays in 28 years, with 36 runs each
+	s, _ := cronexpr.Parse("*/15 9-17 * * 1-5")
+	if r := s.Reach(from); r.PerYear != 36*7305.0/28 || len(r.Years) != 3 || r.Years[0] != 2026 {
+		t.Errorf("Reach(*/15 9-17 * * 1-5) = %+v", r)
+	}
+}
+
+func ExampleDescribe() {
+	fmt.Println(cronexpr.Describe("*/15 9-17 * * 1-5"))
+	fmt.Println(cronexpr.Describe("@daily"))
+	// Output:
+	// at minute every 15, hour 9-17, on weekday 1-5
+	// Once a day at midnight (0 0 * * *)
+}
+
+func ExampleNext() {
+	after := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	next, err := cronexpr.Next("30 2 * * 1-5", after)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(next.Format(time.RFC1123))
+	// Output: Mon, 19 Oct 2026 02:30:00 UTC
+}
+
+func ExampleEqual() {
+	same, err := cronexpr.Equal("*/20 * * * *", "0,20,40 * * * *")
+	fmt.Println(same, err)
+	// Output: true <nil>
+}
+
+func ExampleParse() {
+	s, err := cronexpr.Parse("0 9 * * MON")
+	if err != nil {
+		panic(err)
+	}
+	after := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	for _, t := range s.NextN(after, 2) {
+		fmt.Println(t.Format("Mon Jan 2 15:04"))
+	}
+	// Output:
+	// Mon Oct 19 09:00
+	// Mon Oct 26 09:00
+}
+
+func ExampleNormalize() {
+	for _, expr := range []string{"0,15,30,45 9-17 * * MON-FRI", "0-59 * * * 7", "@weekly"} {
+		canonical, err := cronexpr.Normalize(expr)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(canonical)
+	}
+	// Output:
+	// */15 9-17 * * 1-5
+	// * * * * 0
+	// 0 0 * * 0
+}
+
+func ExampleDifference_Summary() {
+	// One week from Saturday October 17th 2026
+	start := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	d, err := cronexpr.Diff("0 9 * * 1-5", "0 9,17 * * 1-6", start, start.AddDate(0, 0, 7))
+	if err != nil {
+		panic(err)
+	}
+	for _, line := range d.Summary() {
+		fmt.Println(line)
+	}
+	// Output:
+	// now also fires on Saturdays at 09:00
+	// now also fires from Monday to Saturday at 17:00
+}
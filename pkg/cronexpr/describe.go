@@ -0,0 +1,82 @@
+package cronexpr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Describe gives a short English description of a cron expression. Invalid
+// expressions are described by their error.
+func Describe(expr string) string {
+	expr = strings.TrimSpace(expr)
+
+	// Handle special strings
+	specialDescriptions := map[string]string{
+		"@yearly":   "Once a year at midnight on January 1st (0 0 1 1 *)",
+		"@annually": "Once a year at midnight on January 1st (0 0 1 1 *)",
+		"@monthly":  "Once a month at midnight on the 1st (0 0 1 * *)",
+		"@weekly":   "Once a week at midnight on Sunday (0 0 * * 0)",
+		"@daily":    "Once a day at midnight (0 0 * * *)",
+		"@midnight": "Once a day at midnight (0 0 * * *)",
+		"@hourly":   "Once an hour at the start of the hour (0 * * * *)",
+		"@reboot":   "Once at system startup",
+	}
+
+	if desc, ok := specialDescriptions[strings.ToLower(expr)]; ok {
+		return desc
+	}
+
+	// Validate first
+	if err := Validate(expr); err != nil {
+		return fmt.Sprintf("Invalid cron expression: %v", err)
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return "Invalid cron expression format"
+	}
+
+	parts := []string{}
+
+	// Minute
+	if fields[0] != "*" {
+		parts = append(parts, "at minute "+describeField(fields[0]))
+	}
+
+	// Hour
+	if fields[1] != "*" {
+		parts = append(parts, "hour "+describeField(fields[1]))
+	}
+
+	// Day of month
+	if fields[2] != "*" {
+		parts = append(parts, "on day "+describeField(fields[2]))
+	}
+
+	// Month
+	if fields[3] != "*" {
+		parts = append(parts, "in month "+describeField(fields[3]))
+	}
+
+	// Weekday
+	if fields[4] != "*" {
+		parts = append(parts, "on weekday "+describeField(fields[4]))
+	}
+
+	if len(parts) == 0 {
+		return "Every minute"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// describeField provides a simple description of a field value
+func describeField(field string) string {
+	if field == "*" {
+		return "every"
+	}
+	if strings.HasPrefix(field, "*/") {
+		return "every " + field[2:]
+	}
+	return field
+}
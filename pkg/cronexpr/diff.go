@@ -0,0 +1,177 @@
+package cronexpr
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/english"
+)
+
+// weeksChecked is how many weeks a difference must recur for to be
+// summarised by weekday rather than by date
+const weeksChecked = 53
+
+// Difference holds the fire times of two schedules that do not coincide
+// within a window
+type Difference struct {
+	Start   time.Time   // first minute compared
+	End     time.Time   // end of the window, not included
+	Added   []time.Time // times only the second schedule fires
+	Removed []time.Time // times only the first schedule fires
+	Shared  int         // number of times both fire
+
+	before, after *Schedule
+}
+
+// Empty reports whether the two schedules fired at the same times
+func (d *Difference) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// Diff compares the fire times of a and b from start up to, but not
+// including, end. Times are minutes in start's location.
+func Diff(a, b string, start, end time.Time) (*Difference, error) {
+	sa, err := Parse(a)
+	if err != nil {
+		return nil, err
+	}
+	sb, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	return sa.Diff(sb, start, end), nil
+}
+
+// Diff compares the fire times of the schedule with other's from start up
+// to, but not including, end
+func (s *Schedule) Diff(other *Schedule, start, end time.Time) *Difference {
+	d := &Difference{Start: start, End: end, before: s, after: other}
+	t := start.Truncate(time.Minute)
+	if t.Before(start) {
+		t = t.Add(time.Minute)
+	}
+	for ; t.Before(end); t = t.Add(time.Minute) {
+		inS, inOther := s.Matches(t), other.Matches(t)
+		switch {
+		case inS && inOther:
+			d.Shared++
+		case inS:
+			d.Removed = append(d.Removed, t)
+		case inOther:
+			d.Added = append(d.Added, t)
+		}
+	}
+	return d
+}
+
+// Summary describes the difference in sentences such as "now also fires on
+// Saturdays at 09:00" and "no longer fires on Mon 2 Nov at 00:00". A change
+// is described by weekday when the window is at least a week long and the
+// change recurs every week for a year, and by date otherwise. It is empty when the schedules fired at the same times.
+func (d *Difference) Summary() []string {
+	lines := d.summarize("now also fires", d.Added, d.after, d.before)
+	return append(lines, d.summarize("no longer fires", d.Removed, d.before, d.after)...)
+}
+
+// summarize groups runs that fire in one schedule and not the other by the
+// days they fall on, and describes each group in a sentence
+func (d *Difference) summarize(verb string, runs []time.Time, in, out *Schedule) []string {
+	byTime := make(map[int][]time.Time) // minute of the day -> runs
+	for _, t := range runs {
+		minute := t.Hour()*60 + t.Minute()
+		byTime[minute] = append(byTime[minute], t)
+	}
+	minutes := make([]int, 0, len(byTime))
+	for minute := range byTime {
+		minutes = append(minutes, minute)
+	}
+	sort.Ints(minutes)
+
+	// Times of the day with the same days make one sentence, in order of
+	// their earliest time
+	var order []string
+	times := make(map[string][]string)
+	for _, minute := range minutes {
+		days := d.describeDays(byTime[minute], in, out)
+		if _, seen := times[days]; !seen {
+			order = append(order, days)
+		}
+		times[days] = append(times[days], fmt.Sprintf("%02d:%02d", minute/60, minute%60))
+	}
+
+	lines := make([]string, 0, len(order))
+	for _, days := range order {
+		lines = append(lines, fmt.Sprintf("%s %s at %s", verb, days, english.List(times[days], "times")))
+	}
+	return lines
+}
+
+// describeDays describes the days that runs at one time of the day fall on.
+// It names weekdays, such as "on Saturdays", when in fires and out does not
+// at that time on every one of those weekdays for a year, and dates otherwise.
+// A window shorter than a week has not seen every weekday, so it always
+// names dates.
+func (d *Difference) describeDays(runs []time.Time, in, out *Schedule) string {
+	var weekdays [7]bool
+	for _, t := range runs {
+		weekdays[t.Weekday()] = true
+	}
+
+	weekly := !d.End.Before(d.Start.AddDate(0, 0, 7))
+	first := runs[0]
+	for w := time.Sunday; w <= time.Saturday && weekly; w++ {
+		if !weekdays[w] {
+			continue
+		}
+		offset := (int(w) - int(d.Start.Weekday()) + 7) % 7
+		day := time.Date(d.Start.Year(), d.Start.Month(), d.Start.Day()+offset, first.Hour(), first.Minute(), 0, 0, first.Location())
+		for i := 0; i < weeksChecked; i++ {
+			if !in.Matches(day) || out.Matches(day) {
+				weekly = false
+				break
+			}
+			day = day.AddDate(0, 0, 7)
+		}
+	}
+	if weekly {
+		return describeWeekdays(weekdays)
+	}
+
+	dates := make([]string, len(runs))
+	for i, t := range runs {
+		dates[i] = t.Format("Mon 2 Jan")
+	}
+	return "on " + english.List(dates, "days")
+}
+
+// describeWeekdays names a set of weekdays, such as "on weekdays", "from
+// Monday to Saturday" or "on Mondays and Thursdays"
+func describeWeekdays(weekdays [7]bool) string {
+	// Weeks start on Monday here, so that Monday to Friday is one run
+	var days []time.Weekday
+	for i := 1; i <= 7; i++ {
+		if w := time.Weekday(i % 7); weekdays[w] {
+			days = append(days, w)
+		}
+	}
+
+	first, last := days[0], days[len(days)-1]
+	contiguous := (int(last)+6)%7-(int(first)+6)%7 == len(days)-1
+	switch {
+	case len(days) == 7:
+		return "every day"
+	case contiguous && first == time.Monday && last == time.Friday:
+		return "on weekdays"
+	case contiguous && first == time.Saturday && last == time.Sunday:
+		return "at weekends"
+	case contiguous && len(days) >= 3:
+		return fmt.Sprintf("from %s to %s", first, last)
+	}
+
+	names := make([]string, len(days))
+	for i, w := range days {
+		names[i] = w.String() + "s"
+	}
+	return "on " + english.Join(names)
+}
@@ -0,0 +1,156 @@
+package cronexpr
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// fieldStyle says whether a formatted day field must start with *
+type fieldStyle int
+
+const (
+	anyStyle   fieldStyle = iota
+	starStyle             // must be * or */n
+	plainStyle            // must not start with *
+)
+
+// Normalize rewrites expr in canonical minimal form. Names become numbers,
+// Sunday is 0, @-shortcuts other than @reboot are spelled out, and each field
+// is written in its shortest form: "0,15,30,45" becomes "*/15", "0-59"
+// becomes "*" and "1-5/1" becomes "1-5". Expressions with the same canonical
+// form fire at the same times, but the converse does not always hold: "0 0 1-30
+// 4 *" and "0 0 * 4 *" are written differently because April happens to have
+// 30 days. Use Equal to compare schedules.
+func Normalize(expr string) (string, error) {
+	s, err := Parse(expr)
+	if err != nil {
+		return "", err
+	}
+	return s.String(), nil
+}
+
+// String returns the schedule in the canonical form described by Normalize
+func (s *Schedule) String() string {
+	if s.Reboot {
+		return "@reboot"
+	}
+
+	// Whether a day field starts with * decides if the day fields are ORed,
+	// so the canonical form has to keep that, even where it costs characters
+	days, weekdays := s.Day, s.Weekday
+	dayStyle, weekdayStyle := anyStyle, anyStyle
+	if !s.DayStar && !s.WeekdayStar {
+		if days == stepSet(DayOfMonth.Min(), DayOfMonth.Max(), 1) || weekdays == stepSet(DayOfWeek.Min(), 6, 1) {
+			// Either field matching every day makes the OR match every day
+			days, weekdays = stepSet(DayOfMonth.Min(), DayOfMonth.Max(), 1), stepSet(DayOfWeek.Min(), 6, 1)
+		} else {
+			dayStyle, weekdayStyle = plainStyle, plainStyle
+		}
+	}
+	day := formatField(days, DayOfMonth.Min(), DayOfMonth.Max(), dayStyle)
+	weekday := formatField(weekdays, DayOfWeek.Min(), 6, weekdayStyle)
+	if dayStyle == anyStyle && !strings.HasPrefix(day, "*") && !strings.HasPrefix(weekday, "*") {
+		if s.DayStar {
+			day = formatField(days, DayOfMonth.Min(), DayOfMonth.Max(), starStyle)
+		} else {
+			weekday = formatField(weekdays, DayOfWeek.Min(), 6, starStyle)
+		}
+	}
+
+	return strings.Join([]string{
+		formatField(s.Minute, Minute.Min(), Minute.Max(), anyStyle),
+		formatField(s.Hour, Hour.Min(), Hour.Max(), anyStyle),
+		day,
+		formatField(s.Month, Month.Min(), Month.Max(), anyStyle),
+		weekday,
+	}, " ")
+}
+
+// formatField writes the values of a field in the shortest form the style
+// allows. Ties go to the earlier of a list of values and ranges, */n, and a
+// stepped range, so 0,30 stays as it is.
+func formatField(set uint64, min, max int, style fieldStyle) string {
+	if set == stepSet(min, max, 1) {
+		if style == plainStyle {
+			return fmt.Sprintf("%d-%d", min, max)
+		}
+		return "*"
+	}
+
+	var candidates []string
+	if style != starStyle {
+		candidates = append(candidates, listForm(set, min, max))
+	}
+	if style != plainStyle {
+		for step := 2; step <= max-min+1; step++ {
+			if set == stepSet(min, max, step) {
+				candidates = append(candidates, fmt.Sprintf("*/%d", step))
+				break
+			}
+		}
+	}
+	if style != starStyle {
+		if form, ok := progressionForm(set, min, max); ok {
+			candidates = append(candidates, form)
+		}
+	}
+
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if len(c) < len(best) {
+			best = c
+		}
+	}
+	return best
+}
+
+// stepSet returns the values from min to max in steps of step
+func stepSet(min, max, step int) uint64 {
+	var set uint64
+	for v := min; v <= max; v += step {
+		set |= 1 << uint(v)
+	}
+	return set
+}
+
+// listForm writes a set as a comma-separated list, with runs of three or
+// more values as ranges
+func listForm(set uint64, min, max int) string {
+	var items []string
+	for v := min; v <= max; v++ {
+		if !hasBit(set, v) {
+			continue
+		}
+		end := v
+		for end < max && hasBit(set, end+1) {
+			end++
+		}
+		switch end - v {
+		case 0:
+			items = append(items, fmt.Sprint(v))
+		case 1:
+			items = append(items, fmt.Sprint(v), fmt.Sprint(end))
+		default:
+			items = append(items, fmt.Sprintf("%d-%d", v, end))
+		}
+		v = end
+	}
+	return strings.Join(items, ",")
+}
+
+// progressionForm writes a set of three or more evenly spaced values as a
+// stepped range such as 5-45/10
+func progressionForm(set uint64, min, max int) (string, bool) {
+	if bits.OnesCount64(set) < 3 {
+		return "", false
+	}
+	first := bits.TrailingZeros64(set)
+	rest := set &^ (1 << uint(first))
+	step := bits.TrailingZeros64(rest) - first
+	last := 63 - bits.LeadingZeros64(set)
+	if step < 2 || first < min || last > max || set != stepSet(first, last, step) {
+		return "", false
+	}
+	return fmt.Sprintf("%d-%d/%d", first, last, step), true
+}
@@ -0,0 +1,114 @@
+package cronexpr
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reachCycle is the number of years after which dates fall on the same
+// weekdays again, between 1901 and 2099
+const reachCycle = 28
+
+// reachYears is how many of the next fire years Reach lists
+const reachYears = 3
+
+// Reach is how often a schedule fires
+type Reach struct {
+	// PerYear is the average number of runs a year, over a cycle of 28
+	// years. It is 0.25 for February 29th.
+	PerYear float64
+
+	// Years are the next few years, from the one Reach was asked about, in
+	// which the schedule fires
+	Years []int
+}
+
+// Reach works out how often the schedule fires, and in which years from
+// from's year on. @reboot schedules have no calendar, so their Reach is zero.
+func (s *Schedule) Reach(from time.Time) Reach {
+	var r Reach
+	if s.Reboot {
+		return r
+	}
+
+	perDay := bits.OnesCount64(s.Minute) * bits.OnesCount64(s.Hour)
+	days := 0
+	day := time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for end := day.AddDate(reachCycle, 0, 0); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if s.matchesDay(day) {
+			days++
+		}
+	}
+	r.PerYear = float64(days*perDay) / reachCycle
+	if r.PerYear == 0 {
+		return r
+	}
+
+	// Leap days can be eight years apart, so two cycles always find a few
+	for year := from.Year(); year < from.Year()+2*reachCycle && len(r.Years) < reachYears; year++ {
+		if s.firesIn(year) {
+			r.Years = append(r.Years, year)
+		}
+	}
+	return r
+}
+
+// firesIn reports whether the schedule fires on any day of the year
+func (s *Schedule) firesIn(year int) bool {
+	day := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for ; day.Year() == year; day = day.AddDate(0, 0, 1) {
+		if s.matchesDay(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// RareError is returned by CheckReach for schedules that fire, but fewer
+// times a year than asked for
+type RareError struct {
+	Reach Reach
+	Min   int // the fewest runs a year asked for
+}
+
+func (e *RareError) Error() string {
+	years := make([]string, len(e.Reach.Years))
+	for i, y := range e.Reach.Years {
+		years[i] = strconv.Itoa(y)
+	}
+	next := strings.Join(years, ", ")
+	if len(years) > 1 {
+		next = strings.Join(years[:len(years)-1], ", ") + " and " + years[len(years)-1]
+	}
+	times := strconv.FormatFloat(e.Reach.PerYear, 'g', 3, 64) + " times"
+	if e.Reach.PerYear == 1 {
+		times = "once"
+	}
+	return fmt.Sprintf("fires %s a year on average, fewer than %d; next in %s", times, e.Min, next)
+}
+
+// CheckReach checks that a valid expression can actually run. It returns
+// ErrNeverFires for schedules such as February 30th, and a *RareError for
+// those that fire fewer than minPerYear times a year, such as February 29th
+// with a minPerYear of 1. The next fire years are counted from from. @reboot
+// always passes, since it runs at every boot.
+func CheckReach(expr string, minPerYear int, from time.Time) error {
+	s, err := Parse(expr)
+	if err != nil {
+		return err
+	}
+	if s.Reboot {
+		return nil
+	}
+	r := s.Reach(from)
+	if r.PerYear == 0 {
+		return ErrNeverFires
+	}
+	if r.PerYear < float64(minPerYear) {
+		return &RareError{Reach: r, Min: minPerYear}
+	}
+	return nil
+}
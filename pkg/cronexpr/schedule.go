@@ -0,0 +1,228 @@
+package cronexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchDays limits how far ahead Next looks for a fire time.
+// Eight years is enough to reach the next February 29th.
+const maxSearchDays = 366 * 8
+
+// specialExpressions maps the @-shortcuts to their five field equivalents
+var specialExpressions = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Schedule is a cron expression expanded into the set of values each field matches
+type Schedule struct {
+	Minute  uint64
+	Hour    uint64
+	Day     uint64
+	Month   uint64
+	Weekday uint64 // Sunday is always stored as 0, never 7
+
+	// DayStar and WeekdayStar record whether the day fields started with *.
+	// Cron only ORs day-of-month and day-of-week when both are restricted.
+	DayStar     bool
+	WeekdayStar bool
+
+	// Reboot is set for @reboot, which has no calendar schedule
+	Reboot bool
+}
+
+// Parse parses a cron expression into a Schedule
+func Parse(expr string) (*Schedule, error) {
+	if err := Validate(expr); err != nil {
+		return nil, err
+	}
+
+	exprLower := strings.ToLower(strings.TrimSpace(expr))
+	if exprLower == "@reboot" {
+		return &Schedule{Reboot: true}, nil
+	}
+	if full, ok := specialExpressions[exprLower]; ok {
+		expr = full
+	}
+
+	fields := strings.Fields(expr)
+
+	var sets [5]uint64
+	for i, field := range fields {
+		f := Field(i)
+		set, err := ExpandField(replaceNames(field, f), f.Min(), f.Max())
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %w", i+1, err)
+		}
+		sets[i] = set
+	}
+
+	// Fold Sunday-as-7 onto 0 so equivalent schedules compare equal
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = (sets[4] &^ (1 << 7)) | 1
+	}
+
+	return &Schedule{
+		Minute:      sets[0],
+		Hour:        sets[1],
+		Day:         sets[2],
+		Month:       sets[3],
+		Weekday:     sets[4],
+		DayStar:     strings.HasPrefix(fields[2], "*"),
+		WeekdayStar: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// ExpandField turns a single numeric field, such as 1-5 or */15, into a bit
+// set of the values it matches: bit v is set when the field matches v. A *
+// covers min to max.
+func ExpandField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		item = strings.TrimSpace(item)
+		start, end, step := min, max, 1
+
+		rangePart := item
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			rangePart = item[:idx]
+			n, err := strconv.Atoi(item[idx+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step value: %s", item[idx+1:])
+			}
+			step = n
+		}
+
+		switch {
+		case rangePart == "*":
+			// Full range, already set
+		case strings.Contains(rangePart, "-"):
+			parts := strings.SplitN(rangePart, "-", 2)
+			a, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+			if err != nil {
+				return 0, fmt.Errorf("invalid range start: %s", parts[0])
+			}
+			b, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil {
+				return 0, fmt.Errorf("invalid range end: %s", parts[1])
+			}
+			start, end = a, b
+		default:
+			v, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value: %s", rangePart)
+			}
+			start, end = v, v
+		}
+
+		for v := start; v <= end; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// hasBit reports whether value v is present in the set
+func hasBit(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+// matchesDay reports whether the schedule runs on the given calendar day
+func (s *Schedule) matchesDay(t time.Time) bool {
+	if !hasBit(s.Month, int(t.Month())) {
+		return false
+	}
+
+	dayMatch := hasBit(s.Day, t.Day())
+	weekdayMatch := hasBit(s.Weekday, int(t.Weekday()))
+
+	if s.DayStar || s.WeekdayStar {
+		return dayMatch && weekdayMatch
+	}
+	return dayMatch || weekdayMatch
+}
+
+// Matches reports whether the schedule fires at the minute containing t
+func (s *Schedule) Matches(t time.Time) bool {
+	if s.Reboot {
+		return false
+	}
+	return s.matchesDay(t) && hasBit(s.Hour, t.Hour()) && hasBit(s.Minute, t.Minute())
+}
+
+// Next returns the first fire time strictly after the given time.
+// The second return value is false if the schedule never fires within the search window.
+func (s *Schedule) Next(after time.Time) (time.Time, bool) {
+	if s.Reboot {
+		return time.Time{}, false
+	}
+
+	start := after.Truncate(time.Minute).Add(time.Minute)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+
+	for i := 0; i < maxSearchDays; i++ {
+		if s.matchesDay(day) {
+			for h := 0; h < 24; h++ {
+				if !hasBit(s.Hour, h) {
+					continue
+				}
+				for m := 0; m < 60; m++ {
+					if !hasBit(s.Minute, m) {
+						continue
+					}
+					t := time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+					if !t.Before(start) {
+						return t, true
+					}
+				}
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return time.Time{}, false
+}
+
+// NextN returns up to n fire times after the given time
+func (s *Schedule) NextN(after time.Time, n int) []time.Time {
+	var times []time.Time
+	t := after
+	for len(times) < n {
+		next, ok := s.Next(t)
+		if !ok {
+			break
+		}
+		times = append(times, next)
+		t = next
+	}
+	return times
+}
+
+// Equal reports whether two schedules fire at exactly the same times
+func (s *Schedule) Equal(other *Schedule) bool {
+	if s.Reboot || other.Reboot {
+		return s.Reboot == other.Reboot
+	}
+	if s.Minute != other.Minute || s.Hour != other.Hour || s.Month != other.Month {
+		return false
+	}
+
+	// The day fields interact through the OR rule, so compare them day by day
+	// over a 28 year span, after which the weekday/date pattern repeats
+	day := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := day.AddDate(28, 0, 0)
+	for day.Before(end) {
+		if s.matchesDay(day) != other.matchesDay(day) {
+			return false
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return true
+}
@@ -1,4 +1,4 @@
-package koan
+package cronexpr
 
 import (
 	"fmt"
@@ -7,19 +7,13 @@ import (
 	"strings"
 )
 
-// ValidateCronExpression validates if a cron expression is syntactically correct
-func ValidateCronExpression(expr string) error {
+// Validate reports whether a cron expression is syntactically correct: five
+// fields, or one of the @ shortcuts such as @daily or @reboot
+func Validate(expr string) error {
 	// Check for special strings first
-	specialStrings := []string{
-		"@yearly", "@annually", "@monthly", "@weekly",
-		"@daily", "@midnight", "@hourly", "@reboot",
-	}
-
 	exprLower := strings.ToLower(strings.TrimSpace(expr))
-	for _, special := range specialStrings {
-		if exprLower == special {
-			return nil // Valid special string
-		}
+	if _, ok := specialExpressions[exprLower]; ok || exprLower == "@reboot" {
+		return nil // Valid special string
 	}
 
 	// Split into fields
@@ -44,7 +38,7 @@ func ValidateCronExpression(expr string) error {
 	}
 
 	for _, v := range validators {
-		if err := validateField(v.field, v.min, v.max, v.name); err != nil {
+		if err := validateField(replaceNames(v.field, Field(v.position)), v.min, v.max, v.name); err != nil {
 			return fmt.Errorf("field %d (%s): %w", v.position+1, v.name, err)
 		}
 	}
@@ -52,6 +46,39 @@ func ValidateCronExpression(expr string) error {
 	return nil
 }
 
+// monthNames and weekdayNames are the three-letter names cron accepts in the month and weekday fields
+var (
+	monthNames   = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+	weekdayNames = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+)
+
+// namePattern matches a word in a cron field
+var namePattern = regexp.MustCompile(`[A-Za-z]+`)
+
+// replaceNames replaces month or weekday names with their numbers,
+// case-insensitively. Unknown words are left for validation to reject.
+func replaceNames(field string, f Field) string {
+	var names []string
+	offset := 0
+	switch f {
+	case Month:
+		names, offset = monthNames, 1
+	case DayOfWeek:
+		names = weekdayNames
+	default:
+		return field
+	}
+
+	return namePattern.ReplaceAllStringFunc(field, func(word string) string {
+		for i, name := range names {
+			if strings.EqualFold(word, name) {
+				return strconv.Itoa(i + offset)
+			}
+		}
+		return word
+	})
+}
+
 // validateField validates a single cron field
 func validateField(field string, min, max int, fieldName string) error {
 	// Wildcard
@@ -172,95 +199,3 @@ func validateSingleValue(valueStr string, min, max int, fieldName string) error
 
 	return nil
 }
-
-// DescribeCronExpression provides a human-readable description of a cron expression
-func DescribeCronExpression(expr string) string {
-	expr = strings.TrimSpace(expr)
-
-	// Handle special strings
-	specialDescriptions := map[string]string{
-		"@yearly":   "Once a year at midnight on January 1st (0 0 1 1 *)",
-		"@annually": "Once a year at midnight on January 1st (0 0 1 1 *)",
-		"@monthly":  "Once a month at midnight on the 1st (0 0 1 * *)",
-		"@weekly":   "Once a week at midnight on Sunday (0 0 * * 0)",
-		"@daily":    "Once a day at midnight (0 0 * * *)",
-		"@midnight": "Once a day at midnight (0 0 * * *)",
-		"@hourly":   "Once an hour at the start of the hour (0 * * * *)",
-		"@reboot":   "Once at system startup",
-	}
-
-	if desc, ok := specialDescriptions[strings.ToLower(expr)]; ok {
-		return desc
-	}
-
-	// Validate first
-	if err := ValidateCronExpression(expr); err != nil {
-		return fmt.Sprintf("Invalid cron expression: %v", err)
-	}
-
-	fields := strings.Fields(expr)
-	if len(fields) != 5 {
-		return "Invalid cron expression format"
-	}
-
-	parts := []string{}
-
-	// Minute
-	if fields[0] != "*" {
-		parts = append(parts, "at minute "+describeField(fields[0]))
-	}
-
-	// Hour
-	if fields[1] != "*" {
-		parts = append(parts, "hour "+describeField(fields[1]))
-	}
-
-	// Day of month
-	if fields[2] != "*" {
-		parts = append(parts, "on day "+describeField(fields[2]))
-	}
-
-	// Month
-	if fields[3] != "*" {
-		parts = append(parts, "in month "+describeField(fields[3]))
-	}
-
-	// Weekday
-	if fields[4] != "*" {
-		parts = append(parts, "on weekday "+describeField(fields[4]))
-	}
-
-	if len(parts) == 0 {
-		return "Every minute"
-	}
-
-	return strings.Join(parts, ", ")
-}
-
-// describeField provides a simple description of a field value
-func describeField(field string) string {
-	if field == "*" {
-		return "every"
-	}
-	if strings.HasPrefix(field, "*/") {
-		return "every " + field[2:]
-	}
-	return field
-}
-
-// IsValidCronAnswer checks if the user's answer creates a valid cron expression
-func IsValidCronAnswer(incomplete, answer string) bool {
-	complete := replaceBlank(incomplete, answer)
-	return ValidateCronExpression(complete) == nil
-}
-
-// GetCronValidationRegex returns a regex pattern for basic cron validation
-func GetCronValidationRegex() *regexp.Regexp {
-	// Basic pattern for cron expression validation
-	// This is a simplified version and actual validation should use ValidateCronExpression
-	pattern := `^(@(yearly|annually|monthly|weekly|daily|midnight|hourly|reboot)|` +
-		`((\*|[0-9]|[1-5][0-9]|\*/[0-9]+|[0-9]+-[0-9]+|[0-9]+(,[0-9]+)*)\s+){4}` +
-		`(\*|[0-7]|\*/[0-9]+|[0-7]-[0-7]|[0-7](,[0-7]+)*))$`
-
-	return regexp.MustCompile(pattern)
-}
@@ -0,0 +1,181 @@
+// Package crontab reads user crontab files, as edited with crontab -e, and
+// works out how the jobs in them load a host.
+//
+// A crontab has one job per line: a cron expression, or an @ shortcut such as
+// @daily, followed by the command. Lines starting with # are comments, and
+// lines such as PATH=/usr/bin set environment variables. A comment of the
+// form "# duration: 10m" says how long the job on the next line usually runs.
+//
+// Crontab.Lint and LintExpression check for classic mistakes that are still
+// valid syntax, such as a * in the minute field or an unescaped %.
+package crontab
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+)
+
+// Job is one scheduled line of a crontab
+type Job struct {
+	Line       int    // line number, from 1
+	Expression string // the schedule as written, such as "*/5 * * * *" or "@daily"
+	Command    string // the rest of the line, as cron passes it to the shell
+	Schedule   *cronexpr.Schedule
+
+	// Duration is how long a run is expected to take, from a "# duration:"
+	// comment before the job. It is 0 when unknown.
+	Duration time.Duration
+}
+
+// Variable is an environment variable set in a crontab, such as PATH
+type Variable struct {
+	Line  int
+	Name  string
+	Value string
+}
+
+// Comment is a comment line of a crontab, without its leading #
+type Comment struct {
+	Line int
+	Text string
+}
+
+// Crontab is a parsed crontab file
+type Crontab struct {
+	Jobs      []*Job
+	Variables []Variable
+	Comments  []Comment
+}
+
+// Variable returns the value of the named environment variable and whether
+// the crontab sets it
+func (c *Crontab) Variable(name string) (string, bool) {
+	for _, v := range c.Variables {
+		if v.Name == name {
+			return v.Value, true
+		}
+	}
+	return "", false
+}
+
+// LineError is a problem with one line of a crontab
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// variablePattern matches an environment setting such as PATH=/usr/bin
+	variablePattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(.*)$`)
+
+	// durationPattern matches a "# duration: 10m" annotation
+	durationPattern = regexp.MustCompile(`^#\s*duration:\s*(\S+)\s*$`)
+)
+
+// ReadFile parses the crontab file at path
+func ReadFile(path string) (*Crontab, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Parse reads a crontab. It stops at the first line that is neither a job,
+// a variable nor a comment, returning a *LineError.
+func Parse(r io.Reader) (*Crontab, error) {
+	c := &Crontab{}
+	var duration time.Duration
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			if m := durationPattern.FindStringSubmatch(line); m != nil {
+				d, err := time.ParseDuration(m[1])
+				if err != nil || d <= 0 {
+					return nil, &LineError{n, fmt.Errorf("invalid duration %q (want a duration such as 10m or 1h30m)", m[1])}
+				}
+				duration = d
+			}
+			c.Comments = append(c.Comments, Comment{Line: n, Text: strings.TrimSpace(line[1:])})
+			continue
+		}
+
+		if m := variablePattern.FindStringSubmatch(line); m != nil {
+			c.Variables = append(c.Variables, Variable{Line: n, Name: m[1], Value: unquote(m[2])})
+			continue
+		}
+
+		job, err := parseJob(line)
+		if err != nil {
+			return nil, &LineError{n, err}
+		}
+		job.Line = n
+		job.Duration = duration
+		duration = 0
+		c.Jobs = append(c.Jobs, job)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseJob splits a job line into its schedule and command
+func parseJob(line string) (*Job, error) {
+	fields := 5
+	if strings.HasPrefix(line, "@") {
+		fields = 1
+	}
+
+	rest := line
+	for i := 0; i < fields; i++ {
+		rest = strings.TrimLeft(rest, " \t")
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			return nil, fmt.Errorf("missing command after the schedule")
+		}
+		rest = rest[end:]
+	}
+	expr := strings.Join(strings.Fields(line[:len(line)-len(rest)]), " ")
+	command := strings.TrimSpace(rest)
+
+	s, err := cronexpr.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid cron expression: %w", expr, err)
+	}
+	return &Job{Expression: expr, Command: command, Schedule: s}, nil
+}
+
+// unquote removes the quotes cron allows around a variable's value
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,182 @@
+package crontab_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwildt/cronkoans/pkg/crontab"
+)
+
+const sample = `# Nightly jobs
+PATH = "/usr/local/bin:/usr/bin"
+MAILTO=ops@example.com
+
+0 0 * * *   backup.sh --full   > /var/log/backup.log
+# duration: 90s
+*/1 * * * * heartbeat
+@hourly rotate-logs
+0 0 * * * vacuum-db
+`
+
+func TestParse(t *testing.T) {
+	tab, err := crontab.Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tab.Jobs) != 4 || len(tab.Variables) != 2 {
+		t.Fatalf("got %d jobs and %d variables, want 4 and 2", len(tab.Jobs), len(tab.Variables))
+	}
+	if path, ok := tab.Variable("PATH"); !ok || path != "/usr/local/bin:/usr/bin" {
+		t.Errorf("PATH = %q, %v", path, ok)
+	}
+	if _, ok := tab.Variable("SHELL"); ok {
+		t.Error("SHELL is not set, but Variable found it")
+	}
+
+	backup := tab.Jobs[0]
+	if backup.Line != 5 || backup.Expression != "0 0 * * *" || backup.Command != "backup.sh --full   > /var/log/backup.log" || backup.Duration != 0 {
+		t.Errorf("backup job: %+v", backup)
+	}
+	if heartbeat := tab.Jobs[1]; heartbeat.Duration != 90*time.Second || heartbeat.Expression != "*/1 * * * *" {
+		t.Errorf("heartbeat job: %+v", heartbeat)
+	}
+	if hourly := tab.Jobs[2]; hourly.Expression != "@hourly" || hourly.Command != "rotate-logs" || hourly.Duration != 0 {
+		t.Errorf("hourly job: %+v", hourly)
+	}
+
+	bad := []struct {
+		text, want string
+	}{
+		{"0 0 * * *", "line 1: missing command"},
+		{"# ok\n0 0 32 * * cmd", "line 2: '0 0 32 * *' is not a valid cron expression"},
+		{"# duration: soon\n* * * * * cmd", "line 1: invalid duration"},
+	}
+	for _, tt := range bad {
+		_, err := crontab.Parse(strings.NewReader(tt.text))
+		var lineErr *crontab.LineError
+		if !errors.As(err, &lineErr) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q): got %v, want a line error containing %q", tt.text, err, tt.want)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	tab, err := crontab.Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two days from Saturday October 17th 2026
+	start := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	load := tab.Analyze(start, start.AddDate(0, 0, 2), 3)
+
+	if want := 2 + 2*24*60 + 48 + 2; load.Starts != want {
+		t.Errorf("Starts = %d, want %d", load.Starts, want)
+	}
+	if load.Peaks[0] != 4 || load.Peaks[1] != 1 {
+		t.Errorf("Peaks = %v", load.Peaks)
+	}
+
+	if len(load.Hotspots) != 1 {
+		t.Fatalf("got %d hotspots, want 1: %v", len(load.Hotspots), load.Hotspots)
+	}
+	if h := load.Hotspots[0]; h.String() != "4 jobs start at 00:00" || h.Days != 2 {
+		t.Errorf("hotspot: %s on %d days", h, h.Days)
+	}
+
+	if len(load.Overlaps) != 1 || load.Overlaps[0].Job.Line != 7 || load.Overlaps[0].Overlapping != 2*24*60 {
+		t.Errorf("overlaps: %+v", load.Overlaps)
+	}
+
+	// The backup stays, heartbeat fires every minute and cannot move, and
+	// the others go to the quietest minutes after 0
+	var got []string
+	for _, s := range load.Suggestions {
+		got = append(got, s.Job.Command+": "+s.Expression)
+	}
+	want := []string{"rotate-logs: 1 * * * *", "vacuum-db: 2 0 * * *"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("suggestions = %q, want %q", got, want)
+	}
+}
+
+// lintFrom is the time lint looks for runs after, a Saturday
+var lintFrom = time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+func TestLintExpression(t *testing.T) {
+	tests := []struct {
+		expr  string
+		rules []string
+	}{
+		{"0 9 * * *", nil},
+		{"* 9 * * *", []string{"minute-wildcard"}},
+		{"* * * * *", nil},
+		{"0 0 13 * 5", []string{"day-or"}},
+		{"0 0 1-7 * 1", []string{"day-or"}},
+		{"0 0 */2 * 1", nil},
+		{"0 0 31 2 *", []string{"never-fires"}},
+		{"0 0 31 4,6,9,11 *", []string{"never-fires"}},
+		{"0 0 30 * *", []string{"short-months"}},
+		{"0 0 30 1,3 *", nil},
+		{"0 0 29 2 *", []string{"short-months"}},
+		{"*/7 * * * *", []string{"uneven-step"}},
+		{"5-59/10 * * * *", nil},
+		{"0-30/10 * * * *", nil},
+		{"0 */5 * * *", []string{"uneven-step"}},
+		{"@reboot", nil},
+		{"@daily", nil},
+	}
+	for _, tt := range tests {
+		findings, err := crontab.LintExpression(tt.expr, nil, lintFrom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, f := range findings {
+			got = append(got, f.Rule.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.rules, ",") {
+			t.Errorf("LintExpression(%q) = %v, want %v", tt.expr, got, tt.rules)
+		}
+	}
+
+	findings, _ := crontab.LintExpression("* 9 * * *", []string{"minute-wildcard"}, lintFrom)
+	if len(findings) != 0 {
+		t.Errorf("disabled rule still reported: %v", findings)
+	}
+	if _, err := crontab.LintExpression("* 25 * * *", nil, lintFrom); err == nil {
+		t.Error("LintExpression of an invalid expression: no error")
+	}
+}
+
+func TestLint(t *testing.T) {
+	text := `# cronkoans:disable missing-shell
+0 1 * * * /usr/bin/backup > /tmp/backup-$(date +%F).log
+0 2 * * * /usr/bin/backup > /tmp/backup-$(date +\%F).log
+# cronkoans:disable-next-line minute-wildcard
+* 3 * * * /usr/bin/sweep
+* 4 * * * /usr/bin/sweep
+`
+	tab, err := crontab.Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range tab.Lint(nil, lintFrom) {
+		got = append(got, fmt.Sprintf("%d:%s", f.Line, f.Rule.Name))
+	}
+	// Every command has a full path, so PATH does not matter
+	want := []string{"2:unescaped-percent", "6:minute-wildcard"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Lint() = %v, want %v", got, want)
+	}
+
+	if findings := tab.Lint([]string{"unescaped-percent", "minute-wildcard"}, lintFrom); len(findings) != 0 {
+		t.Errorf("Lint with every rule disabled: %v", findings)
+	}
+	if crontab.FindRule("day-or") == nil || crontab.FindRule("nope") != nil {
+		t.Error("FindRule")
+	}
+}
@@ -0,0 +1,377 @@
+package crontab
+
+import (
+	"fmt"
+	"math/bits"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/english"
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+)
+
+// Severity says how serious a lint finding is
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // the job does not do what it says
+	SeverityWarning Severity = "warning" // the job probably does not do what was meant
+	SeverityInfo    Severity = "info"    // the job works, but could surprise someone
+)
+
+// Rule is a check for a classic cron mistake. Rules are named so that they
+// can be disabled one by one.
+type Rule struct {
+	Name        string
+	Severity    Severity
+	Explanation string // why it is a mistake and how to avoid it
+	Koan        string // ID of the built-in koan that teaches the fix, if any
+
+	// expression checks one schedule, whose runs are looked for after from,
+	// returning a message when it is at fault
+	expression func(fields []string, s *cronexpr.Schedule, from time.Time) string
+	// crontab checks a crontab as a whole
+	crontab func(c *Crontab) []Finding
+}
+
+// Finding is a problem a rule found
+type Finding struct {
+	Rule    *Rule
+	Line    int // line of the crontab, or 0 for a single expression
+	Message string
+}
+
+// Rules are the lint rules, in the order their findings are reported for one line
+var Rules = []*Rule{
+	{
+		Name:        "never-fires",
+		Severity:    SeverityError,
+		Explanation: "The days it names do not exist in the months it names, such as February 30th, so cron never runs the job.",
+		Koan:        "advanced_5",
+		expression:  neverFires,
+	},
+	{
+		Name:        "minute-wildcard",
+		Severity:    SeverityWarning,
+		Explanation: "A * in the minute field runs the job every minute of each hour it names. To run once an hour, give the minute, such as 0.",
+		Koan:        "wildcards_5",
+		expression:  minuteWildcard,
+	},
+	{
+		Name:     "day-or",
+		Severity: SeverityWarning,
+		Explanation: "When both the day of month and the day of week are restricted, cron runs the job on days that match either one, not both. " +
+			"To run on a weekday within some days of the month, schedule the days and check the weekday in the command.",
+		Koan:       "advanced_6",
+		expression: dayOr,
+	},
+	{
+		Name:        "short-months",
+		Severity:    SeverityWarning,
+		Explanation: "Days 29 to 31 do not exist in every month, and cron skips months without them instead of running on their last day.",
+		Koan:        "advanced_5",
+		expression:  shortMonths,
+	},
+	{
+		Name:     "uneven-step",
+		Severity: SeverityInfo,
+		Explanation: "A step restarts at the top of each hour or day, so a step that does not divide 60 minutes or 24 hours leaves a shorter gap there. " +
+			"Steps such as 5, 10, 15 or 20 minutes keep every gap the same.",
+		Koan:       "steps_1",
+		expression: unevenStep,
+	},
+	{
+		Name:     "unescaped-percent",
+		Severity: SeverityError,
+		Explanation: "Cron turns the first unescaped % of a command into a newline and sends the rest to the command's input. " +
+			`Write \% instead, as in date +\%F.`,
+		crontab: unescapedPercent,
+	},
+	{
+		Name:     "missing-path",
+		Severity: SeverityWarning,
+		Explanation: "Cron runs jobs with a minimal PATH, usually /usr/bin:/bin, so commands found in a login shell can be missing. " +
+			"Set PATH at the top of the crontab, or call commands by their full path.",
+		crontab: missingPath,
+	},
+	{
+		Name:     "missing-shell",
+		Severity: SeverityInfo,
+		Explanation: "Cron runs commands with /bin/sh, not the login shell, so bash features such as [[ ]] or {1..3} can fail. " +
+			"Set SHELL=/bin/bash at the top of the crontab if the commands need it.",
+		crontab: missingShell,
+	},
+}
+
+// FindRule returns the rule with the given name, or nil
+func FindRule(name string) *Rule {
+	for _, r := range Rules {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// disablePattern matches a comment that disables rules, either for the
+// next job or for the whole file
+var disablePattern = regexp.MustCompile(`^cronkoans:(disable|disable-next-line)\s+(.+)$`)
+
+// LintExpression checks a single expression against every rule about
+// schedules, except the disabled ones. Rules that look for runs, such as
+// never-fires, look after from.
+func LintExpression(expr string, disabled []string, from time.Time) ([]Finding, error) {
+	s, err := cronexpr.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	off := disabledSet(disabled)
+	var findings []Finding
+	for _, r := range Rules {
+		if r.expression == nil || off[r.Name] {
+			continue
+		}
+		if msg := r.expression(scheduleFields(expr, s), s, from); msg != "" {
+			findings = append(findings, Finding{Rule: r, Message: msg})
+		}
+	}
+	return findings, nil
+}
+
+// Lint checks every job of the crontab, and the crontab as a whole, ordered
+// by line. Rules are skipped when named in disabled, in a comment such as
+// "# cronkoans:disable missing-shell" anywhere in the file, or for one job in
+// a "# cronkoans:disable-next-line minute-wildcard" comment before it. Rules
+// that look for runs look after from.
+func (c *Crontab) Lint(disabled []string, from time.Time) []Finding {
+	off := disabledSet(disabled)
+	offForJob := make(map[*Job]map[string]bool)
+	for _, comment := range c.Comments {
+		m := disablePattern.FindStringSubmatch(comment.Text)
+		if m == nil {
+			continue
+		}
+		names := strings.FieldsFunc(m[2], func(r rune) bool { return r == ',' || r == ' ' })
+		if m[1] == "disable" {
+			for _, name := range names {
+				off[name] = true
+			}
+			continue
+		}
+		if job := c.jobAfter(comment.Line); job != nil {
+			if offForJob[job] == nil {
+				offForJob[job] = make(map[string]bool)
+			}
+			for _, name := range names {
+				offForJob[job][name] = true
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, r := range Rules {
+		if off[r.Name] {
+			continue
+		}
+		if r.crontab != nil {
+			for _, f := range r.crontab(c) {
+				f.Rule = r
+				if job := c.jobAt(f.Line); job == nil || !offForJob[job][r.Name] {
+					findings = append(findings, f)
+				}
+			}
+			continue
+		}
+		for _, job := range c.Jobs {
+			if offForJob[job][r.Name] {
+				continue
+			}
+			if msg := r.expression(scheduleFields(job.Expression, job.Schedule), job.Schedule, from); msg != "" {
+				findings = append(findings, Finding{Rule: r, Line: job.Line, Message: msg})
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// jobAfter returns the first job after the given line
+func (c *Crontab) jobAfter(line int) *Job {
+	for _, job := range c.Jobs {
+		if job.Line > line {
+			return job
+		}
+	}
+	return nil
+}
+
+// jobAt returns the job on the given line
+func (c *Crontab) jobAt(line int) *Job {
+	for _, job := range c.Jobs {
+		if job.Line == line {
+			return job
+		}
+	}
+	return nil
+}
+
+// disabledSet turns a list of rule names into a set
+func disabledSet(names []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, name := range names {
+		set[strings.TrimSpace(name)] = true
+	}
+	return set
+}
+
+// scheduleFields returns the five fields of an expression, spelling out
+// shortcuts such as @daily
+func scheduleFields(expr string, s *cronexpr.Schedule) []string {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		fields = strings.Fields(s.String())
+	}
+	return fields
+}
+
+// allValues is the set of every value from min to max
+func allValues(min, max int) uint64 {
+	return (uint64(1)<<uint(max+1) - 1) &^ (uint64(1)<<uint(min) - 1)
+}
+
+func neverFires(_ []string, s *cronexpr.Schedule, from time.Time) string {
+	if s.Reboot {
+		return ""
+	}
+	if _, ok := s.Next(from); ok {
+		return ""
+	}
+	return "never fires, because none of the months it names has the days it names"
+}
+
+func minuteWildcard(fields []string, s *cronexpr.Schedule, _ time.Time) string {
+	if s.Reboot || s.Minute != allValues(0, 59) || s.Hour == allValues(0, 23) {
+		return ""
+	}
+	fixed := strings.Join(append([]string{"0"}, fields[1:]...), " ")
+	if bits.OnesCount64(s.Hour) == 1 {
+		hour := bits.TrailingZeros64(s.Hour)
+		return fmt.Sprintf("fires every minute from %02d:00 to %02d:59, 60 times a day; %s runs once at %02d:00", hour, hour, fixed, hour)
+	}
+	return fmt.Sprintf("fires every minute of hours %s, %d times a day; %s runs once at the start of each hour",
+		fields[1], 60*bits.OnesCount64(s.Hour), fixed)
+}
+
+func dayOr(fields []string, s *cronexpr.Schedule, _ time.Time) string {
+	if s.Reboot || s.DayStar || s.WeekdayStar {
+		return ""
+	}
+	return fmt.Sprintf("runs on day of month %s or on weekday %s, not only when both match", fields[2], fields[4])
+}
+
+func shortMonths(fields []string, s *cronexpr.Schedule, _ time.Time) string {
+	// With both day fields restricted, the weekdays still run in every month
+	if s.Reboot || s.DayStar || !s.WeekdayStar {
+		return ""
+	}
+
+	var skipped []string
+	leapOnly := false
+	for m := time.January; m <= time.December; m++ {
+		if s.Month&(1<<uint(m)) == 0 {
+			continue
+		}
+		// The longest the month can be, and the length of February most years
+		days := time.Date(2024, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		if s.Day&allValues(1, days) == 0 {
+			skipped = append(skipped, m.String())
+		} else if m == time.February && s.Day&allValues(1, 28) == 0 {
+			leapOnly = true
+		}
+	}
+
+	var reasons []string
+	if len(skipped) > 0 && len(skipped) < bits.OnesCount64(s.Month) {
+		reasons = append(reasons, "never runs in "+english.List(skipped, "months"))
+	}
+	if leapOnly {
+		reasons = append(reasons, "runs in February only in leap years")
+	}
+	if len(reasons) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("day of month %s %s", fields[2], strings.Join(reasons, " and "))
+}
+
+func unevenStep(fields []string, s *cronexpr.Schedule, _ time.Time) string {
+	if s.Reboot {
+		return ""
+	}
+	checks := []struct {
+		field  string
+		set    uint64
+		period int
+		unit   string
+	}{
+		{fields[0], s.Minute, 60, "minutes"},
+		{fields[1], s.Hour, 24, "hours"},
+	}
+	for _, c := range checks {
+		if !strings.Contains(c.field, "/") {
+			continue
+		}
+		var values []int
+		for v := 0; v < c.period; v++ {
+			if c.set&(1<<uint(v)) != 0 {
+				values = append(values, v)
+			}
+		}
+		if len(values) < 2 {
+			continue
+		}
+		step := values[1] - values[0]
+		wrap := c.period - values[len(values)-1] + values[0]
+		if wrap < step {
+			return fmt.Sprintf("%s runs %d %s apart, but only %d %s apart from %d to %d",
+				c.field, step, c.unit, wrap, c.unit, values[len(values)-1], values[0])
+		}
+	}
+	return ""
+}
+
+// percentPattern matches a % that is not escaped with a backslash
+var percentPattern = regexp.MustCompile(`(^|[^\\])%`)
+
+func unescapedPercent(c *Crontab) []Finding {
+	var findings []Finding
+	for _, job := range c.Jobs {
+		if percentPattern.MatchString(job.Command) {
+			findings = append(findings, Finding{Line: job.Line, Message: "the command has an unescaped %, so cron cuts it short there"})
+		}
+	}
+	return findings
+}
+
+func missingPath(c *Crontab) []Finding {
+	if _, ok := c.Variable("PATH"); ok {
+		return nil
+	}
+	// Jobs that call every command by its full path do not need PATH
+	for _, job := range c.Jobs {
+		if fields := strings.Fields(job.Command); len(fields) > 0 && !strings.HasPrefix(fields[0], "/") {
+			return []Finding{{Line: job.Line, Message: fmt.Sprintf("PATH is not set, so %s may not be found", fields[0])}}
+		}
+	}
+	return nil
+}
+
+func missingShell(c *Crontab) []Finding {
+	if _, ok := c.Variable("SHELL"); ok || len(c.Jobs) == 0 {
+		return nil
+	}
+	return []Finding{{Message: "SHELL is not set, so jobs run with /bin/sh"}}
+}
@@ -0,0 +1,245 @@
+package crontab
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dwildt/cronkoans/internal/english"
+)
+
+// DefaultHotspot is how many jobs starting in the same minute make a hotspot
+const DefaultHotspot = 3
+
+// Load is how the jobs of a crontab start over a window
+type Load struct {
+	Start time.Time // first minute analysed
+	End   time.Time // end of the window, not included
+
+	// Peaks holds the most jobs that start together at each minute of the
+	// hour, such as Peaks[0] for the jobs starting on the hour
+	Peaks [60]int
+
+	// Starts is the number of job starts in the window
+	Starts int
+
+	Hotspots    []Hotspot
+	Overlaps    []Overlap
+	Suggestions []Suggestion
+}
+
+// Hotspot is a set of jobs that start in the same minute
+type Hotspot struct {
+	Jobs  []*Job
+	Times []string // times of the day they start together, such as 00:00
+	Days  int      // number of days in the window on which they do
+}
+
+// String describes the hotspot, such as "14 jobs start at 00:00"
+func (h Hotspot) String() string {
+	return fmt.Sprintf("%d jobs start at %s", len(h.Jobs), english.List(h.Times, "times"))
+}
+
+// Overlap is a job that can start while its previous run is still going
+type Overlap struct {
+	Job         *Job
+	Runs        int           // runs in the window
+	Overlapping int           // runs that start before the previous one is expected to end
+	Gap         time.Duration // shortest time between two runs
+}
+
+// String describes the overlap
+func (o Overlap) String() string {
+	return fmt.Sprintf("runs for %s but starts as little as %s apart: %d of %d runs start before the previous one ends",
+		formatDuration(o.Job.Duration), formatDuration(o.Gap), o.Overlapping, o.Runs)
+}
+
+// Suggestion moves a job that starts in a hotspot to a quieter minute
+type Suggestion struct {
+	Job        *Job
+	Expression string // the job's schedule with the minute changed
+}
+
+// Analyze works out when the jobs start from start up to, but not
+// including, end. Sets of at least hotspot jobs that start in the same
+// minute are hotspots; jobs in them that start at a single minute of the
+// hour get a suggestion to move to the least busy minutes.
+func (c *Crontab) Analyze(start, end time.Time, hotspot int) *Load {
+	if hotspot < 2 {
+		hotspot = 2
+	}
+	l := &Load{Start: start, End: end}
+
+	// Group the minutes with enough jobs by which jobs start in them
+	type group struct {
+		hotspot Hotspot
+		times   map[string]bool
+		days    map[string]bool
+	}
+	groups := make(map[string]*group)
+	var order []string
+	runs := make(map[*Job][]time.Time)
+
+	t := start.Truncate(time.Minute)
+	if t.Before(start) {
+		t = t.Add(time.Minute)
+	}
+	for ; t.Before(end); t = t.Add(time.Minute) {
+		var starting []*Job
+		for _, job := range c.Jobs {
+			if job.Schedule.Matches(t) {
+				starting = append(starting, job)
+				runs[job] = append(runs[job], t)
+			}
+		}
+		l.Starts += len(starting)
+		if len(starting) > l.Peaks[t.Minute()] {
+			l.Peaks[t.Minute()] = len(starting)
+		}
+		if len(starting) < hotspot {
+			continue
+		}
+
+		key := jobsKey(starting)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{hotspot: Hotspot{Jobs: starting}, times: make(map[string]bool), days: make(map[string]bool)}
+			groups[key] = g
+			order = append(order, key)
+		}
+		clock := t.Format("15:04")
+		if !g.times[clock] {
+			g.times[clock] = true
+			g.hotspot.Times = append(g.hotspot.Times, clock)
+		}
+		g.days[t.Format("2006-01-02")] = true
+	}
+
+	for _, key := range order {
+		g := groups[key]
+		sort.Strings(g.hotspot.Times)
+		g.hotspot.Days = len(g.days)
+		l.Hotspots = append(l.Hotspots, g.hotspot)
+	}
+	// Biggest first, then by time of day
+	sort.SliceStable(l.Hotspots, func(i, j int) bool {
+		a, b := l.Hotspots[i], l.Hotspots[j]
+		if len(a.Jobs) != len(b.Jobs) {
+			return len(a.Jobs) > len(b.Jobs)
+		}
+		return a.Times[0] < b.Times[0]
+	})
+
+	for _, job := range c.Jobs {
+		if o, ok := overlap(job, runs[job]); ok {
+			l.Overlaps = append(l.Overlaps, o)
+		}
+	}
+	l.Suggestions = c.stagger(l.Hotspots)
+	return l
+}
+
+// jobsKey identifies a set of jobs by their line numbers
+func jobsKey(jobs []*Job) string {
+	lines := make([]string, len(jobs))
+	for i, job := range jobs {
+		lines[i] = strconv.Itoa(job.Line)
+	}
+	return strings.Join(lines, ",")
+}
+
+// overlap checks whether runs of a job with a known duration start before
+// the previous run is expected to end
+func overlap(job *Job, runs []time.Time) (Overlap, bool) {
+	o := Overlap{Job: job, Runs: len(runs)}
+	if job.Duration == 0 || len(runs) == 0 {
+		return o, false
+	}
+
+	for i, run := range runs {
+		var next time.Time
+		if i+1 < len(runs) {
+			next = runs[i+1]
+		} else if n, ok := job.Schedule.Next(run); ok {
+			next = n
+		} else {
+			continue
+		}
+
+		gap := next.Sub(run)
+		if o.Gap == 0 || gap < o.Gap {
+			o.Gap = gap
+		}
+		// The run after this one starts before this one ends
+		if gap < job.Duration {
+			o.Overlapping++
+		}
+	}
+	return o, o.Overlapping > 0
+}
+
+// stagger suggests new minutes for jobs that start in hotspots, keeping the
+// first job of each hotspot in place. Only jobs that start at a single
+// minute of the hour can simply be moved. Each goes to the minute the fewest
+// jobs start at, the nearest one after its current minute on a tie.
+func (c *Crontab) stagger(hotspots []Hotspot) []Suggestion {
+	var load [60]int
+	for _, job := range c.Jobs {
+		for m := 0; m < 60; m++ {
+			if job.Schedule.Minute&(1<<uint(m)) != 0 {
+				load[m]++
+			}
+		}
+	}
+
+	var suggestions []Suggestion
+	moved := make(map[*Job]bool)
+	for _, h := range hotspots {
+		for _, job := range h.Jobs[1:] {
+			if moved[job] || job.Schedule.Reboot || bits.OnesCount64(job.Schedule.Minute) != 1 {
+				continue
+			}
+			from := bits.TrailingZeros64(job.Schedule.Minute)
+			to := from
+			for step := 1; step < 60; step++ {
+				m := (from + step) % 60
+				if load[m] < load[to] {
+					to = m
+				}
+			}
+			if to == from {
+				continue
+			}
+
+			load[from]--
+			load[to]++
+			moved[job] = true
+			// Shortcuts such as @hourly are spelled out to change their minute
+			fields := strings.Fields(job.Expression)
+			if len(fields) != 5 {
+				fields = strings.Fields(job.Schedule.String())
+			}
+			fields[0] = strconv.Itoa(to)
+			suggestions = append(suggestions, Suggestion{Job: job, Expression: strings.Join(fields, " ")})
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].Job.Line < suggestions[j].Job.Line
+	})
+	return suggestions
+}
+
+// formatDuration formats a duration without zero units, such as 10m or 1h30m
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
@@ -0,0 +1,115 @@
+package oncalendar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+)
+
+// Conversion is a schedule translated between cron and systemd
+type Conversion struct {
+	// Lines are what to write instead of the original: timer settings such
+	// as "OnCalendar=Mon..Fri *-*-* 09:00:00", or a cron expression
+	Lines []string
+
+	// Warnings describe where the result behaves differently from the
+	// original, or had to be written differently than expected
+	Warnings []string
+}
+
+// FromCron translates a cron expression into the settings of a systemd
+// timer. A schedule that restricts both the day of month and the day of
+// week needs two OnCalendar= lines, because cron runs it on days that match
+// either, and systemd only on days that match both. @reboot becomes
+// OnBootSec=, as it has no calendar time.
+func FromCron(expr string) (*Conversion, error) {
+	s, err := cronexpr.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conversion{}
+
+	if s.Reboot {
+		c.Lines = []string{"OnBootSec=0"}
+		c.Warnings = append(c.Warnings,
+			"@reboot has no calendar time, so the timer uses OnBootSec= instead of OnCalendar=; "+
+				"it runs when the system boots, where cron runs it when the cron daemon starts")
+		return c, nil
+	}
+	if strings.EqualFold(strings.TrimSpace(expr), "@weekly") {
+		c.Warnings = append(c.Warnings,
+			"cron's @weekly runs on Sundays but systemd's weekly runs on Mondays, so the timer names Sun instead of using weekly")
+	}
+
+	spec := &Spec{
+		Weekday: s.Weekday,
+		Month:   s.Month,
+		Day:     s.Day,
+		Hour:    s.Hour,
+		Minute:  s.Minute,
+		Second:  1,
+	}
+
+	// Cron only ORs the day fields when both are restricted
+	allDays, allWeekdays := fullSet(1, 31), fullSet(0, 6)
+	if !s.DayStar && !s.WeekdayStar {
+		if s.Day == allDays || s.Weekday == allWeekdays {
+			spec.Day, spec.Weekday = allDays, allWeekdays
+		} else {
+			byDay, byWeekday := *spec, *spec
+			byDay.Weekday = allWeekdays
+			byWeekday.Day = allDays
+			c.Lines = []string{"OnCalendar=" + byDay.String(), "OnCalendar=" + byWeekday.String()}
+			c.Warnings = append(c.Warnings,
+				"cron runs this on days that match the day of month or the day of week, but OnCalendar= needs both to match, "+
+					"so the timer has one OnCalendar= line for each")
+			return c, nil
+		}
+	}
+
+	c.Lines = []string{"OnCalendar=" + spec.String()}
+	return c, nil
+}
+
+// ToCron translates a calendar specification into a cron expression. It
+// fails for specs cron cannot express: ones that fire at seconds other than
+// :00, in some years only, on days counted from the end of the month, or
+// only on days that match both a day of month and a day of week.
+func ToCron(spec string) (*Conversion, error) {
+	s, err := Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	allDays, allWeekdays := fullSet(1, 31), fullSet(0, 6)
+	switch {
+	case s.Second != 1:
+		return nil, fmt.Errorf("it fires at seconds other than :00, but cron runs jobs at the start of a minute")
+	case s.Years != nil:
+		return nil, fmt.Errorf("it fires in some years only, but cron has no year field")
+	case s.LastDays:
+		return nil, fmt.Errorf("it counts days from the end of the month, which cron cannot do")
+	case s.Day != allDays && s.Weekday != allWeekdays:
+		return nil, fmt.Errorf("it fires only on days that match both the date and the day of week, " +
+			"but cron runs on days that match either")
+	}
+
+	c := &Conversion{}
+	schedule := &cronexpr.Schedule{
+		Minute:      s.Minute,
+		Hour:        s.Hour,
+		Day:         s.Day,
+		Month:       s.Month,
+		Weekday:     s.Weekday,
+		DayStar:     s.Day == allDays,
+		WeekdayStar: s.Weekday == allWeekdays,
+	}
+	c.Lines = []string{schedule.String()}
+	if s.Location != nil {
+		c.Warnings = append(c.Warnings, fmt.Sprintf(
+			"cron runs jobs in the system time zone, not %s; some crons accept CRON_TZ=%s at the top of the crontab",
+			s.Location, s.Location))
+	}
+	return c, nil
+}
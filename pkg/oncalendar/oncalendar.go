@@ -0,0 +1,577 @@
+// Package oncalendar parses and validates the calendar specifications of
+// systemd timers, as written after OnCalendar=, works out when they fire,
+// and converts between them and cron expressions.
+//
+// A specification has an optional day of week, an optional date and an
+// optional time, followed by an optional time zone:
+//
+//	Mon..Fri *-*-* 09:00:00
+//	*-*-01 00:00:00 UTC
+//	Sat,Sun 10:30
+//
+// Each date and time component can be *, a number, a range (1..5), a
+// repetition (00/15, starting at 00 and repeating every 15, or 1..20/5) or a
+// comma-separated list of those. A date with ~ instead of its second -
+// counts days from the end of the month: *-02~01 is the last day of
+// February. The shortcuts minutely, hourly, daily, weekly, monthly,
+// yearly, annually, quarterly and semiannually are accepted too.
+//
+// Unlike cron, systemd fires only on days that match both the day of week and
+// the date.
+package oncalendar
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The years systemd accepts in a calendar specification
+const (
+	minYear = 1970
+	maxYear = 2199
+)
+
+// shortcuts maps the systemd shortcuts to the specifications they stand for
+var shortcuts = map[string]string{
+	"minutely":     "*-*-* *:*:00",
+	"hourly":       "*-*-* *:00:00",
+	"daily":        "*-*-* 00:00:00",
+	"weekly":       "Mon *-*-* 00:00:00",
+	"monthly":      "*-*-01 00:00:00",
+	"yearly":       "*-01-01 00:00:00",
+	"annually":     "*-01-01 00:00:00",
+	"quarterly":    "*-01,04,07,10-01 00:00:00",
+	"semiannually": "*-01,07-01 00:00:00",
+}
+
+// weekdayNames are the names systemd accepts for the days of the week,
+// starting with Sunday as time.Weekday does
+var weekdayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+// Spec is a calendar specification expanded into the set of values each
+// component matches: bit v of Hour is set when the spec fires in hour v
+type Spec struct {
+	Weekday uint64 // bit 0 is Sunday, as in time.Weekday
+	Years   []int  // the years it fires in, in order; nil for every year
+	Month   uint64
+	Day     uint64
+	Hour    uint64
+	Minute  uint64
+	Second  uint64
+
+	// LastDays is set when the days count from the end of the month, as in
+	// *-02~01 for the last day of February
+	LastDays bool
+
+	// Location is the time zone named at the end of the spec, or nil for
+	// the local time zone
+	Location *time.Location
+}
+
+// Validate reports whether a calendar specification is one systemd accepts
+func Validate(spec string) error {
+	_, err := Parse(spec)
+	return err
+}
+
+// Parse parses a calendar specification, such as "Mon..Fri *-*-* 09:00:00"
+func Parse(spec string) (*Spec, error) {
+	words := strings.Fields(spec)
+	if len(words) == 0 {
+		return nil, errors.New("calendar specification is empty")
+	}
+	if full, ok := shortcuts[strings.ToLower(words[0])]; ok {
+		words = append(strings.Fields(full), words[1:]...)
+	}
+
+	s := &Spec{
+		Weekday: fullSet(0, 6),
+		Month:   fullSet(1, 12),
+		Day:     fullSet(1, 31),
+		Hour:    1,
+		Minute:  1,
+		Second:  1,
+	}
+
+	// Each part is optional, but they come in this order
+	i := 0
+	if i < len(words) && isWeekdayWord(words[i]) {
+		set, err := parseWeekdays(words[i])
+		if err != nil {
+			return nil, err
+		}
+		s.Weekday = set
+		i++
+	}
+	if i < len(words) && !strings.Contains(words[i], ":") && strings.ContainsAny(words[i], "-~") {
+		if err := s.parseDate(words[i]); err != nil {
+			return nil, err
+		}
+		i++
+	}
+	if i < len(words) && strings.Contains(words[i], ":") {
+		if err := s.parseTime(words[i]); err != nil {
+			return nil, err
+		}
+		i++
+	}
+	if i == 0 {
+		return nil, fmt.Errorf("%q is not a day of week, date, time or shortcut", words[0])
+	}
+	if i < len(words) {
+		loc, err := parseLocation(words[i])
+		if err != nil {
+			return nil, err
+		}
+		s.Location = loc
+		i++
+	}
+	if i < len(words) {
+		return nil, fmt.Errorf("unexpected %q after the time zone", strings.Join(words[i:], " "))
+	}
+	return s, nil
+}
+
+// isWeekdayWord reports whether a word of a spec lists days of the week
+func isWeekdayWord(word string) bool {
+	c := word[0]
+	if (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') {
+		return false
+	}
+	first := strings.FieldsFunc(word, func(r rune) bool { return r == ',' || r == '.' || r == '-' })
+	return len(first) > 0 && weekdayIndex(first[0]) >= 0
+}
+
+// weekdayIndex returns the day of the week a name stands for, or -1. Names
+// are the three-letter abbreviations or the full English names.
+func weekdayIndex(name string) int {
+	for i, full := range weekdayNames {
+		if strings.EqualFold(name, full) || strings.EqualFold(name, full[:3]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseWeekdays parses a list of days of the week such as Mon..Fri,Sun.
+// Ranges run from Monday to Sunday, as the week does in systemd.
+func parseWeekdays(word string) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(word, ",") {
+		if strings.Contains(item, "-") {
+			return 0, fmt.Errorf("invalid day of week range %q: ranges use .., as in Mon..Fri", item)
+		}
+		from, to, isRange := strings.Cut(item, "..")
+		start := weekdayIndex(from)
+		if start < 0 {
+			return 0, fmt.Errorf("invalid day of week %q", from)
+		}
+		end := start
+		if isRange {
+			if end = weekdayIndex(to); end < 0 {
+				return 0, fmt.Errorf("invalid day of week %q", to)
+			}
+		}
+		// Count from Monday, so that Mon..Sun covers the whole week
+		a, b := (start+6)%7, (end+6)%7
+		if a > b {
+			return 0, fmt.Errorf("day of week range %s runs backwards; the week starts on Monday", item)
+		}
+		for d := a; d <= b; d++ {
+			set |= 1 << uint((d+1)%7)
+		}
+	}
+	return set, nil
+}
+
+// parseDate parses [YEAR-]MONTH-DAY or [YEAR-]MONTH~DAY
+func (s *Spec) parseDate(word string) error {
+	var parts []string
+	if before, day, ok := strings.Cut(word, "~"); ok {
+		s.LastDays = true
+		parts = append(strings.Split(before, "-"), day)
+	} else {
+		parts = strings.Split(word, "-")
+	}
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("invalid date %q (want YEAR-MONTH-DAY or MONTH-DAY)", word)
+	}
+
+	if len(parts) == 3 {
+		if parts[0] != "*" {
+			years, err := expand(parts[0], minYear, maxYear, "year")
+			if err != nil {
+				return err
+			}
+			s.Years = years
+		}
+		parts = parts[1:]
+	}
+
+	var err error
+	if s.Month, err = expandSet(parts[0], 1, 12, "month"); err != nil {
+		return err
+	}
+	if s.Day, err = expandSet(parts[1], 1, 31, "day"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseTime parses HOUR:MINUTE[:SECOND]
+func (s *Spec) parseTime(word string) error {
+	parts := strings.Split(word, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("invalid time %q (want HOUR:MINUTE or HOUR:MINUTE:SECOND)", word)
+	}
+	if len(parts) == 2 {
+		parts = append(parts, "00")
+	}
+
+	var err error
+	if s.Hour, err = expandSet(parts[0], 0, 23, "hour"); err != nil {
+		return err
+	}
+	if s.Minute, err = expandSet(parts[1], 0, 59, "minute"); err != nil {
+		return err
+	}
+	if strings.Contains(parts[2], ".") {
+		return fmt.Errorf("fractions of a second are not supported: %s", parts[2])
+	}
+	if s.Second, err = expandSet(parts[2], 0, 59, "second"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseLocation parses the time zone at the end of a spec
+func parseLocation(word string) (*time.Location, error) {
+	if strings.EqualFold(word, "UTC") {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(word)
+	if err != nil || strings.ContainsAny(word, ":*") {
+		return nil, fmt.Errorf("unknown time zone %q", word)
+	}
+	return loc, nil
+}
+
+// expand turns one component, such as 1..5,10/15 or *, into the values it
+// matches, in order
+func expand(component string, min, max int, name string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, item := range strings.Split(component, ",") {
+		start, end, step := min, max, 1
+
+		rangePart := item
+		if before, after, ok := strings.Cut(item, "/"); ok {
+			n, err := strconv.Atoi(after)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid repetition %q in %s", after, name)
+			}
+			rangePart, step = before, n
+		}
+
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, ".."):
+			from, to, _ := strings.Cut(rangePart, "..")
+			a, err := parseValue(from, min, max, name)
+			if err != nil {
+				return nil, err
+			}
+			b, err := parseValue(to, min, max, name)
+			if err != nil {
+				return nil, err
+			}
+			if a > b {
+				return nil, fmt.Errorf("%s range %s runs backwards", name, rangePart)
+			}
+			start, end = a, b
+		default:
+			v, err := parseValue(rangePart, min, max, name)
+			if err != nil {
+				return nil, err
+			}
+			start = v
+			// A single value without a repetition matches only itself
+			if !strings.Contains(item, "/") {
+				end = v
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			seen[v] = true
+		}
+	}
+
+	values := make([]int, 0, len(seen))
+	for v := range seen {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+	return values, nil
+}
+
+// expandSet is expand for components that fit in a bit set
+func expandSet(component string, min, max int, name string) (uint64, error) {
+	values, err := expand(component, min, max, name)
+	if err != nil {
+		return 0, err
+	}
+	var set uint64
+	for _, v := range values {
+		set |= 1 << uint(v)
+	}
+	return set, nil
+}
+
+// parseValue parses one number of a component
+func parseValue(text string, min, max int, name string) (int, error) {
+	v, err := strconv.Atoi(text)
+	if err != nil || text == "" || text[0] == '-' || text[0] == '+' {
+		return 0, fmt.Errorf("invalid %s %q", name, text)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("%s %d out of bounds [%d-%d]", name, v, min, max)
+	}
+	return v, nil
+}
+
+// fullSet is the set of every value from min to max
+func fullSet(min, max int) uint64 {
+	return (uint64(1)<<uint(max+1) - 1) &^ (uint64(1)<<uint(min) - 1)
+}
+
+// hasBit reports whether value v is present in the set
+func hasBit(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+// matchesDay reports whether the spec fires on the calendar day t falls on
+func (s *Spec) matchesDay(t time.Time) bool {
+	if !hasBit(s.Weekday, int(t.Weekday())) || !hasBit(s.Month, int(t.Month())) || !s.matchesYear(t.Year()) {
+		return false
+	}
+	day := t.Day()
+	if s.LastDays {
+		day = daysIn(t.Year(), t.Month()) - day + 1
+	}
+	return hasBit(s.Day, day)
+}
+
+// matchesYear reports whether the spec fires in the year
+func (s *Spec) matchesYear(year int) bool {
+	if s.Years == nil {
+		return true
+	}
+	i := sort.SearchInts(s.Years, year)
+	return i < len(s.Years) && s.Years[i] == year
+}
+
+// daysIn returns the number of days in a month
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// Matches reports whether the spec fires at the second containing t
+func (s *Spec) Matches(t time.Time) bool {
+	if s.Location != nil {
+		t = t.In(s.Location)
+	}
+	return s.matchesDay(t) && hasBit(s.Hour, t.Hour()) && hasBit(s.Minute, t.Minute()) && hasBit(s.Second, t.Second())
+}
+
+// Next returns the first time strictly after the given one that the spec
+// fires at. The second return value is false if it never fires again.
+func (s *Spec) Next(after time.Time) (time.Time, bool) {
+	loc := after.Location()
+	if s.Location != nil {
+		loc = s.Location
+	}
+	start := after.In(loc).Truncate(time.Second).Add(time.Second)
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+
+	for year := start.Year(); year <= maxYear; year++ {
+		if !s.matchesYear(year) {
+			continue
+		}
+		for month := time.January; month <= time.December; month++ {
+			if !hasBit(s.Month, int(month)) {
+				continue
+			}
+			for day := 1; day <= daysIn(year, month); day++ {
+				date := time.Date(year, month, day, 0, 0, 0, 0, loc)
+				if date.Before(first) || !s.matchesDay(date) {
+					continue
+				}
+				if t, ok := s.firstTimeOn(date, start); ok {
+					return t, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// firstTimeOn returns the first time on the given day, not before start,
+// that the spec fires at
+func (s *Spec) firstTimeOn(date, start time.Time) (time.Time, bool) {
+	for h := 0; h < 24; h++ {
+		if !hasBit(s.Hour, h) {
+			continue
+		}
+		for m := 0; m < 60; m++ {
+			if !hasBit(s.Minute, m) {
+				continue
+			}
+			for sec := 0; sec < 60; sec++ {
+				if !hasBit(s.Second, sec) {
+					continue
+				}
+				t := time.Date(date.Year(), date.Month(), date.Day(), h, m, sec, 0, date.Location())
+				if !t.Before(start) {
+					return t, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// NextN returns up to n fire times after the given time
+func (s *Spec) NextN(after time.Time, n int) []time.Time {
+	var times []time.Time
+	t := after
+	for len(times) < n {
+		next, ok := s.Next(t)
+		if !ok {
+			break
+		}
+		times = append(times, next)
+		t = next
+	}
+	return times
+}
+
+// String returns the spec in the normalized form systemd-analyze calendar
+// prints, such as "Mon..Fri *-*-* 09:00:00"
+func (s *Spec) String() string {
+	var words []string
+	if s.Weekday != fullSet(0, 6) {
+		words = append(words, formatWeekdays(s.Weekday))
+	}
+
+	year := "*"
+	if s.Years != nil {
+		year = formatValues(s.Years, minYear, maxYear, 4)
+	}
+	separator := "-"
+	if s.LastDays {
+		separator = "~"
+	}
+	words = append(words, year+"-"+formatSet(s.Month, 1, 12)+separator+formatSet(s.Day, 1, 31))
+	words = append(words, formatSet(s.Hour, 0, 23)+":"+formatSet(s.Minute, 0, 59)+":"+formatSet(s.Second, 0, 59))
+
+	if s.Location != nil {
+		words = append(words, s.Location.String())
+	}
+	return strings.Join(words, " ")
+}
+
+// Equal reports whether two specs have the same normalized form, as specs
+// that are written differently but match the same values do
+func (s *Spec) Equal(other *Spec) bool {
+	return s.String() == other.String()
+}
+
+// formatWeekdays writes days of the week from Monday to Sunday, joining
+// three or more days in a row into a range such as Mon..Fri
+func formatWeekdays(set uint64) string {
+	var days []int // counted from Monday
+	for d := 0; d < 7; d++ {
+		if hasBit(set, (d+1)%7) {
+			days = append(days, d)
+		}
+	}
+	var parts []string
+	for _, run := range runs(days, 1) {
+		first, last := weekdayNames[(run[0]+1)%7][:3], weekdayNames[(run[len(run)-1]+1)%7][:3]
+		switch {
+		case len(run) >= 3:
+			parts = append(parts, first+".."+last)
+		case len(run) == 2:
+			parts = append(parts, first, last)
+		default:
+			parts = append(parts, first)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// formatSet writes a bit set of values from min to max
+func formatSet(set uint64, min, max int) string {
+	var values []int
+	for v := min; v <= max; v++ {
+		if hasBit(set, v) {
+			values = append(values, v)
+		}
+	}
+	return formatValues(values, min, max, 2)
+}
+
+// formatValues writes values from min to max as *, a repetition such as
+// 00/15 when they step evenly to the end, or a list of values and ranges,
+// with each number padded to width digits
+func formatValues(values []int, min, max, width int) string {
+	pad := func(v int) string {
+		return fmt.Sprintf("%0*d", width, v)
+	}
+	if len(values) == max-min+1 {
+		return "*"
+	}
+	if len(values) == 1 {
+		return pad(values[0])
+	}
+
+	// A repetition runs from its start to the largest value
+	if len(values) >= 3 {
+		step := values[1] - values[0]
+		even := step > 1
+		for i := 2; i < len(values) && even; i++ {
+			even = values[i]-values[i-1] == step
+		}
+		if even && values[len(values)-1]+step > max {
+			return pad(values[0]) + "/" + strconv.Itoa(step)
+		}
+	}
+
+	var parts []string
+	for _, run := range runs(values, 1) {
+		if len(run) >= 3 {
+			parts = append(parts, pad(run[0])+".."+pad(run[len(run)-1]))
+			continue
+		}
+		for _, v := range run {
+			parts = append(parts, pad(v))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// runs splits sorted values into runs that each go up by step
+func runs(values []int, step int) [][]int {
+	var result [][]int
+	for i, v := range values {
+		if i > 0 && v-values[i-1] == step {
+			result[len(result)-1] = append(result[len(result)-1], v)
+			continue
+		}
+		result = append(result, []int{v})
+	}
+	return result
+}
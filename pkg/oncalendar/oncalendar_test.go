@@ -0,0 +1,203 @@
+package oncalendar_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwildt/cronkoans/pkg/cronexpr"
+	"github.com/dwildt/cronkoans/pkg/oncalendar"
+)
+
+func TestParse(t *testing.T) {
+	normalized := map[string]string{
+		"Mon..Fri *-*-* 09:00:00":   "Mon..Fri *-*-* 09:00:00",
+		"mon,tue,wednesday 9:00":    "Mon..Wed *-*-* 09:00:00",
+		"Sat,Sun 10:30":             "Sat,Sun *-*-* 10:30:00",
+		"Fri,Mon":                   "Mon,Fri *-*-* 00:00:00",
+		"*:0/15":                    "*-*-* *:00/15:00",
+		"*-*-* 9..17:00":            "*-*-* 09..17:00:00",
+		"2026-11-03":                "2026-11-03 00:00:00",
+		"2024..2026-01-01 08:30:15": "2024..2026-01-01 08:30:15",
+		"*-02~01":                   "*-02~01 00:00:00",
+		"*-*-1,15 12:00 UTC":        "*-*-01,15 12:00:00 UTC",
+		"*-1,5,9-01":                "*-01/4-01 00:00:00",
+		"daily":                     "*-*-* 00:00:00",
+		"weekly":                    "Mon *-*-* 00:00:00",
+		"quarterly":                 "*-01/3-01 00:00:00",
+		"minutely":                  "*-*-* *:*:00",
+	}
+	for spec, want := range normalized {
+		s, err := oncalendar.Parse(spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", spec, err)
+			continue
+		}
+		if got := s.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", spec, got, want)
+		}
+	}
+
+	invalid := map[string]string{
+		"":                    "empty",
+		"Fri..Mon":            "runs backwards",
+		"Mon-Fri 09:00":       "ranges use ..",
+		"Mon *-13-01":         "month 13 out of bounds",
+		"*-*-* 24:00":         "hour 24 out of bounds",
+		"*-*-* 12:00:00.5":    "fractions of a second",
+		"*-*-* 12:00 Mars/Ol": "unknown time zone",
+		"0 9 * * 1-5":         "not a day of week, date, time or shortcut",
+		"1969-01-01":          "year 1969 out of bounds",
+		"*-*-* */0:00":        "invalid repetition",
+	}
+	for spec, want := range invalid {
+		if err := oncalendar.Validate(spec); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate(%q) = %v, want an error containing %q", spec, err, want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Saturday October 17th 2026
+	after := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"Mon..Fri 09:00", []string{"Mon 2026-10-19 09:00:00", "Tue 2026-10-20 09:00:00"}},
+		{"*:0/20", []string{"Sat 2026-10-17 12:20:00", "Sat 2026-10-17 12:40:00"}},
+		{"*-02~01", []string{"Sun 2027-02-28 00:00:00", "Tue 2028-02-29 00:00:00"}},
+		{"Fri *-*-13", []string{"Fri 2026-11-13 00:00:00", "Fri 2027-08-13 00:00:00"}},
+		{"2027-01-01", []string{"Fri 2027-01-01 00:00:00"}},
+		{"*-02-30", nil},
+	}
+	for _, tt := range tests {
+		s, err := oncalendar.Parse(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, next := range s.NextN(after, 2) {
+			got = append(got, next.Format("Mon 2006-01-02 15:04:05"))
+		}
+		if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("NextN(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestFromCron(t *testing.T) {
+	tests := []struct {
+		expr     string
+		lines    []string
+		warnings int
+	}{
+		{"0 9 * * 1-5", []string{"OnCalendar=Mon..Fri *-*-* 09:00:00"}, 0},
+		{"*/15 * * * *", []string{"OnCalendar=*-*-* *:00/15:00"}, 0},
+		{"30 2 1 */3 *", []string{"OnCalendar=*-01/3-01 02:30:00"}, 0},
+		{"0 0 * * 0,7", []string{"OnCalendar=Sun *-*-* 00:00:00"}, 0},
+		{"0 0 */2 * 1", []string{"OnCalendar=Mon *-*-01/2 00:00:00"}, 0},
+		{"0 0 13 * 5", []string{"OnCalendar=*-*-13 00:00:00", "OnCalendar=Fri *-*-* 00:00:00"}, 1},
+		{"0 0 1 * 0-6", []string{"OnCalendar=*-*-* 00:00:00"}, 0},
+		{"@weekly", []string{"OnCalendar=Sun *-*-* 00:00:00"}, 1},
+		{"@reboot", []string{"OnBootSec=0"}, 1},
+	}
+	for _, tt := range tests {
+		c, err := oncalendar.FromCron(tt.expr)
+		if err != nil {
+			t.Errorf("FromCron(%q): %v", tt.expr, err)
+			continue
+		}
+		if strings.Join(c.Lines, "\n") != strings.Join(tt.lines, "\n") || len(c.Warnings) != tt.warnings {
+			t.Errorf("FromCron(%q) = %q with warnings %q", tt.expr, c.Lines, c.Warnings)
+		}
+	}
+	if _, err := oncalendar.FromCron("0 9 * *"); err == nil {
+		t.Error("FromCron of an invalid expression: no error")
+	}
+}
+
+// TestFromCronFiresTheSame checks minute by minute that the timer fires
+// whenever cron would
+func TestFromCronFiresTheSame(t *testing.T) {
+	exprs := []string{"0 9 * * 1-5", "*/7 1-3 * * *", "0 0 13 * 5", "15 4 */10 2,8 *", "0 12 29-31 * *", "0 0 1,15 * 1-3"}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, expr := range exprs {
+		schedule, _ := cronexpr.Parse(expr)
+		c, err := oncalendar.FromCron(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var specs []*oncalendar.Spec
+		for _, line := range c.Lines {
+			s, err := oncalendar.Parse(strings.TrimPrefix(line, "OnCalendar="))
+			if err != nil {
+				t.Fatalf("%s: %v", line, err)
+			}
+			specs = append(specs, s)
+		}
+
+		for m := start; m.Before(start.AddDate(0, 3, 0)); m = m.Add(time.Minute) {
+			fires := false
+			for _, s := range specs {
+				fires = fires || s.Matches(m)
+			}
+			if fires != schedule.Matches(m) {
+				t.Errorf("%s -> %v: cron fires at %s is %v, the timer %v", expr, c.Lines, m, schedule.Matches(m), fires)
+				break
+			}
+		}
+	}
+}
+
+func TestToCron(t *testing.T) {
+	tests := map[string]string{
+		"Mon..Fri *-*-* 09:00:00": "0 9 * * 1-5",
+		"*:0/15":                  "*/15 * * * *",
+		"Sat,Sun 10:30":           "30 10 * * 0,6",
+		"*-*-01,15 06:00":         "0 6 1,15 * *",
+		"monthly":                 "0 0 1 * *",
+		"weekly":                  "0 0 * * 1",
+	}
+	for spec, want := range tests {
+		c, err := oncalendar.ToCron(spec)
+		if err != nil {
+			t.Errorf("ToCron(%q): %v", spec, err)
+			continue
+		}
+		if len(c.Lines) != 1 || c.Lines[0] != want || len(c.Warnings) != 0 {
+			t.Errorf("ToCron(%q) = %q with warnings %q, want %q", spec, c.Lines, c.Warnings, want)
+		}
+	}
+
+	c, err := oncalendar.ToCron("*-*-* 03:00 UTC")
+	if err != nil || c.Lines[0] != "0 3 * * *" || len(c.Warnings) != 1 {
+		t.Errorf("ToCron with a time zone = %+v, %v", c, err)
+	}
+
+	unrepresentable := map[string]string{
+		"*-*-* *:*:30":     "seconds",
+		"2027-01-01":       "some years only",
+		"*-02~01":          "end of the month",
+		"Fri *-*-13 00:00": "both the date and the day of week",
+	}
+	for spec, want := range unrepresentable {
+		if _, err := oncalendar.ToCron(spec); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ToCron(%q) = %v, want an error containing %q", spec, err, want)
+		}
+	}
+}
+
+func ExampleFromCron() {
+	c, err := oncalendar.FromCron("0 0 13 * FRI")
+	if err != nil {
+		panic(err)
+	}
+	for _, line := range c.Lines {
+		fmt.Println(line)
+	}
+	// Output:
+	// OnCalendar=*-*-13 00:00:00
+	// OnCalendar=Fri *-*-* 00:00:00
+}